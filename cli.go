@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"server_new/config"
+	"server_new/services"
+	"server_new/utils"
+)
+
+// runCommand выполняет служебную команду из аргументов запуска (например, create-admin).
+// Возвращает false, если команды нет и нужно запускать сервер.
+func runCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	switch args[0] {
+	case "create-admin":
+		if err := createAdminCommand(args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, "Ошибка:", err)
+			os.Exit(1)
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// createAdminCommand создаёт первого администратора (или повышает существующего пользователя).
+// Пароль можно передать флагом, переменной ADMIN_PASSWORD или ввести в терминале.
+//
+//	server create-admin -email admin@example.com -username admin
+func createAdminCommand(args []string) error {
+	fs := flag.NewFlagSet("create-admin", flag.ContinueOnError)
+	username := fs.String("username", "admin", "имя пользователя")
+	email := fs.String("email", "", "email администратора (обязательно)")
+	password := fs.String("password", "", "пароль (если не указан, берётся из ADMIN_PASSWORD или запрашивается)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	normalizedEmail := strings.TrimSpace(strings.ToLower(*email))
+	if !utils.ValidateEmail(normalizedEmail) {
+		return fmt.Errorf("укажи корректный email через -email")
+	}
+	cleanUsername := strings.TrimSpace(*username)
+	if ok, msg := utils.ValidateUsername(cleanUsername); !ok {
+		return fmt.Errorf("%s", msg)
+	}
+
+	if err := config.Load(); err != nil {
+		return fmt.Errorf("ошибка загрузки конфигурации: %v", err)
+	}
+	if err := config.InitDB(); err != nil {
+		return fmt.Errorf("ошибка инициализации БД: %v", err)
+	}
+	defer config.CloseDB()
+
+	rawPassword := strings.TrimSpace(*password)
+	if rawPassword == "" {
+		rawPassword = strings.TrimSpace(os.Getenv("ADMIN_PASSWORD"))
+	}
+
+	service := services.NewAdminService()
+
+	// Пароль нужен только для нового пользователя; существующему просто назначаем роль
+	exists, err := userExists(normalizedEmail)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if rawPassword == "" {
+			fmt.Print("Пароль: ")
+			line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil && line == "" {
+				return fmt.Errorf("не удалось прочитать пароль: %v", err)
+			}
+			rawPassword = strings.TrimSpace(line)
+		}
+		if ok, msg := utils.ValidatePassword(rawPassword); !ok {
+			return fmt.Errorf("%s", msg)
+		}
+	}
+
+	id, created, err := service.CreateAdmin(cleanUsername, normalizedEmail, rawPassword)
+	if err != nil {
+		return err
+	}
+
+	if created {
+		fmt.Printf("Администратор %s (ID %d) создан\n", normalizedEmail, id)
+	} else {
+		fmt.Printf("Пользователю %s (ID %d) назначена роль admin\n", normalizedEmail, id)
+	}
+	return nil
+}
+
+// userExists проверяет, зарегистрирован ли email
+func userExists(email string) (bool, error) {
+	var n int
+	if err := config.DB.QueryRow("SELECT COUNT(*) FROM users WHERE email = ?", email).Scan(&n); err != nil {
+		return false, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	return n > 0, nil
+}
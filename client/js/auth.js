@@ -13,14 +13,22 @@ async function apiRequest(endpoint, options = {}) {
         },
     };
 
-    // Добавляем токен, если он есть
+    // Добавляем токен, если он есть (вход без cookie)
     const token = localStorage.getItem('token');
     if (token) {
         defaultOptions.headers['Authorization'] = `Bearer ${token}`;
     }
 
+    // При входе через cookie изменяющие запросы подтверждаются CSRF токеном
+    const method = (options.method || 'GET').toUpperCase();
+    const csrfToken = getCSRFToken();
+    if (csrfToken && !['GET', 'HEAD', 'OPTIONS'].includes(method)) {
+        defaultOptions.headers['X-CSRF-Token'] = csrfToken;
+    }
+
     const config = {
         ...defaultOptions,
+        credentials: 'same-origin',
         ...options,
         headers: {
             ...defaultOptions.headers,
@@ -29,8 +37,15 @@ async function apiRequest(endpoint, options = {}) {
     };
 
     try {
-        const response = await fetch(url, config);
-        const data = await response.json();
+        let response = await fetch(url, config);
+
+        // Access токен живёт недолго: при 401 пробуем обновить его и повторить запрос
+        if (response.status === 401 && !options._retried && await refreshTokens()) {
+            return await apiRequest(endpoint, { ...options, _retried: true });
+        }
+
+        // 204 No Content не содержит тела
+        const data = response.status === 204 ? {} : await response.json();
 
         if (!response.ok) {
             return {
@@ -51,6 +66,62 @@ async function apiRequest(endpoint, options = {}) {
     }
 }
 
+/**
+ * Читает CSRF токен из cookie (её выставляет сервер при входе через cookie)
+ */
+function getCSRFToken() {
+    const match = document.cookie.match(/(?:^|;\s*)csrf_token=([^;]*)/);
+    return match ? decodeURIComponent(match[1]) : '';
+}
+
+/**
+ * Сохраняет токены, полученные при входе или обновлении.
+ * При входе через cookie токены в ответе не приходят: они в HttpOnly cookie
+ */
+function saveTokens(data) {
+    if (data.jwt) {
+        localStorage.setItem('token', data.jwt);
+    }
+    if (data.refreshToken) {
+        localStorage.setItem('refreshToken', data.refreshToken);
+    }
+}
+
+/**
+ * Обновляет access токен с помощью refresh токена (из localStorage или cookie).
+ * Возвращает true, если удалось получить новую пару токенов.
+ */
+async function refreshTokens() {
+    const refreshToken = localStorage.getItem('refreshToken');
+    const csrfToken = getCSRFToken();
+    if (!refreshToken && !csrfToken) {
+        return false;
+    }
+
+    const headers = { 'Content-Type': 'application/json' };
+    if (!refreshToken) {
+        headers['X-CSRF-Token'] = csrfToken;
+    }
+
+    try {
+        const response = await fetch(`${API_BASE_URL}/auth/refresh`, {
+            method: 'POST',
+            headers,
+            credentials: 'same-origin',
+            body: refreshToken ? JSON.stringify({ refreshToken }) : '{}',
+        });
+        if (!response.ok) {
+            localStorage.removeItem('token');
+            localStorage.removeItem('refreshToken');
+            return false;
+        }
+        saveTokens(await response.json());
+        return true;
+    } catch (error) {
+        return false;
+    }
+}
+
 /**
  * Регистрация нового пользователя
  */
@@ -74,6 +145,46 @@ async function login(email, password) {
         body: JSON.stringify({
             email,
             password,
+            useCookie: true,
+        }),
+    });
+}
+
+/**
+ * Второй шаг входа при включённой двухфакторной аутентификации
+ */
+async function verifyTwoFactor(twoFactorToken, code) {
+    return await apiRequest('/auth/2fa/verify', {
+        method: 'POST',
+        body: JSON.stringify({
+            twoFactorToken,
+            code,
+            useCookie: true,
+        }),
+    });
+}
+
+/**
+ * Запрос письма для сброса пароля
+ */
+async function requestPasswordReset(email) {
+    return await apiRequest('/auth/password-reset/request', {
+        method: 'POST',
+        body: JSON.stringify({
+            email,
+        }),
+    });
+}
+
+/**
+ * Установка нового пароля по токену из письма
+ */
+async function confirmPasswordReset(token, newPassword) {
+    return await apiRequest('/auth/password-reset/confirm', {
+        method: 'POST',
+        body: JSON.stringify({
+            token,
+            newPassword,
         }),
     });
 }
@@ -82,8 +193,7 @@ async function login(email, password) {
  * Получение информации о текущем пользователе
  */
 async function getCurrentUser() {
-    const token = localStorage.getItem('token');
-    if (!token) {
+    if (!isAuthenticated()) {
         return {
             success: false,
             error: 'Токен не найден',
@@ -98,8 +208,17 @@ async function getCurrentUser() {
 /**
  * Выход из системы
  */
-function logout() {
+async function logout() {
+    const refreshToken = localStorage.getItem('refreshToken');
+    if (refreshToken || getCSRFToken()) {
+        // При входе через cookie сервер сам возьмёт refresh токен из cookie и удалит её
+        await apiRequest('/auth/logout', {
+            method: 'POST',
+            body: refreshToken ? JSON.stringify({ refreshToken }) : '{}',
+        });
+    }
     localStorage.removeItem('token');
+    localStorage.removeItem('refreshToken');
     localStorage.removeItem('user');
     window.location.href = 'index.html';
 }
@@ -108,7 +227,7 @@ function logout() {
  * Проверка, авторизован ли пользователь
  */
 function isAuthenticated() {
-    return !!localStorage.getItem('token');
+    return !!localStorage.getItem('token') || !!localStorage.getItem('refreshToken') || !!getCSRFToken();
 }
 
 /**
@@ -1,19 +1,106 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"log/slog"
+	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// Что разрешено пользователям с неподтверждённым email
+const (
+	UnverifiedPolicyAllow      = "allow"       // всё, как у подтверждённых
+	UnverifiedPolicyReadOnly   = "read_only"   // вход разрешён, изменять задачи нельзя
+	UnverifiedPolicyBlockLogin = "block_login" // вход запрещён до подтверждения
+)
+
+// Окружения запуска
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+// DefaultJWTSecret — секрет по умолчанию, годится только для разработки
+const DefaultJWTSecret = "dev-secret-change-me"
+
+// insecureJWTSecrets — заведомо известные секреты (значение по умолчанию и пример из .env.example)
+var insecureJWTSecrets = map[string]bool{
+	"":               true,
+	DefaultJWTSecret: true,
+	"your-super-secret-key-change-me-in-production": true,
+}
+
 var (
+	// Окружение: development или production
+	AppEnv string
+
 	Port           string
 	JWTSecret      string
 	DBPath         string
 	AllowedOrigins []string
+
+	// Асимметричная подпись JWT: закрытый ключ (PEM, Ed25519 или RSA) для новых токенов
+	// и ключи прошлых поколений, которые ещё принимаются при проверке (для ротации)
+	JWTSigningKeyFile       string
+	JWTVerificationKeyFiles []string
+
+	// Ключ HMAC-подписи курсоров пагинации (по умолчанию — JWTSecret)
+	CursorSecret string
+
+	// Публичный адрес приложения (используется для ссылок в письмах)
+	AppBaseURL string
+
+	// Настройки отправки писем
+	MailDriver   string // "log" или "smtp"
+	MailFrom     string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+
+	// Время жизни токена для сброса пароля
+	PasswordResetTTL time.Duration
+
+	// Время жизни access токена (JWT) и refresh токена
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// Подтверждение email: время жизни ссылки, минимальный интервал повторной отправки
+	// и политика для неподтверждённых пользователей (см. UnverifiedPolicy*)
+	EmailVerificationTTL   time.Duration
+	VerificationResendWait time.Duration
+	UnverifiedPolicy       string
+
+	// Защита от перебора: сколько неудачных попыток допускается для аккаунта и для IP
+	// в пределах окна, начальная и максимальная длительность блокировки
+	LoginMaxAttempts      int
+	LoginMaxAttemptsPerIP int
+	LoginFailureWindow    time.Duration
+	LockoutBase           time.Duration
+	LockoutMax            time.Duration
+
+	// Общее ограничение частоты запросов к /auth/* с одного IP
+	AuthRateLimit  int
+	AuthRateWindow time.Duration
+
+	// Доверять заголовку X-Forwarded-For (если сервер стоит за прокси)
+	TrustProxy bool
+
+	// Вход через cookie (для встроенного веб-клиента): разрешён ли он и параметры cookie
+	CookieAuth     bool
+	CookieSecure   bool
+	CookieSameSite http.SameSite
+	CookieDomain   string
+
+	// Зависимости задач: незавершённые блокирующие задачи всегда мешают завершить задачу,
+	// а с этим флагом — и взять её в работу (in_progress)
+	DependenciesBlockInProgress bool
 )
 
 // Load загружает переменные окружения
@@ -23,8 +110,22 @@ func Load() error {
 		log.Println("Файл .env не найден, используем переменные окружения системы")
 	}
 
+	AppEnv = strings.ToLower(getEnv("APP_ENV", EnvDevelopment))
 	Port = getEnv("PORT", "4000")
-	JWTSecret = getEnv("JWT_SECRET", "dev-secret-change-me")
+	JWTSecret = getEnv("JWT_SECRET", DefaultJWTSecret)
+	JWTSigningKeyFile = getEnv("JWT_SIGNING_KEY_FILE", "")
+	JWTVerificationKeyFiles = getEnvList("JWT_VERIFICATION_KEY_FILES")
+
+	// В production нельзя подписывать токены секретом, который знают все
+	if AppEnv == EnvProduction && JWTSigningKeyFile == "" && insecureJWTSecrets[JWTSecret] {
+		return fmt.Errorf("в production задайте JWT_SECRET (не значение по умолчанию) или JWT_SIGNING_KEY_FILE")
+	}
+	// Курсоры подписываются CURSOR_SECRET или JWT_SECRET; при подписи JWT ключом из файла
+	// JWT_SECRET может остаться значением по умолчанию, и тогда курсоры можно подделать
+	CursorSecret = getEnv("CURSOR_SECRET", JWTSecret)
+	if AppEnv == EnvProduction && insecureJWTSecrets[CursorSecret] {
+		return fmt.Errorf("в production задайте CURSOR_SECRET или JWT_SECRET (не значение по умолчанию)")
+	}
 	DBPath = getEnv("DB_PATH", ".tmp/base.sqlite")
 
 	originsStr := getEnv("ALLOWED_ORIGINS", "http://localhost:3000")
@@ -35,6 +136,56 @@ func Load() error {
 		AllowedOrigins[i] = strings.TrimSpace(AllowedOrigins[i])
 	}
 
+	AppBaseURL = strings.TrimRight(getEnv("APP_BASE_URL", "http://localhost:"+Port), "/")
+
+	MailDriver = strings.ToLower(getEnv("MAIL_DRIVER", "log"))
+	MailFrom = getEnv("MAIL_FROM", "no-reply@localhost")
+	SMTPHost = getEnv("SMTP_HOST", "localhost")
+	SMTPPort = getEnv("SMTP_PORT", "1025") // порт MailHog по умолчанию
+	SMTPUsername = getEnv("SMTP_USERNAME", "")
+	SMTPPassword = getEnv("SMTP_PASSWORD", "")
+
+	PasswordResetTTL = getEnvDuration("PASSWORD_RESET_TTL", time.Hour)
+
+	AccessTokenTTL = getEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute)
+	RefreshTokenTTL = getEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+
+	TrustProxy = getEnvBool("TRUST_PROXY", false)
+
+	CookieAuth = getEnvBool("COOKIE_AUTH", true)
+	CookieSecure = getEnvBool("COOKIE_SECURE", AppEnv == EnvProduction)
+	CookieDomain = getEnv("COOKIE_DOMAIN", "")
+	switch strings.ToLower(getEnv("COOKIE_SAMESITE", "lax")) {
+	case "strict":
+		CookieSameSite = http.SameSiteStrictMode
+	case "lax":
+		CookieSameSite = http.SameSiteLaxMode
+	default:
+		log.Printf("Некорректное значение COOKIE_SAMESITE, используем lax")
+		CookieSameSite = http.SameSiteLaxMode
+	}
+
+	DependenciesBlockInProgress = getEnvBool("DEPENDENCIES_BLOCK_IN_PROGRESS", false)
+
+	LoginMaxAttempts = getEnvInt("LOGIN_MAX_ATTEMPTS", 5)
+	LoginMaxAttemptsPerIP = getEnvInt("LOGIN_MAX_ATTEMPTS_PER_IP", 20)
+	LoginFailureWindow = getEnvDuration("LOGIN_FAILURE_WINDOW", 15*time.Minute)
+	LockoutBase = getEnvDuration("LOGIN_LOCKOUT_BASE", time.Minute)
+	LockoutMax = getEnvDuration("LOGIN_LOCKOUT_MAX", time.Hour)
+
+	AuthRateLimit = getEnvInt("AUTH_RATE_LIMIT", 20)
+	AuthRateWindow = getEnvDuration("AUTH_RATE_WINDOW", time.Minute)
+
+	EmailVerificationTTL = getEnvDuration("EMAIL_VERIFICATION_TTL", 24*time.Hour)
+	VerificationResendWait = getEnvDuration("EMAIL_VERIFICATION_RESEND_INTERVAL", time.Minute)
+	UnverifiedPolicy = strings.ToLower(getEnv("UNVERIFIED_POLICY", UnverifiedPolicyReadOnly))
+	switch UnverifiedPolicy {
+	case UnverifiedPolicyAllow, UnverifiedPolicyReadOnly, UnverifiedPolicyBlockLogin:
+	default:
+		log.Printf("Неизвестная UNVERIFIED_POLICY=%q, используем %s", UnverifiedPolicy, UnverifiedPolicyReadOnly)
+		UnverifiedPolicy = UnverifiedPolicyReadOnly
+	}
+
 	return nil
 }
 
@@ -47,6 +198,60 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
+// getEnvList читает список значений через запятую (пустые элементы пропускаются)
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getEnvDuration читает длительность в формате time.ParseDuration (например, "15m", "1h")
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Некорректное значение %s=%q, используем %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return d
+}
+
+// getEnvInt читает положительное целое число из переменной окружения
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		log.Printf("Некорректное значение %s=%q, используем %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return n
+}
+
+// getEnvBool читает логическое значение (true/false, 1/0, yes/no)
+func getEnvBool(key string, defaultValue bool) bool {
+	switch strings.ToLower(os.Getenv(key)) {
+	case "":
+		return defaultValue
+	case "1", "true", "yes", "on":
+		return true
+	case "0", "false", "no", "off":
+		return false
+	default:
+		log.Printf("Некорректное значение %s, используем %t", key, defaultValue)
+		return defaultValue
+	}
+}
+
 // GetLogLevel возвращает уровень логирования из конфигурации
 func GetLogLevel() slog.Level {
 	levelStr := getEnv("LOG_LEVEL", "info")
@@ -6,6 +6,7 @@ import (
 	"log"           // для логов
 	"os"            // для работы с файловой системой
 	"path/filepath" // для работы с путями
+	"strings"       // для работы со строками
 	"time"          // для работы со временем
 
 	"server_new/migrations" // для миграций БД
@@ -33,7 +34,7 @@ func InitDB() error {
 
 	// Открываем соединение с базой данных
 	// Если файла нет, SQLite создаст его автоматически
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := sql.Open("sqlite3", DataSourceName(dbPath))
 	if err != nil {
 		return fmt.Errorf("не удалось открыть базу данных: %v", err)
 	}
@@ -48,10 +49,13 @@ func InitDB() error {
 	db.SetMaxIdleConns(5)
 	db.SetConnMaxLifetime(5 * time.Minute)
 
-	// Включаем поддержку внешних ключей (важно для связей между таблицами)
-	_, err = db.Exec("PRAGMA foreign_keys = ON;")
-	if err != nil {
-		return fmt.Errorf("не удалось включить внешние ключи: %v", err)
+	// Внешние ключи включает драйвер (см. DataSourceName); проверяем, что он это сделал
+	var foreignKeys bool
+	if err := db.QueryRow("PRAGMA foreign_keys").Scan(&foreignKeys); err != nil {
+		return fmt.Errorf("не удалось проверить внешние ключи: %v", err)
+	}
+	if !foreignKeys {
+		return fmt.Errorf("не удалось включить внешние ключи")
 	}
 
 	// Запускаем миграции
@@ -64,6 +68,17 @@ func InitDB() error {
 	return nil
 }
 
+// DataSourceName возвращает строку подключения к SQLite с включёнными внешними ключами.
+// PRAGMA foreign_keys действует только на одно соединение, а в пуле их несколько,
+// поэтому включаем ключи параметром драйвера — он выполняет PRAGMA на каждом новом соединении.
+func DataSourceName(path string) string {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return path + separator + "_foreign_keys=on"
+}
+
 // CloseDB закрывает соединение с базой данных
 func CloseDB() error {
 	if DB != nil {
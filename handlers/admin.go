@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"server_new/models"
+	"server_new/services"
+	"server_new/utils"
+)
+
+// AdminHandler обрабатывает запросы к /admin (только для администраторов)
+type AdminHandler struct {
+	service *services.AdminService
+}
+
+func NewAdminHandler() *AdminHandler {
+	return &AdminHandler{
+		service: services.NewAdminService(),
+	}
+}
+
+// ListUsers возвращает пользователей с поиском и пагинацией
+// @Summary Список пользователей
+// @Tags admin
+// @Produce json
+// @Param q query string false "Поиск по username или email"
+// @Param role query string false "Фильтр по роли" Enums(user, admin)
+// @Param disabled query bool false "Только отключённые (true) или активные (false)"
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество на странице" default(20)
+// @Success 200 {array} models.User
+// @Header 200 {string} X-Total-Count "Общее количество пользователей"
+// @Router /admin/users [get]
+// @Security BearerAuth
+func (h *AdminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+		return
+	}
+
+	query := r.URL.Query()
+
+	page, _ := strconv.Atoi(query.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	filter := services.UserFilter{Query: query.Get("q")}
+
+	if role := query.Get("role"); role != "" {
+		if role != models.RoleUser && role != models.RoleAdmin {
+			sendError(w, http.StatusBadRequest, "Неизвестная роль")
+			return
+		}
+		filter.Role = role
+	}
+
+	if disabledStr := query.Get("disabled"); disabledStr != "" {
+		disabled, err := strconv.ParseBool(disabledStr)
+		if err != nil {
+			sendError(w, http.StatusBadRequest, "Параметр disabled должен быть true или false")
+			return
+		}
+		filter.Disabled = &disabled
+	}
+
+	users, total, err := h.service.ListUsers(filter, page, limit)
+	if err != nil {
+		utils.LogError(err, "Ошибка получения списка пользователей")
+		sendError(w, http.StatusInternalServerError, "Не удалось получить пользователей")
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	sendJSON(w, http.StatusOK, users)
+}
+
+// User обрабатывает /admin/users/{id} и действия над пользователем:
+// GET /admin/users/{id}, POST /admin/users/{id}/disable, /enable, /password-reset
+func (h *AdminHandler) User(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/admin/users/"), "/")
+	if len(parts) > 2 {
+		sendError(w, http.StatusNotFound, "Маршрут не найден")
+		return
+	}
+
+	userID, err := strconv.Atoi(parts[0])
+	if err != nil || userID < 1 {
+		sendError(w, http.StatusBadRequest, "Неверный ID пользователя")
+		return
+	}
+
+	action := ""
+	if len(parts) == 2 {
+		action = parts[1]
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		h.getUser(w, userID)
+	case action == "disable" && r.Method == http.MethodPost:
+		h.setDisabled(w, r, userID, true)
+	case action == "enable" && r.Method == http.MethodPost:
+		h.setDisabled(w, r, userID, false)
+	case action == "password-reset" && r.Method == http.MethodPost:
+		h.forcePasswordReset(w, userID)
+	case action == "" || action == "disable" || action == "enable" || action == "password-reset":
+		sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+	default:
+		sendError(w, http.StatusNotFound, "Маршрут не найден")
+	}
+}
+
+// getUser возвращает карточку пользователя с количеством задач по статусам
+func (h *AdminHandler) getUser(w http.ResponseWriter, userID int) {
+	details, err := h.service.GetUser(userID)
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			sendError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.LogError(err, "Ошибка получения пользователя", "userID", userID)
+		sendError(w, http.StatusInternalServerError, "Не удалось получить пользователя")
+		return
+	}
+
+	sendJSON(w, http.StatusOK, details)
+}
+
+// setDisabled отключает или включает аккаунт
+func (h *AdminHandler) setDisabled(w http.ResponseWriter, r *http.Request, userID int, disabled bool) {
+	adminID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	if err := h.service.SetDisabled(adminID, userID, disabled); err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			sendError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, services.ErrCannotDisableSelf):
+			sendError(w, http.StatusBadRequest, err.Error())
+		default:
+			utils.LogError(err, "Ошибка изменения статуса пользователя", "userID", userID)
+			sendError(w, http.StatusInternalServerError, "Не удалось изменить статус пользователя")
+		}
+		return
+	}
+
+	utils.LogInfo("Статус пользователя изменён администратором", "adminID", adminID, "userID", userID, "disabled", disabled)
+	sendJSON(w, http.StatusOK, map[string]bool{"success": true, "disabled": disabled})
+}
+
+// forcePasswordReset сбрасывает пароль и отправляет пользователю ссылку для установки нового
+func (h *AdminHandler) forcePasswordReset(w http.ResponseWriter, userID int) {
+	if err := h.service.ForcePasswordReset(userID); err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			sendError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.LogError(err, "Ошибка принудительного сброса пароля", "userID", userID)
+		sendError(w, http.StatusInternalServerError, "Не удалось сбросить пароль")
+		return
+	}
+
+	sendJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
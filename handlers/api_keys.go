@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"server_new/models"
+	"server_new/services"
+	"server_new/utils"
+)
+
+// maxAPIKeyNameLength — максимальная длина названия ключа
+const maxAPIKeyNameLength = 100
+
+// APIKeysHandler обрабатывает запросы к /me/api-keys
+type APIKeysHandler struct {
+	service *services.APIKeysService
+}
+
+func NewAPIKeysHandler() *APIKeysHandler {
+	return &APIKeysHandler{
+		service: services.NewAPIKeysService(),
+	}
+}
+
+// List возвращает ключи текущего пользователя (без самих значений)
+// @Summary Список API ключей
+// @Tags api-keys
+// @Produce json
+// @Success 200 {array} models.APIKey
+// @Router /me/api-keys [get]
+// @Security BearerAuth
+func (h *APIKeysHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	keys, err := h.service.ListByUserID(userID)
+	if err != nil {
+		utils.LogError(err, "Ошибка получения API ключей", "userID", userID)
+		sendError(w, http.StatusInternalServerError, "Не удалось получить API ключи")
+		return
+	}
+
+	sendJSON(w, http.StatusOK, keys)
+}
+
+// Create создаёт API ключ. Значение ключа возвращается только в этом ответе.
+// @Summary Создать API ключ
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Router /me/api-keys [post]
+// @Security BearerAuth
+func (h *APIKeysHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	var requestData struct {
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expires_at"` // необязательно, RFC 3339
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		sendError(w, http.StatusBadRequest, "Неверный формат JSON")
+		return
+	}
+
+	name := strings.TrimSpace(requestData.Name)
+	if name == "" || len([]rune(name)) > maxAPIKeyNameLength {
+		sendError(w, http.StatusBadRequest, "Укажи название ключа (до 100 символов)")
+		return
+	}
+
+	if requestData.ExpiresAt != nil && !requestData.ExpiresAt.After(time.Now()) {
+		sendError(w, http.StatusBadRequest, "Срок действия ключа должен быть в будущем")
+		return
+	}
+
+	key, rawKey, err := h.service.Create(userID, name, requestData.Scopes, requestData.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidScope) {
+			sendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.LogError(err, "Ошибка создания API ключа", "userID", userID)
+		sendError(w, http.StatusInternalServerError, "Не удалось создать API ключ")
+		return
+	}
+
+	utils.LogInfo("API ключ создан", "userID", userID, "keyID", key.ID)
+
+	sendJSON(w, http.StatusCreated, struct {
+		models.APIKey
+		Key string `json:"key"`
+	}{*key, rawKey})
+}
+
+// Revoke отзывает ключ (DELETE /me/api-keys/{id})
+// @Summary Отозвать API ключ
+// @Tags api-keys
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Router /me/api-keys/{id} [delete]
+// @Security BearerAuth
+func (h *APIKeysHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	keyID, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/me/api-keys/"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Неверный ID ключа")
+		return
+	}
+
+	if err := h.service.Revoke(userID, keyID); err != nil {
+		if errors.Is(err, services.ErrAPIKeyNotFound) {
+			sendError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.LogError(err, "Ошибка отзыва API ключа", "userID", userID)
+		sendError(w, http.StatusInternalServerError, "Не удалось отозвать API ключ")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"server_new/middleware"
+	"server_new/services"
+	"server_new/utils"
+)
+
+// AuthHandler обрабатывает обновление токенов и выход
+type AuthHandler struct {
+	service *services.AuthService
+}
+
+func NewAuthHandler() *AuthHandler {
+	return &AuthHandler{
+		service: services.NewAuthService(),
+	}
+}
+
+// readRefreshToken извлекает refresh токен из тела запроса
+func readRefreshToken(r *http.Request) (string, bool) {
+	var requestData struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		return "", false
+	}
+	token := strings.TrimSpace(requestData.RefreshToken)
+	return token, token != ""
+}
+
+// refreshTokenFromRequest берёт refresh токен из тела запроса, а если его там нет —
+// из HttpOnly cookie (режим cookie). Во втором случае запрос должен нести CSRF токен.
+// При ошибке сам отправляет ответ и возвращает ok = false.
+func (h *AuthHandler) refreshTokenFromRequest(w http.ResponseWriter, r *http.Request) (token string, fromCookie, ok bool) {
+	if token, ok := readRefreshToken(r); ok {
+		return token, false, true
+	}
+
+	cookie, err := r.Cookie(middleware.RefreshTokenCookie)
+	if err != nil || cookie.Value == "" {
+		sendError(w, http.StatusBadRequest, "Укажи refreshToken")
+		return "", false, false
+	}
+	if !middleware.ValidCSRF(r) {
+		sendError(w, http.StatusForbidden, "Неверный или отсутствующий CSRF токен")
+		return "", false, false
+	}
+	return cookie.Value, true, true
+}
+
+// Refresh выдаёт новую пару токенов в обмен на refresh токен
+// @Summary Обновить access токен
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Success 200 {object} services.TokenPair
+// @Failure 401 {object} map[string]string
+// @Router /auth/refresh [post]
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+		return
+	}
+
+	refreshToken, fromCookie, ok := h.refreshTokenFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	pair, err := h.service.Refresh(refreshToken)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRefreshToken) || errors.Is(err, services.ErrRefreshTokenReused) {
+			if fromCookie {
+				middleware.ClearAuthCookies(w)
+			}
+			sendError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrAccountDisabled) {
+			sendError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		utils.LogError(err, "Ошибка обновления токена")
+		sendError(w, http.StatusInternalServerError, "Не удалось обновить токен")
+		return
+	}
+
+	if fromCookie {
+		csrfToken, err := middleware.SetAuthCookies(w, pair.AccessToken, pair.RefreshToken)
+		if err != nil {
+			utils.LogError(err, "Ошибка создания CSRF токена")
+			sendError(w, http.StatusInternalServerError, "Не удалось обновить токен")
+			return
+		}
+		sendJSON(w, http.StatusOK, map[string]interface{}{"expiresIn": pair.ExpiresIn, "csrfToken": csrfToken})
+		return
+	}
+
+	sendJSON(w, http.StatusOK, pair)
+}
+
+// Logout отзывает refresh токен текущего входа
+// @Summary Выйти из системы
+// @Tags auth
+// @Accept json
+// @Success 204
+// @Router /auth/logout [post]
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+		return
+	}
+
+	refreshToken, fromCookie, ok := h.refreshTokenFromRequest(w, r)
+	if !ok {
+		return
+	}
+	if fromCookie {
+		middleware.ClearAuthCookies(w)
+	}
+
+	if err := h.service.Logout(refreshToken); err != nil {
+		// Неизвестный токен — выход всё равно считается успешным
+		if !errors.Is(err, services.ErrInvalidRefreshToken) {
+			utils.LogError(err, "Ошибка выхода")
+			sendError(w, http.StatusInternalServerError, "Не удалось выйти")
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
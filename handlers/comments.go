@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxCommentLength — максимальная длина комментария
+const maxCommentLength = 5000
+
+// decodeCommentBody читает текст комментария из JSON {"body": "..."} и проверяет длину
+func decodeCommentBody(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var requestData struct {
+		Body string `json:"body"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		sendError(w, http.StatusBadRequest, "Неверный формат JSON")
+		return "", false
+	}
+
+	body := strings.TrimSpace(requestData.Body)
+	if body == "" || len([]rune(body)) > maxCommentLength {
+		sendError(w, http.StatusBadRequest, "Укажи текст комментария (до 5000 символов)")
+		return "", false
+	}
+
+	return body, true
+}
+
+// ListComments возвращает комментарии задачи в порядке написания
+// @Summary Комментарии задачи
+// @Tags comments
+// @Produce json
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество на странице" default(20)
+// @Success 200 {array} models.Comment
+// @Header 200 {string} X-Total-Count "Общее количество комментариев"
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/comments [get]
+// @Security BearerAuth
+func (h *TasksHandler) ListComments(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	taskID, err := getTaskID(r)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "ID должен быть числом")
+		return
+	}
+
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	comments, total, err := h.comments.List(userID, taskID, page, limit)
+	if err != nil {
+		h.sendTaskError(w, err, "Не удалось получить комментарии", taskID)
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	sendJSON(w, http.StatusOK, comments)
+}
+
+// CreateComment добавляет комментарий; упомянутые через @имя участники задачи получают уведомление
+// @Summary Добавить комментарий
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Success 201 {object} models.Comment
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/comments [post]
+// @Security BearerAuth
+func (h *TasksHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	taskID, err := getTaskID(r)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "ID должен быть числом")
+		return
+	}
+
+	body, ok := decodeCommentBody(w, r)
+	if !ok {
+		return
+	}
+
+	comment, err := h.comments.Create(userID, taskID, body)
+	if err != nil {
+		h.sendTaskError(w, err, "Не удалось добавить комментарий", taskID)
+		return
+	}
+
+	sendJSON(w, http.StatusCreated, comment)
+}
+
+// GetComment возвращает комментарий задачи
+// @Summary Комментарий
+// @Tags comments
+// @Produce json
+// @Success 200 {object} models.Comment
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/comments/{commentId} [get]
+// @Security BearerAuth
+func (h *TasksHandler) GetComment(w http.ResponseWriter, r *http.Request, commentID int) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	taskID, err := getTaskID(r)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "ID должен быть числом")
+		return
+	}
+
+	comment, err := h.comments.Get(userID, taskID, commentID)
+	if err != nil {
+		h.sendTaskError(w, err, "Не удалось получить комментарий", taskID)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, comment)
+}
+
+// UpdateComment меняет текст своего комментария; прежний текст сохраняется в истории правок
+// @Summary Изменить комментарий
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.Comment
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/comments/{commentId} [put]
+// @Security BearerAuth
+func (h *TasksHandler) UpdateComment(w http.ResponseWriter, r *http.Request, commentID int) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	taskID, err := getTaskID(r)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "ID должен быть числом")
+		return
+	}
+
+	body, ok := decodeCommentBody(w, r)
+	if !ok {
+		return
+	}
+
+	comment, err := h.comments.Update(userID, taskID, commentID, body)
+	if err != nil {
+		h.sendTaskError(w, err, "Не удалось изменить комментарий", taskID)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, comment)
+}
+
+// DeleteComment удаляет комментарий: свой — любой участник, чужой — владелец задачи или администратор проекта
+// @Summary Удалить комментарий
+// @Tags comments
+// @Success 204
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/comments/{commentId} [delete]
+// @Security BearerAuth
+func (h *TasksHandler) DeleteComment(w http.ResponseWriter, r *http.Request, commentID int) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	taskID, err := getTaskID(r)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "ID должен быть числом")
+		return
+	}
+
+	if err := h.comments.Delete(userID, taskID, commentID); err != nil {
+		h.sendTaskError(w, err, "Не удалось удалить комментарий", taskID)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CommentHistory возвращает прежние версии текста комментария
+// @Summary История правок комментария
+// @Tags comments
+// @Produce json
+// @Success 200 {array} models.CommentRevision
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/comments/{commentId}/history [get]
+// @Security BearerAuth
+func (h *TasksHandler) CommentHistory(w http.ResponseWriter, r *http.Request, commentID int) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	taskID, err := getTaskID(r)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "ID должен быть числом")
+		return
+	}
+
+	revisions, err := h.comments.History(userID, taskID, commentID)
+	if err != nil {
+		h.sendTaskError(w, err, "Не удалось получить историю правок", taskID)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, revisions)
+}
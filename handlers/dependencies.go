@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"server_new/utils"
+)
+
+// ListDependencies возвращает задачи, которые блокируют задачу, и задачи, которые блокирует она
+// @Summary Зависимости задачи
+// @Tags tasks
+// @Produce json
+// @Success 200 {object} models.TaskDependencies
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/dependencies [get]
+// @Security BearerAuth
+func (h *TasksHandler) ListDependencies(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	taskID, err := getTaskID(r)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "ID должен быть числом")
+		return
+	}
+
+	dependencies, err := h.service.ListDependencies(userID, taskID)
+	if err != nil {
+		h.sendTaskError(w, err, "Не удалось получить зависимости", taskID)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, dependencies)
+}
+
+// AddDependency отмечает, что задача заблокирована другой задачей
+// @Summary Добавить зависимость
+// @Description Задача не может быть завершена, пока блокирующая задача не выполнена
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Success 201 {object} models.TaskDependencies
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /tasks/{id}/dependencies [post]
+// @Security BearerAuth
+func (h *TasksHandler) AddDependency(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	taskID, err := getTaskID(r)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "ID должен быть числом")
+		return
+	}
+
+	var requestData struct {
+		BlockedBy int `json:"blocked_by"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		sendError(w, http.StatusBadRequest, "Неверный формат JSON")
+		return
+	}
+	if requestData.BlockedBy < 1 {
+		sendError(w, http.StatusBadRequest, "Укажи blocked_by — ID блокирующей задачи")
+		return
+	}
+
+	dependencies, err := h.service.AddDependency(userID, taskID, requestData.BlockedBy)
+	if err != nil {
+		h.sendTaskError(w, err, "Не удалось добавить зависимость", taskID)
+		return
+	}
+
+	utils.LogInfo("Зависимость добавлена", "taskID", taskID, "blockedBy", requestData.BlockedBy, "userID", userID)
+	sendJSON(w, http.StatusCreated, dependencies)
+}
+
+// RemoveDependency снимает блокировку задачи другой задачей
+// @Summary Удалить зависимость
+// @Tags tasks
+// @Success 204
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/dependencies/{blockerId} [delete]
+// @Security BearerAuth
+func (h *TasksHandler) RemoveDependency(w http.ResponseWriter, r *http.Request, blockerID int) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	taskID, err := getTaskID(r)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "ID должен быть числом")
+		return
+	}
+
+	if err := h.service.RemoveDependency(userID, taskID, blockerID); err != nil {
+		h.sendTaskError(w, err, "Не удалось удалить зависимость", taskID)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
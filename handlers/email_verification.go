@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"server_new/middleware"
+	"server_new/services"
+	"server_new/utils"
+)
+
+// EmailVerificationHandler обрабатывает подтверждение email
+type EmailVerificationHandler struct {
+	service *services.EmailVerificationService
+}
+
+func NewEmailVerificationHandler() *EmailVerificationHandler {
+	return &EmailVerificationHandler{
+		service: services.NewEmailVerificationService(),
+	}
+}
+
+// Verify подтверждает email по ссылке из письма
+// @Summary Подтвердить email
+// @Tags auth
+// @Param token query string true "Токен из письма"
+// @Produce json
+// @Success 200 {object} map[string]bool
+// @Failure 400 {object} map[string]string
+// @Router /auth/verify [get]
+func (h *EmailVerificationHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+		return
+	}
+
+	token := strings.TrimSpace(r.URL.Query().Get("token"))
+	if token == "" {
+		sendError(w, http.StatusBadRequest, "Укажи токен подтверждения")
+		return
+	}
+
+	if err := h.service.Verify(token); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidVerificationToken):
+			sendError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrEmailTaken):
+			sendError(w, http.StatusConflict, err.Error())
+		default:
+			utils.LogError(err, "Ошибка подтверждения email")
+			sendError(w, http.StatusInternalServerError, "Не удалось подтвердить email")
+		}
+		return
+	}
+
+	sendJSON(w, http.StatusOK, map[string]bool{"verified": true})
+}
+
+// Resend повторно отправляет письмо подтверждения
+// @Summary Отправить письмо подтверждения повторно
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Success 202 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /auth/verify/resend [post]
+func (h *EmailVerificationHandler) Resend(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+		return
+	}
+
+	var requestData struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		sendError(w, http.StatusBadRequest, "Неверный формат JSON")
+		return
+	}
+
+	email := strings.TrimSpace(strings.ToLower(requestData.Email))
+	if !utils.ValidateEmail(email) {
+		sendError(w, http.StatusBadRequest, "Укажи корректный email")
+		return
+	}
+
+	if err := h.service.Resend(email); err != nil {
+		var throttled *services.ResendThrottledError
+		if errors.As(err, &throttled) {
+			sendTooManyRequests(w, throttled.RetryAfter, throttled.Error())
+			return
+		}
+		utils.LogError(err, "Ошибка повторной отправки подтверждения")
+		sendError(w, http.StatusInternalServerError, "Не удалось отправить письмо")
+		return
+	}
+
+	sendJSON(w, http.StatusAccepted, map[string]string{
+		"message": "Если адрес зарегистрирован и не подтверждён, мы отправили письмо повторно",
+	})
+}
+
+// sendTooManyRequests отправляет 429 с заголовком Retry-After
+func sendTooManyRequests(w http.ResponseWriter, retryAfter time.Duration, message string) {
+	middleware.SetRetryAfter(w, retryAfter)
+	sendError(w, http.StatusTooManyRequests, message)
+}
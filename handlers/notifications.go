@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"server_new/services"
+	"server_new/utils"
+)
+
+type NotificationsHandler struct {
+	service *services.NotificationsService
+}
+
+func NewNotificationsHandler() *NotificationsHandler {
+	return &NotificationsHandler{
+		service: services.NewNotificationsService(),
+	}
+}
+
+// List возвращает уведомления текущего пользователя, новые первыми
+// @Summary Мои уведомления
+// @Tags notifications
+// @Produce json
+// @Param unread query bool false "Только непрочитанные"
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество на странице" default(20)
+// @Success 200 {array} models.Notification
+// @Header 200 {string} X-Total-Count "Общее количество уведомлений"
+// @Router /me/notifications [get]
+// @Security BearerAuth
+func (h *NotificationsHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	unreadOnly, _ := strconv.ParseBool(query.Get("unread"))
+
+	notifications, total, err := h.service.List(userID, unreadOnly, page, limit)
+	if err != nil {
+		utils.LogError(err, "Ошибка получения уведомлений", "userID", userID)
+		sendError(w, http.StatusInternalServerError, "Не удалось получить уведомления")
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	sendJSON(w, http.StatusOK, notifications)
+}
+
+// MarkRead отмечает уведомления прочитанными:
+// POST /me/notifications/read — все, POST /me/notifications/{id}/read — одно
+// @Summary Отметить уведомления прочитанными
+// @Tags notifications
+// @Produce json
+// @Success 200 {object} map[string]int
+// @Failure 404 {object} map[string]string
+// @Router /me/notifications/{id}/read [post]
+// @Security BearerAuth
+func (h *NotificationsHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/me/notifications/"), "/")
+	if !(len(parts) == 1 && parts[0] == "read") && !(len(parts) == 2 && parts[1] == "read") {
+		sendError(w, http.StatusNotFound, "Маршрут не найден")
+		return
+	}
+	if r.Method != http.MethodPost {
+		sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	if len(parts) == 1 {
+		count, err := h.service.MarkAllRead(userID)
+		if err != nil {
+			utils.LogError(err, "Ошибка обновления уведомлений", "userID", userID)
+			sendError(w, http.StatusInternalServerError, "Не удалось отметить уведомления")
+			return
+		}
+		sendJSON(w, http.StatusOK, map[string]int{"marked": count})
+		return
+	}
+
+	notificationID, err := strconv.Atoi(parts[0])
+	if err != nil || notificationID < 1 {
+		sendError(w, http.StatusBadRequest, "Неверный ID уведомления")
+		return
+	}
+
+	if err := h.service.MarkRead(userID, notificationID); err != nil {
+		if errors.Is(err, services.ErrNotificationNotFound) {
+			sendError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.LogError(err, "Ошибка обновления уведомления", "notificationID", notificationID)
+		sendError(w, http.StatusInternalServerError, "Не удалось отметить уведомление")
+		return
+	}
+
+	sendJSON(w, http.StatusOK, map[string]int{"marked": 1})
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"server_new/middleware"
+	"server_new/services"
+	"server_new/utils"
+)
+
+// PasswordResetHandler обрабатывает запросы на сброс пароля
+type PasswordResetHandler struct {
+	service *services.PasswordResetService
+	guard   *services.LoginGuardService
+}
+
+func NewPasswordResetHandler() *PasswordResetHandler {
+	return &PasswordResetHandler{
+		service: services.NewPasswordResetService(),
+		guard:   services.NewLoginGuardService(),
+	}
+}
+
+// Request отправляет письмо со ссылкой для сброса пароля
+// @Summary Запросить сброс пароля
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Success 202 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /auth/password-reset/request [post]
+func (h *PasswordResetHandler) Request(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+		return
+	}
+
+	var requestData struct {
+		Email string `json:"email"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		sendError(w, http.StatusBadRequest, "Неверный формат JSON")
+		return
+	}
+
+	email := strings.TrimSpace(strings.ToLower(requestData.Email))
+	if !utils.ValidateEmail(email) {
+		sendError(w, http.StatusBadRequest, "Укажи корректный email")
+		return
+	}
+
+	// Число писем на один адрес и запросов с одного IP ограничено: учитывается каждый запрос
+	clientIP := middleware.ClientIP(r)
+	guardKeys := []string{services.ResetAccountKey(email), services.ResetIPKey(clientIP)}
+	if h.locked(w, guardKeys...) {
+		return
+	}
+	h.registerAttempt(w, clientIP, guardKeys...)
+
+	if err := h.service.RequestReset(email); err != nil {
+		utils.LogError(err, "Ошибка запроса сброса пароля")
+		sendError(w, http.StatusInternalServerError, "Не удалось отправить письмо")
+		return
+	}
+
+	// Ответ одинаковый независимо от того, есть ли такой пользователь
+	sendJSON(w, http.StatusAccepted, map[string]string{
+		"message": "Если такой email зарегистрирован, мы отправили на него ссылку для сброса пароля",
+	})
+}
+
+// Confirm устанавливает новый пароль по токену из письма
+// @Summary Подтвердить сброс пароля
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]bool
+// @Failure 400 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /auth/password-reset/confirm [post]
+func (h *PasswordResetHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+		return
+	}
+
+	var requestData struct {
+		Token       string `json:"token"`
+		NewPassword string `json:"newPassword"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		sendError(w, http.StatusBadRequest, "Неверный формат JSON")
+		return
+	}
+
+	token := strings.TrimSpace(requestData.Token)
+	if token == "" {
+		sendError(w, http.StatusBadRequest, "Укажи токен сброса пароля")
+		return
+	}
+
+	newPassword := strings.TrimSpace(requestData.NewPassword)
+	if ok, msg := utils.ValidatePassword(newPassword); !ok {
+		sendError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	// Перебор токенов с одного IP блокируется так же, как перебор паролей
+	clientIP := middleware.ClientIP(r)
+	if h.locked(w, services.ResetIPKey(clientIP)) {
+		return
+	}
+
+	if err := h.service.ConfirmReset(token, newPassword); err != nil {
+		if errors.Is(err, services.ErrInvalidResetToken) {
+			h.registerAttempt(w, clientIP, services.ResetIPKey(clientIP))
+			sendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.LogError(err, "Ошибка подтверждения сброса пароля")
+		sendError(w, http.StatusInternalServerError, "Не удалось обновить пароль")
+		return
+	}
+
+	sendJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// locked отвечает 429 с Retry-After, если один из ключей заблокирован
+func (h *PasswordResetHandler) locked(w http.ResponseWriter, keys ...string) bool {
+	wait, err := h.guard.LockedFor(keys...)
+	if err != nil {
+		utils.LogError(err, "Ошибка проверки блокировки сброса пароля")
+		sendError(w, http.StatusInternalServerError, "Не удалось выполнить запрос")
+		return true
+	}
+	if wait > 0 {
+		middleware.SetRetryAfter(w, wait)
+		sendError(w, http.StatusTooManyRequests, "Слишком много запросов сброса пароля. Попробуйте позже")
+		return true
+	}
+	return false
+}
+
+// registerAttempt учитывает попытку по ключам; если ключ заблокирован, сразу выставляет Retry-After
+func (h *PasswordResetHandler) registerAttempt(w http.ResponseWriter, ip string, keys ...string) {
+	lock, err := h.guard.RegisterFailure(ip, keys...)
+	if err != nil {
+		utils.LogError(err, "Ошибка учёта попытки сброса пароля")
+		return
+	}
+	if lock > 0 {
+		middleware.SetRetryAfter(w, lock)
+	}
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"server_new/services"
+	"server_new/utils"
+)
+
+// ListMembers возвращает участников проекта и ещё не принятые приглашения
+// @Summary Участники проекта
+// @Tags projects
+// @Produce json
+// @Success 200 {array} models.ProjectMember
+// @Failure 404 {object} map[string]string
+// @Router /projects/{id}/members [get]
+// @Security BearerAuth
+func (h *ProjectsHandler) ListMembers(w http.ResponseWriter, r *http.Request, projectID int) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	members, err := h.members.List(userID, projectID)
+	if err != nil {
+		h.sendProjectError(w, err, "Не удалось получить участников", projectID)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, members)
+}
+
+// InviteMember приглашает зарегистрированного пользователя в проект
+// @Summary Пригласить в проект
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Success 201 {object} models.ProjectMember
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /projects/{id}/members [post]
+// @Security BearerAuth
+func (h *ProjectsHandler) InviteMember(w http.ResponseWriter, r *http.Request, projectID int) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	var requestData struct {
+		Login string `json:"login"` // email или имя пользователя
+		Role  string `json:"role"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		sendError(w, http.StatusBadRequest, "Неверный формат JSON")
+		return
+	}
+
+	if strings.TrimSpace(requestData.Login) == "" {
+		sendError(w, http.StatusBadRequest, "Укажи email или имя пользователя")
+		return
+	}
+
+	member, err := h.members.Invite(userID, projectID, requestData.Login, requestData.Role)
+	if err != nil {
+		h.sendProjectError(w, err, "Не удалось пригласить пользователя", projectID)
+		return
+	}
+
+	utils.LogInfo("Приглашение в проект", "projectID", projectID, "userID", userID, "inviteeID", member.UserID, "role", member.Role)
+	sendJSON(w, http.StatusCreated, member)
+}
+
+// UpdateMember меняет роль участника
+// @Summary Изменить роль участника
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.ProjectMember
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /projects/{id}/members/{userID} [put]
+// @Security BearerAuth
+func (h *ProjectsHandler) UpdateMember(w http.ResponseWriter, r *http.Request, projectID, memberID int) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	var requestData struct {
+		Role string `json:"role"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		sendError(w, http.StatusBadRequest, "Неверный формат JSON")
+		return
+	}
+
+	member, err := h.members.UpdateRole(userID, projectID, memberID, requestData.Role)
+	if err != nil {
+		h.sendProjectError(w, err, "Не удалось изменить роль", projectID)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, member)
+}
+
+// RemoveMember исключает участника или отзывает приглашение; участник может так выйти из проекта
+// @Summary Исключить участника
+// @Tags projects
+// @Success 204
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /projects/{id}/members/{userID} [delete]
+// @Security BearerAuth
+func (h *ProjectsHandler) RemoveMember(w http.ResponseWriter, r *http.Request, projectID, memberID int) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	if err := h.members.Remove(userID, projectID, memberID); err != nil {
+		h.sendProjectError(w, err, "Не удалось исключить участника", projectID)
+		return
+	}
+
+	utils.LogInfo("Участник исключён из проекта", "projectID", projectID, "userID", userID, "memberID", memberID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// InvitationsHandler обрабатывает приглашения текущего пользователя (/me/invitations)
+type InvitationsHandler struct {
+	service *services.ProjectMembersService
+}
+
+func NewInvitationsHandler() *InvitationsHandler {
+	return &InvitationsHandler{
+		service: services.NewProjectMembersService(),
+	}
+}
+
+// List возвращает непринятые приглашения в проекты
+// @Summary Мои приглашения
+// @Tags projects
+// @Produce json
+// @Success 200 {array} models.ProjectInvitation
+// @Router /me/invitations [get]
+// @Security BearerAuth
+func (h *InvitationsHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	invitations, err := h.service.ListInvitations(userID)
+	if err != nil {
+		utils.LogError(err, "Ошибка получения приглашений", "userID", userID)
+		sendError(w, http.StatusInternalServerError, "Не удалось получить приглашения")
+		return
+	}
+
+	sendJSON(w, http.StatusOK, invitations)
+}
+
+// Respond принимает или отклоняет приглашение:
+// POST /me/invitations/{projectID}/accept, POST /me/invitations/{projectID}/decline
+// @Summary Принять или отклонить приглашение
+// @Tags projects
+// @Success 200 {object} map[string]bool
+// @Failure 404 {object} map[string]string
+// @Router /me/invitations/{projectID}/{action} [post]
+// @Security BearerAuth
+func (h *InvitationsHandler) Respond(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/me/invitations/"), "/")
+	if len(parts) != 2 || (parts[1] != "accept" && parts[1] != "decline") {
+		sendError(w, http.StatusNotFound, "Маршрут не найден")
+		return
+	}
+	if r.Method != http.MethodPost {
+		sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+		return
+	}
+
+	projectID, err := strconv.Atoi(parts[0])
+	if err != nil || projectID < 1 {
+		sendError(w, http.StatusBadRequest, "Неверный ID проекта")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	accept := parts[1] == "accept"
+	if accept {
+		err = h.service.AcceptInvitation(userID, projectID)
+	} else {
+		err = h.service.DeclineInvitation(userID, projectID)
+	}
+	if err != nil {
+		if errors.Is(err, services.ErrInvitationNotFound) {
+			sendError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.LogError(err, "Ошибка ответа на приглашение", "userID", userID, "projectID", projectID)
+		sendError(w, http.StatusInternalServerError, "Не удалось ответить на приглашение")
+		return
+	}
+
+	utils.LogInfo("Ответ на приглашение в проект", "userID", userID, "projectID", projectID, "accepted", accept)
+	sendJSON(w, http.StatusOK, map[string]bool{"success": true, "accepted": accept})
+}
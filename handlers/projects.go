@@ -0,0 +1,345 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"server_new/services"
+	"server_new/utils"
+)
+
+// maxProjectNameLength — максимальная длина названия проекта
+const maxProjectNameLength = 100
+
+// ProjectsHandler обрабатывает запросы к /projects
+type ProjectsHandler struct {
+	service *services.ProjectsService
+	members *services.ProjectMembersService
+	tasks   *services.TasksService
+}
+
+func NewProjectsHandler() *ProjectsHandler {
+	return &ProjectsHandler{
+		service: services.NewProjectsService(),
+		members: services.NewProjectMembersService(),
+		tasks:   services.NewTasksService(),
+	}
+}
+
+// Projects обрабатывает /projects: GET — список проектов, POST — создать проект
+func (h *ProjectsHandler) Projects(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.List(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+	}
+}
+
+// Project обрабатывает /projects/{id} (GET, PUT, DELETE), GET /projects/{id}/tasks
+// и участников: /projects/{id}/members (GET, POST), /projects/{id}/members/{userID} (PUT, DELETE)
+func (h *ProjectsHandler) Project(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/projects/"), "/")
+
+	projectID, err := strconv.Atoi(parts[0])
+	if err != nil || projectID < 1 {
+		sendError(w, http.StatusBadRequest, "Неверный ID проекта")
+		return
+	}
+
+	switch {
+	case len(parts) == 1:
+		switch r.Method {
+		case http.MethodGet:
+			h.Get(w, r, projectID)
+		case http.MethodPut:
+			h.Update(w, r, projectID)
+		case http.MethodDelete:
+			h.Delete(w, r, projectID)
+		default:
+			sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+		}
+	case len(parts) == 2 && parts[1] == "tasks":
+		if r.Method != http.MethodGet {
+			sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+			return
+		}
+		h.Tasks(w, r, projectID)
+	case len(parts) == 2 && parts[1] == "members":
+		switch r.Method {
+		case http.MethodGet:
+			h.ListMembers(w, r, projectID)
+		case http.MethodPost:
+			h.InviteMember(w, r, projectID)
+		default:
+			sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+		}
+	case len(parts) == 3 && parts[1] == "members":
+		memberID, err := strconv.Atoi(parts[2])
+		if err != nil || memberID < 1 {
+			sendError(w, http.StatusBadRequest, "Неверный ID участника")
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			h.UpdateMember(w, r, projectID, memberID)
+		case http.MethodDelete:
+			h.RemoveMember(w, r, projectID, memberID)
+		default:
+			sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+		}
+	default:
+		sendError(w, http.StatusNotFound, "Маршрут не найден")
+	}
+}
+
+// List возвращает проекты текущего пользователя
+// @Summary Список проектов
+// @Tags projects
+// @Produce json
+// @Param archived query bool false "Включить архивные проекты"
+// @Success 200 {array} models.Project
+// @Router /projects [get]
+// @Security BearerAuth
+func (h *ProjectsHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	includeArchived, _ := strconv.ParseBool(r.URL.Query().Get("archived"))
+
+	projects, err := h.service.ListByUserID(userID, includeArchived)
+	if err != nil {
+		utils.LogError(err, "Ошибка получения проектов", "userID", userID)
+		sendError(w, http.StatusInternalServerError, "Не удалось получить проекты")
+		return
+	}
+
+	sendJSON(w, http.StatusOK, projects)
+}
+
+// Create создаёт проект
+// @Summary Создать проект
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Success 201 {object} models.Project
+// @Failure 400 {object} map[string]string
+// @Router /projects [post]
+// @Security BearerAuth
+func (h *ProjectsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	var requestData struct {
+		Name  string `json:"name"`
+		Color string `json:"color"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		sendError(w, http.StatusBadRequest, "Неверный формат JSON")
+		return
+	}
+
+	name := strings.TrimSpace(requestData.Name)
+	if name == "" || len([]rune(name)) > maxProjectNameLength {
+		sendError(w, http.StatusBadRequest, "Укажи название проекта (до 100 символов)")
+		return
+	}
+	if requestData.Color != "" && !utils.ValidateColor(requestData.Color) {
+		sendError(w, http.StatusBadRequest, "Цвет должен быть в формате #rrggbb")
+		return
+	}
+
+	project, err := h.service.Create(userID, name, strings.ToLower(requestData.Color))
+	if err != nil {
+		utils.LogError(err, "Ошибка создания проекта", "userID", userID)
+		sendError(w, http.StatusInternalServerError, "Не удалось создать проект")
+		return
+	}
+
+	utils.LogInfo("Проект создан", "projectID", project.ID, "userID", userID)
+	sendJSON(w, http.StatusCreated, project)
+}
+
+// Get возвращает проект по ID
+// @Summary Получить проект
+// @Tags projects
+// @Produce json
+// @Success 200 {object} models.Project
+// @Failure 404 {object} map[string]string
+// @Router /projects/{id} [get]
+// @Security BearerAuth
+func (h *ProjectsHandler) Get(w http.ResponseWriter, r *http.Request, projectID int) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	project, err := h.service.GetByID(userID, projectID)
+	if err != nil {
+		h.sendProjectError(w, err, "Не удалось получить проект", projectID)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, project)
+}
+
+// Update изменяет проект: название, цвет, архивность, позицию
+// @Summary Изменить проект
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.Project
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /projects/{id} [put]
+// @Security BearerAuth
+func (h *ProjectsHandler) Update(w http.ResponseWriter, r *http.Request, projectID int) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	var requestData struct {
+		Name     *string `json:"name"`
+		Color    *string `json:"color"`
+		Archived *bool   `json:"archived"`
+		Position *int    `json:"position"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		sendError(w, http.StatusBadRequest, "Неверный формат JSON")
+		return
+	}
+
+	update := services.ProjectUpdate{
+		Archived: requestData.Archived,
+		Position: requestData.Position,
+	}
+	if requestData.Name != nil {
+		name := strings.TrimSpace(*requestData.Name)
+		if name == "" || len([]rune(name)) > maxProjectNameLength {
+			sendError(w, http.StatusBadRequest, "Укажи название проекта (до 100 символов)")
+			return
+		}
+		update.Name = &name
+	}
+	if requestData.Color != nil {
+		if *requestData.Color != "" && !utils.ValidateColor(*requestData.Color) {
+			sendError(w, http.StatusBadRequest, "Цвет должен быть в формате #rrggbb")
+			return
+		}
+		color := strings.ToLower(*requestData.Color)
+		update.Color = &color
+	}
+
+	project, err := h.service.Update(userID, projectID, update)
+	if err != nil {
+		h.sendProjectError(w, err, "Не удалось изменить проект", projectID)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, project)
+}
+
+// Delete удаляет проект. По умолчанию задачи переносятся во «Входящие»,
+// с ?tasks=delete — удаляются вместе с проектом.
+// @Summary Удалить проект
+// @Tags projects
+// @Param tasks query string false "Что сделать с задачами проекта" Enums(move, delete) default(move)
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /projects/{id} [delete]
+// @Security BearerAuth
+func (h *ProjectsHandler) Delete(w http.ResponseWriter, r *http.Request, projectID int) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	var deleteTasks bool
+	switch r.URL.Query().Get("tasks") {
+	case "", "move":
+	case "delete":
+		deleteTasks = true
+	default:
+		sendError(w, http.StatusBadRequest, "Параметр tasks должен быть: move или delete")
+		return
+	}
+
+	if err := h.service.Delete(userID, projectID, deleteTasks); err != nil {
+		h.sendProjectError(w, err, "Не удалось удалить проект", projectID)
+		return
+	}
+
+	utils.LogInfo("Проект удалён", "projectID", projectID, "userID", userID, "deleteTasks", deleteTasks)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Tasks возвращает задачи проекта с той же пагинацией и фильтрами, что и GET /tasks
+// @Summary Задачи проекта
+// @Tags projects
+// @Produce json
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество на странице" default(10)
+// @Success 200 {array} models.Task
+// @Header 200 {string} X-Total-Count "Общее количество задач"
+// @Failure 404 {object} map[string]string
+// @Router /projects/{id}/tasks [get]
+// @Security BearerAuth
+func (h *ProjectsHandler) Tasks(w http.ResponseWriter, r *http.Request, projectID int) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	page, limit, filter, err := parseTaskListQuery(r)
+	if err != nil {
+		sendTaskListQueryError(w, err)
+		return
+	}
+	filter.ProjectID = projectID
+
+	if _, err := h.service.GetByID(userID, projectID); err != nil {
+		h.sendProjectError(w, err, "Не удалось получить проект", projectID)
+		return
+	}
+
+	sendTaskList(w, r, h.tasks, userID, page, limit, filter, "Не удалось получить задачи")
+}
+
+// sendProjectError переводит ошибки сервиса проектов в HTTP ответ
+func (h *ProjectsHandler) sendProjectError(w http.ResponseWriter, err error, message string, projectID int) {
+	switch {
+	case errors.Is(err, services.ErrProjectNotFound),
+		errors.Is(err, services.ErrMemberNotFound),
+		errors.Is(err, services.ErrUserNotFound):
+		sendError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrProjectForbidden):
+		sendError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrMemberExists):
+		sendError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrInboxProject),
+		errors.Is(err, services.ErrInvalidProjectRole):
+		sendError(w, http.StatusBadRequest, err.Error())
+	default:
+		utils.LogError(err, message, "projectID", projectID)
+		sendError(w, http.StatusInternalServerError, message)
+	}
+}
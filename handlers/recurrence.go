@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"server_new/models"
+)
+
+// defaultRecurrencePreview — сколько повторений показывать, если count не указан
+const defaultRecurrencePreview = 5
+
+// PreviewRecurrence возвращает ближайшие повторения задачи после её срока
+// @Summary Ближайшие повторения
+// @Tags tasks
+// @Produce json
+// @Param count query int false "Сколько повторений показать (до 50)" default(5)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/recurrence [get]
+// @Security BearerAuth
+func (h *TasksHandler) PreviewRecurrence(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	taskID, err := getTaskID(r)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "ID должен быть числом")
+		return
+	}
+
+	count := defaultRecurrencePreview
+	if value := r.URL.Query().Get("count"); value != "" {
+		count, err = strconv.Atoi(value)
+		if err != nil || count < 1 {
+			sendError(w, http.StatusBadRequest, "count должен быть положительным числом")
+			return
+		}
+	}
+
+	recurrence, occurrences, err := h.service.PreviewRecurrence(userID, taskID, count)
+	if err != nil {
+		h.sendTaskError(w, err, "Не удалось рассчитать повторения", taskID)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, struct {
+		Recurrence *models.TaskRecurrence `json:"recurrence"`
+		Next       []time.Time            `json:"next"`
+	}{recurrence, occurrences})
+}
+
+// StopRecurrence останавливает серию повторений: уже созданные задачи остаются, новые не создаются
+// @Summary Остановить серию повторений
+// @Tags tasks
+// @Produce json
+// @Success 200 {object} models.Task
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/recurrence [delete]
+// @Security BearerAuth
+func (h *TasksHandler) StopRecurrence(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	taskID, err := getTaskID(r)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "ID должен быть числом")
+		return
+	}
+
+	task, err := h.service.StopRecurrence(userID, taskID)
+	if err != nil {
+		h.sendTaskError(w, err, "Не удалось остановить серию", taskID)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, task)
+}
@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"server_new/models"
+	"server_new/services"
+	"server_new/utils"
+)
+
+// SavedViewsHandler обрабатывает запросы к /me/views и /views/{id}/tasks
+type SavedViewsHandler struct {
+	service *services.SavedViewsService
+	tasks   *services.TasksService
+}
+
+func NewSavedViewsHandler() *SavedViewsHandler {
+	return &SavedViewsHandler{
+		service: services.NewSavedViewsService(),
+		tasks:   services.NewTasksService(),
+	}
+}
+
+// savedViewRequest — поля вида в теле запроса (nil — поле не передано)
+type savedViewRequest struct {
+	Name      *string           `json:"name"`
+	Filters   map[string]string `json:"filters"`
+	Sort      *string           `json:"sort"`
+	PageSize  *int              `json:"page_size"`
+	IsDefault *bool             `json:"is_default"`
+}
+
+// input проверяет название и размер страницы и переводит запрос в поля сервиса;
+// фильтры и сортировку проверяет сервис
+func (req savedViewRequest) input() (services.SavedViewInput, error) {
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" || utf8.RuneCountInString(name) > 100 {
+			return services.SavedViewInput{}, errors.New("Название вида — от 1 до 100 символов")
+		}
+		req.Name = &name
+	}
+	if req.PageSize != nil && (*req.PageSize < 1 || *req.PageSize > 100) {
+		return services.SavedViewInput{}, errors.New("page_size должен быть от 1 до 100")
+	}
+	return services.SavedViewInput{
+		Name:      req.Name,
+		Filters:   req.Filters,
+		Sort:      req.Sort,
+		PageSize:  req.PageSize,
+		IsDefault: req.IsDefault,
+	}, nil
+}
+
+// Views обрабатывает /me/views: GET — список видов, POST — сохранить вид
+func (h *SavedViewsHandler) Views(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.List(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+	}
+}
+
+// View обрабатывает /me/views/{id}: GET — получить, PUT — изменить, DELETE — удалить
+func (h *SavedViewsHandler) View(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.Get(w, r)
+	case http.MethodPut:
+		h.Update(w, r)
+	case http.MethodDelete:
+		h.Delete(w, r)
+	default:
+		sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+	}
+}
+
+// List возвращает виды текущего пользователя
+// @Summary Сохранённые виды
+// @Tags views
+// @Produce json
+// @Success 200 {array} models.SavedView
+// @Router /me/views [get]
+// @Security BearerAuth
+func (h *SavedViewsHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	views, err := h.service.List(userID)
+	if err != nil {
+		utils.LogError(err, "Ошибка получения видов", "userID", userID)
+		sendError(w, http.StatusInternalServerError, "Не удалось получить виды")
+		return
+	}
+
+	sendJSON(w, http.StatusOK, views)
+}
+
+// Create сохраняет вид: фильтры в формате параметров GET /tasks, сортировку и размер страницы
+// @Summary Сохранить вид
+// @Tags views
+// @Accept json
+// @Produce json
+// @Success 201 {object} models.SavedView
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /me/views [post]
+// @Security BearerAuth
+func (h *SavedViewsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	var requestData savedViewRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		sendError(w, http.StatusBadRequest, "Неверный формат JSON")
+		return
+	}
+	if requestData.Name == nil {
+		sendError(w, http.StatusBadRequest, "Укажи название вида")
+		return
+	}
+
+	input, err := requestData.input()
+	if err != nil {
+		sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	view, err := h.service.Create(userID, input)
+	if err != nil {
+		h.sendViewError(w, err, "Не удалось сохранить вид", userID)
+		return
+	}
+
+	sendJSON(w, http.StatusCreated, view)
+}
+
+// Get возвращает вид по ID
+// @Summary Получить вид
+// @Tags views
+// @Produce json
+// @Success 200 {object} models.SavedView
+// @Failure 404 {object} map[string]string
+// @Router /me/views/{id} [get]
+// @Security BearerAuth
+func (h *SavedViewsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	viewID, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/me/views/"))
+	if err != nil || viewID < 1 {
+		sendError(w, http.StatusBadRequest, "Неверный ID вида")
+		return
+	}
+
+	view, err := h.service.Get(userID, viewID)
+	if err != nil {
+		h.sendViewError(w, err, "Не удалось получить вид", userID)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, view)
+}
+
+// Update меняет переданные поля вида; filters заменяются целиком
+// @Summary Изменить вид
+// @Tags views
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.SavedView
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /me/views/{id} [put]
+// @Security BearerAuth
+func (h *SavedViewsHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	viewID, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/me/views/"))
+	if err != nil || viewID < 1 {
+		sendError(w, http.StatusBadRequest, "Неверный ID вида")
+		return
+	}
+
+	var requestData savedViewRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		sendError(w, http.StatusBadRequest, "Неверный формат JSON")
+		return
+	}
+
+	input, err := requestData.input()
+	if err != nil {
+		sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	view, err := h.service.Update(userID, viewID, input)
+	if err != nil {
+		h.sendViewError(w, err, "Не удалось изменить вид", userID)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, view)
+}
+
+// Delete удаляет вид
+// @Summary Удалить вид
+// @Tags views
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Router /me/views/{id} [delete]
+// @Security BearerAuth
+func (h *SavedViewsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	viewID, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/me/views/"))
+	if err != nil || viewID < 1 {
+		sendError(w, http.StatusBadRequest, "Неверный ID вида")
+		return
+	}
+
+	if err := h.service.Delete(userID, viewID); err != nil {
+		h.sendViewError(w, err, "Не удалось удалить вид", userID)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Tasks возвращает задачи по сохранённому виду: GET /views/{id}/tasks?page=
+// @Summary Задачи по виду
+// @Tags views
+// @Produce json
+// @Param page query int false "Номер страницы" default(1)
+// @Success 200 {array} models.Task
+// @Header 200 {string} X-Total-Count "Общее количество задач"
+// @Failure 404 {object} map[string]string
+// @Router /views/{id}/tasks [get]
+// @Security BearerAuth
+func (h *SavedViewsHandler) Tasks(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/views/"), "/")
+	if len(parts) != 2 || parts[1] != "tasks" {
+		sendError(w, http.StatusNotFound, "Маршрут не найден")
+		return
+	}
+	if r.Method != http.MethodGet {
+		sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	viewID, err := strconv.Atoi(parts[0])
+	if err != nil || viewID < 1 {
+		sendError(w, http.StatusBadRequest, "Неверный ID вида")
+		return
+	}
+
+	view, err := h.service.Get(userID, viewID)
+	if err != nil {
+		h.sendViewError(w, err, "Не удалось получить вид", userID)
+		return
+	}
+
+	sendViewTasks(w, r, h.tasks, userID, view)
+}
+
+// sendViewTasks отвечает страницей задач по виду; из запроса берутся только page, cursor и count
+func sendViewTasks(w http.ResponseWriter, r *http.Request, tasks *services.TasksService, userID int, view *models.SavedView) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	filter, err := services.SavedViewTaskFilter(view)
+	if err != nil {
+		sendTaskListQueryError(w, err)
+		return
+	}
+
+	w.Header().Set("X-View-ID", strconv.Itoa(view.ID))
+	sendTaskList(w, r, tasks, userID, page, view.PageSize, filter, "Не удалось получить задачи")
+}
+
+// sendViewError переводит ошибки сервиса видов в HTTP ответ
+func (h *SavedViewsHandler) sendViewError(w http.ResponseWriter, err error, message string, userID int) {
+	switch {
+	case errors.Is(err, services.ErrSavedViewNotFound):
+		sendError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrSavedViewExists):
+		sendError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrInvalidSavedView):
+		sendTaskListQueryError(w, err)
+	default:
+		utils.LogError(err, message, "userID", userID)
+		sendError(w, http.StatusInternalServerError, message)
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"server_new/services"
+	"server_new/utils"
+)
+
+// SessionsHandler обрабатывает запросы к /me/sessions
+type SessionsHandler struct {
+	service *services.SessionsService
+}
+
+func NewSessionsHandler() *SessionsHandler {
+	return &SessionsHandler{
+		service: services.NewSessionsService(),
+	}
+}
+
+// List возвращает активные сессии текущего пользователя
+// @Summary Список сессий
+// @Tags sessions
+// @Produce json
+// @Success 200 {array} models.Session
+// @Router /me/sessions [get]
+// @Security BearerAuth
+func (h *SessionsHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	sessions, err := h.service.ListByUserID(userID)
+	if err != nil {
+		utils.LogError(err, "Ошибка получения сессий", "userID", userID)
+		sendError(w, http.StatusInternalServerError, "Не удалось получить сессии")
+		return
+	}
+
+	// Отмечаем сессию, из которой пришёл запрос
+	currentID := r.Header.Get("X-Session-ID")
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+
+	sendJSON(w, http.StatusOK, sessions)
+}
+
+// Revoke завершает сессию по ID (DELETE /me/sessions/{id})
+// @Summary Завершить сессию
+// @Tags sessions
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Router /me/sessions/{id} [delete]
+// @Security BearerAuth
+func (h *SessionsHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	sessionID := strings.TrimPrefix(r.URL.Path, "/me/sessions/")
+	if sessionID == "" || strings.Contains(sessionID, "/") {
+		sendError(w, http.StatusBadRequest, "Неверный формат пути")
+		return
+	}
+
+	if err := h.service.Revoke(userID, sessionID); err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			sendError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.LogError(err, "Ошибка завершения сессии", "userID", userID)
+		sendError(w, http.StatusInternalServerError, "Не удалось завершить сессию")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeAll завершает все сессии пользователя — «выйти везде» (DELETE /me/sessions).
+// С параметром ?keepCurrent=true текущая сессия остаётся активной.
+// @Summary Выйти на всех устройствах
+// @Tags sessions
+// @Param keepCurrent query bool false "Не завершать текущую сессию"
+// @Success 204
+// @Router /me/sessions [delete]
+// @Security BearerAuth
+func (h *SessionsHandler) RevokeAll(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	exceptID := ""
+	if r.URL.Query().Get("keepCurrent") == "true" {
+		exceptID = r.Header.Get("X-Session-ID")
+	}
+
+	if err := h.service.RevokeAll(userID, exceptID); err != nil {
+		utils.LogError(err, "Ошибка завершения сессий", "userID", userID)
+		sendError(w, http.StatusInternalServerError, "Не удалось завершить сессии")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
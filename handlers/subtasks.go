@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// maxChecklistTitleLength — максимальная длина пункта чек-листа
+const maxChecklistTitleLength = 200
+
+// validateChecklistTitle обрезает пробелы и проверяет длину пункта чек-листа
+func validateChecklistTitle(title string) (string, bool) {
+	title = strings.TrimSpace(title)
+	return title, title != "" && len([]rune(title)) <= maxChecklistTitleLength
+}
+
+// Subtasks возвращает прямые подзадачи задачи с той же пагинацией и фильтрами, что и GET /tasks
+// @Summary Подзадачи задачи
+// @Tags tasks
+// @Produce json
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество на странице" default(10)
+// @Success 200 {array} models.Task
+// @Header 200 {string} X-Total-Count "Общее количество подзадач"
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/subtasks [get]
+// @Security BearerAuth
+func (h *TasksHandler) Subtasks(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	taskID, err := getTaskID(r)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "ID должен быть числом")
+		return
+	}
+
+	page, limit, filter, err := parseTaskListQuery(r)
+	if err != nil {
+		sendTaskListQueryError(w, err)
+		return
+	}
+	filter.ParentID = taskID
+
+	if _, err := h.service.GetTaskByID(taskID, userID); err != nil {
+		h.sendTaskError(w, err, "Не удалось получить задачу", taskID)
+		return
+	}
+
+	sendTaskList(w, r, h.service, userID, page, limit, filter, "Не удалось получить подзадачи")
+}
+
+// ListChecklist возвращает чек-лист задачи
+// @Summary Чек-лист задачи
+// @Tags tasks
+// @Produce json
+// @Success 200 {array} models.ChecklistItem
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/checklist [get]
+// @Security BearerAuth
+func (h *TasksHandler) ListChecklist(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	taskID, err := getTaskID(r)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "ID должен быть числом")
+		return
+	}
+
+	items, err := h.checklist.List(userID, taskID)
+	if err != nil {
+		h.sendTaskError(w, err, "Не удалось получить чек-лист", taskID)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, items)
+}
+
+// CreateChecklistItem добавляет пункт в конец чек-листа
+// @Summary Добавить пункт чек-листа
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Success 201 {object} models.ChecklistItem
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/checklist [post]
+// @Security BearerAuth
+func (h *TasksHandler) CreateChecklistItem(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	taskID, err := getTaskID(r)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "ID должен быть числом")
+		return
+	}
+
+	var requestData struct {
+		Title string `json:"title"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		sendError(w, http.StatusBadRequest, "Неверный формат JSON")
+		return
+	}
+
+	title, ok := validateChecklistTitle(requestData.Title)
+	if !ok {
+		sendError(w, http.StatusBadRequest, "Укажи текст пункта (до 200 символов)")
+		return
+	}
+
+	item, err := h.checklist.Create(userID, taskID, title)
+	if err != nil {
+		h.sendTaskError(w, err, "Не удалось добавить пункт чек-листа", taskID)
+		return
+	}
+
+	sendJSON(w, http.StatusCreated, item)
+}
+
+// UpdateChecklistItem меняет текст пункта или отмечает его выполненным
+// @Summary Изменить пункт чек-листа
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.ChecklistItem
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/checklist/{itemId} [put]
+// @Security BearerAuth
+func (h *TasksHandler) UpdateChecklistItem(w http.ResponseWriter, r *http.Request, itemID int) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	taskID, err := getTaskID(r)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "ID должен быть числом")
+		return
+	}
+
+	var requestData struct {
+		Title *string `json:"title"`
+		Done  *bool   `json:"done"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		sendError(w, http.StatusBadRequest, "Неверный формат JSON")
+		return
+	}
+
+	if requestData.Title != nil {
+		title, ok := validateChecklistTitle(*requestData.Title)
+		if !ok {
+			sendError(w, http.StatusBadRequest, "Укажи текст пункта (до 200 символов)")
+			return
+		}
+		requestData.Title = &title
+	}
+
+	item, err := h.checklist.Update(userID, taskID, itemID, requestData.Title, requestData.Done)
+	if err != nil {
+		h.sendTaskError(w, err, "Не удалось изменить пункт чек-листа", taskID)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, item)
+}
+
+// DeleteChecklistItem удаляет пункт чек-листа
+// @Summary Удалить пункт чек-листа
+// @Tags tasks
+// @Success 204
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/checklist/{itemId} [delete]
+// @Security BearerAuth
+func (h *TasksHandler) DeleteChecklistItem(w http.ResponseWriter, r *http.Request, itemID int) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	taskID, err := getTaskID(r)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "ID должен быть числом")
+		return
+	}
+
+	if err := h.checklist.Delete(userID, taskID, itemID); err != nil {
+		h.sendTaskError(w, err, "Не удалось удалить пункт чек-листа", taskID)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReorderChecklist задаёт новый порядок пунктов чек-листа
+// @Summary Изменить порядок чек-листа
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.ChecklistItem
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/checklist/order [put]
+// @Security BearerAuth
+func (h *TasksHandler) ReorderChecklist(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	taskID, err := getTaskID(r)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "ID должен быть числом")
+		return
+	}
+
+	var requestData struct {
+		IDs []int `json:"ids"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		sendError(w, http.StatusBadRequest, "Неверный формат JSON")
+		return
+	}
+
+	items, err := h.checklist.Reorder(userID, taskID, requestData.IDs)
+	if err != nil {
+		h.sendTaskError(w, err, "Не удалось изменить порядок чек-листа", taskID)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, items)
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"server_new/services"
+	"server_new/utils"
+)
+
+// TagsHandler обрабатывает запросы к /tags
+type TagsHandler struct {
+	service *services.TagsService
+}
+
+func NewTagsHandler() *TagsHandler {
+	return &TagsHandler{
+		service: services.NewTagsService(),
+	}
+}
+
+// Tags обрабатывает /tags: GET — список меток, POST — создать метку
+func (h *TagsHandler) Tags(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.List(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+	}
+}
+
+// Tag обрабатывает /tags/{id}: GET — получить, PUT — изменить, DELETE — удалить
+func (h *TagsHandler) Tag(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.Get(w, r)
+	case http.MethodPut:
+		h.Update(w, r)
+	case http.MethodDelete:
+		h.Delete(w, r)
+	default:
+		sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+	}
+}
+
+// List возвращает метки текущего пользователя
+// @Summary Список меток
+// @Tags tags
+// @Produce json
+// @Success 200 {array} models.Tag
+// @Router /tags [get]
+// @Security BearerAuth
+func (h *TagsHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	tags, err := h.service.ListByUserID(userID)
+	if err != nil {
+		utils.LogError(err, "Ошибка получения меток", "userID", userID)
+		sendError(w, http.StatusInternalServerError, "Не удалось получить метки")
+		return
+	}
+
+	sendJSON(w, http.StatusOK, tags)
+}
+
+// Create создаёт метку
+// @Summary Создать метку
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Success 201 {object} models.Tag
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /tags [post]
+// @Security BearerAuth
+func (h *TagsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	var requestData struct {
+		Name  string `json:"name"`
+		Color string `json:"color"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		sendError(w, http.StatusBadRequest, "Неверный формат JSON")
+		return
+	}
+
+	if ok, msg := utils.ValidateTagName(requestData.Name); !ok {
+		sendError(w, http.StatusBadRequest, msg)
+		return
+	}
+	if requestData.Color != "" && !utils.ValidateColor(requestData.Color) {
+		sendError(w, http.StatusBadRequest, "Цвет должен быть в формате #rrggbb")
+		return
+	}
+
+	tag, err := h.service.Create(userID, strings.TrimSpace(requestData.Name), strings.ToLower(requestData.Color))
+	if err != nil {
+		h.sendTagError(w, err, "Не удалось создать метку", userID)
+		return
+	}
+
+	sendJSON(w, http.StatusCreated, tag)
+}
+
+// Get возвращает метку по ID
+// @Summary Получить метку
+// @Tags tags
+// @Produce json
+// @Success 200 {object} models.Tag
+// @Failure 404 {object} map[string]string
+// @Router /tags/{id} [get]
+// @Security BearerAuth
+func (h *TagsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	tagID, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/tags/"))
+	if err != nil || tagID < 1 {
+		sendError(w, http.StatusBadRequest, "Неверный ID метки")
+		return
+	}
+
+	tag, err := h.service.GetByID(userID, tagID)
+	if err != nil {
+		h.sendTagError(w, err, "Не удалось получить метку", userID)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, tag)
+}
+
+// Update переименовывает метку или меняет её цвет
+// @Summary Изменить метку
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.Tag
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /tags/{id} [put]
+// @Security BearerAuth
+func (h *TagsHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	tagID, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/tags/"))
+	if err != nil || tagID < 1 {
+		sendError(w, http.StatusBadRequest, "Неверный ID метки")
+		return
+	}
+
+	var requestData struct {
+		Name  *string `json:"name"`
+		Color *string `json:"color"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		sendError(w, http.StatusBadRequest, "Неверный формат JSON")
+		return
+	}
+
+	if requestData.Name != nil {
+		if ok, msg := utils.ValidateTagName(*requestData.Name); !ok {
+			sendError(w, http.StatusBadRequest, msg)
+			return
+		}
+		name := strings.TrimSpace(*requestData.Name)
+		requestData.Name = &name
+	}
+	if requestData.Color != nil {
+		if *requestData.Color != "" && !utils.ValidateColor(*requestData.Color) {
+			sendError(w, http.StatusBadRequest, "Цвет должен быть в формате #rrggbb")
+			return
+		}
+		color := strings.ToLower(*requestData.Color)
+		requestData.Color = &color
+	}
+
+	tag, err := h.service.Update(userID, tagID, requestData.Name, requestData.Color)
+	if err != nil {
+		h.sendTagError(w, err, "Не удалось изменить метку", userID)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, tag)
+}
+
+// Delete удаляет метку и снимает её со всех задач
+// @Summary Удалить метку
+// @Tags tags
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Router /tags/{id} [delete]
+// @Security BearerAuth
+func (h *TagsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	tagID, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/tags/"))
+	if err != nil || tagID < 1 {
+		sendError(w, http.StatusBadRequest, "Неверный ID метки")
+		return
+	}
+
+	if err := h.service.Delete(userID, tagID); err != nil {
+		h.sendTagError(w, err, "Не удалось удалить метку", userID)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// sendTagError переводит ошибки сервиса меток в HTTP ответ
+func (h *TagsHandler) sendTagError(w http.ResponseWriter, err error, message string, userID int) {
+	switch {
+	case errors.Is(err, services.ErrTagNotFound):
+		sendError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrTagExists):
+		sendError(w, http.StatusConflict, err.Error())
+	default:
+		utils.LogError(err, message, "userID", userID)
+		sendError(w, http.StatusInternalServerError, message)
+	}
+}
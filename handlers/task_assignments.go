@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"server_new/utils"
+)
+
+// AssignTask назначает исполнителя задачи
+// @Summary Назначить исполнителя
+// @Description Исполнитель должен иметь доступ к задаче: быть её автором или участником проекта
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.Task
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/assignee [put]
+// @Security BearerAuth
+func (h *TasksHandler) AssignTask(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	taskID, err := getTaskID(r)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "ID должен быть числом")
+		return
+	}
+
+	var requestData struct {
+		UserID int `json:"user_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		sendError(w, http.StatusBadRequest, "Неверный формат JSON")
+		return
+	}
+	if requestData.UserID < 1 {
+		sendError(w, http.StatusBadRequest, "Укажи user_id исполнителя")
+		return
+	}
+
+	task, err := h.service.Assign(taskID, userID, requestData.UserID)
+	if err != nil {
+		h.sendTaskError(w, err, "Не удалось назначить исполнителя", taskID)
+		return
+	}
+
+	utils.LogInfo("Исполнитель назначен", "taskID", taskID, "assigneeID", requestData.UserID, "userID", userID)
+	sendJSON(w, http.StatusOK, task)
+}
+
+// UnassignTask снимает исполнителя с задачи
+// @Summary Снять исполнителя
+// @Tags tasks
+// @Produce json
+// @Success 200 {object} models.Task
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/assignee [delete]
+// @Security BearerAuth
+func (h *TasksHandler) UnassignTask(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	taskID, err := getTaskID(r)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "ID должен быть числом")
+		return
+	}
+
+	task, err := h.service.Unassign(taskID, userID)
+	if err != nil {
+		h.sendTaskError(w, err, "Не удалось снять исполнителя", taskID)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, task)
+}
+
+// AssignedToMe возвращает задачи, где текущий пользователь — исполнитель,
+// с той же пагинацией и фильтрами, что и GET /tasks
+// @Summary Задачи, назначенные мне
+// @Tags tasks
+// @Produce json
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество на странице" default(10)
+// @Param status query string false "Фильтр по статусу" Enums(pending, in_progress, completed)
+// @Param created_by query int false "Фильтр по автору"
+// @Success 200 {array} models.Task
+// @Header 200 {string} X-Total-Count "Общее количество задач"
+// @Router /me/assigned [get]
+// @Security BearerAuth
+func (h *TasksHandler) AssignedToMe(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	page, limit, filter, err := parseTaskListQuery(r)
+	if err != nil {
+		sendTaskListQueryError(w, err)
+		return
+	}
+	filter.AssigneeID = userID
+
+	sendTaskList(w, r, h.service, userID, page, limit, filter, "Не удалось получить задачи")
+}
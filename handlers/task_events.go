@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"server_new/models"
+	"server_new/services"
+	"server_new/utils"
+)
+
+// taskEventsPage — страница истории: события и курсор следующей страницы (null — это последняя)
+type taskEventsPage struct {
+	Events     []models.TaskEvent `json:"events"`
+	NextCursor *string            `json:"next_cursor"`
+}
+
+// parseEventsQuery читает параметры cursor и limit (по умолчанию 20, не больше 100)
+func parseEventsQuery(r *http.Request) (string, int) {
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	return query.Get("cursor"), limit
+}
+
+// newTaskEventsPage собирает ответ со страницей событий
+func newTaskEventsPage(events []models.TaskEvent, next string) taskEventsPage {
+	page := taskEventsPage{Events: events}
+	if next != "" {
+		page.NextCursor = &next
+	}
+	return page
+}
+
+// TaskHistory возвращает историю изменений задачи, новые события первыми
+// @Summary История задачи
+// @Tags tasks
+// @Produce json
+// @Param cursor query string false "next_cursor с предыдущей страницы"
+// @Param limit query int false "Количество на странице" default(20)
+// @Success 200 {object} taskEventsPage
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tasks/{id}/history [get]
+// @Security BearerAuth
+func (h *TasksHandler) TaskHistory(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	taskID, err := getTaskID(r)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "ID должен быть числом")
+		return
+	}
+
+	cursor, limit := parseEventsQuery(r)
+	events, next, err := h.service.TaskHistory(userID, taskID, cursor, limit)
+	if err != nil {
+		h.sendTaskError(w, err, "Не удалось получить историю задачи", taskID)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, newTaskEventsPage(events, next))
+}
+
+// Activity возвращает ленту изменений: свои действия и изменения в доступных задачах
+// @Summary Лента активности
+// @Tags tasks
+// @Produce json
+// @Param cursor query string false "next_cursor с предыдущей страницы"
+// @Param limit query int false "Количество на странице" default(20)
+// @Success 200 {object} taskEventsPage
+// @Failure 400 {object} map[string]string
+// @Router /me/activity [get]
+// @Security BearerAuth
+func (h *TasksHandler) Activity(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	cursor, limit := parseEventsQuery(r)
+	events, next, err := h.service.Activity(userID, cursor, limit)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCursor) {
+			sendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.LogError(err, "Ошибка получения ленты активности", "userID", userID)
+		sendError(w, http.StatusInternalServerError, "Не удалось получить ленту активности")
+		return
+	}
+
+	sendJSON(w, http.StatusOK, newTaskEventsPage(events, next))
+}
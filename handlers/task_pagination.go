@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"server_new/models"
+	"server_new/services"
+	"server_new/utils"
+)
+
+// taskListPage — список задач в режиме курсоров (?cursor=): задачи и курсоры соседних страниц
+// (null — такой страницы нет)
+type taskListPage struct {
+	Tasks      []models.Task `json:"tasks"`
+	NextCursor *string       `json:"next_cursor"`
+	PrevCursor *string       `json:"prev_cursor"`
+}
+
+// sendTaskList отвечает страницей задач с фильтром filter. Без параметра cursor — массив задач
+// страницы page; с cursor (в том числе пустым — первая страница) — конверт taskListPage
+// и заголовок Link с соседними страницами. Общее количество (X-Total-Count) по умолчанию
+// считается только без курсора, ?count=true|false меняет это.
+func sendTaskList(w http.ResponseWriter, r *http.Request, tasks *services.TasksService, userID, page, limit int, filter services.TaskFilter, message string) {
+	query := r.URL.Query()
+	cursorMode := query.Has("cursor")
+
+	req := services.TaskPageRequest{Page: page, Limit: limit, Cursor: query.Get("cursor"), Count: !cursorMode}
+	if value := query.Get("count"); value != "" {
+		count, err := strconv.ParseBool(value)
+		if err != nil {
+			sendError(w, http.StatusBadRequest, "count должен быть true или false")
+			return
+		}
+		req.Count = count
+	}
+
+	result, err := tasks.ListTasks(userID, req, filter)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCursor) {
+			sendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.LogError(err, message, "userID", userID)
+		sendError(w, http.StatusInternalServerError, message)
+		return
+	}
+
+	if result.Total >= 0 {
+		w.Header().Set("X-Total-Count", strconv.Itoa(result.Total))
+	}
+	if !cursorMode {
+		sendJSON(w, http.StatusOK, result.Tasks)
+		return
+	}
+
+	body := taskListPage{Tasks: result.Tasks}
+	var links []string
+	if result.NextCursor != "" {
+		body.NextCursor = &result.NextCursor
+		links = append(links, taskListLink(r, result.NextCursor, "next"))
+	}
+	if result.PrevCursor != "" {
+		body.PrevCursor = &result.PrevCursor
+		links = append(links, taskListLink(r, result.PrevCursor, "prev"))
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+	sendJSON(w, http.StatusOK, body)
+}
+
+// taskListLink — ссылка для заголовка Link: тот же запрос с другим курсором
+func taskListLink(r *http.Request, cursor, rel string) string {
+	query := r.URL.Query()
+	query.Del("page")
+	query.Set("cursor", cursor)
+	return "<" + r.URL.Path + "?" + query.Encode() + `>; rel="` + rel + `"`
+}
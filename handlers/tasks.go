@@ -2,22 +2,34 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
+
+	"server_new/models"
 
 	"server_new/services"
+	"server_new/taskquery"
 	"server_new/utils"
 )
 
 type TasksHandler struct {
-	service *services.TasksService
+	service   *services.TasksService
+	checklist *services.ChecklistService
+	comments  *services.CommentsService
+	views     *services.SavedViewsService
 }
 
 func NewTasksHandler() *TasksHandler {
 	return &TasksHandler{
-		service: services.NewTasksService(),
+		service:   services.NewTasksService(),
+		checklist: services.NewChecklistService(),
+		comments:  services.NewCommentsService(),
+		views:     services.NewSavedViewsService(),
 	}
 }
 
@@ -41,17 +53,193 @@ func getUserID(r *http.Request) (int, error) {
 	return strconv.Atoi(userIDStr)
 }
 
+// getTaskID извлекает ID задачи из пути /tasks/{id} или /tasks/{id}/...
+func getTaskID(r *http.Request) (int, error) {
+	id, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/tasks/"), "/")
+	taskID, err := strconv.Atoi(id)
+	if err != nil || taskID < 1 {
+		return 0, fmt.Errorf("неверный ID задачи")
+	}
+	return taskID, nil
+}
+
+// parseDueAt разбирает срок выполнения в формате RFC 3339
+func parseDueAt(value string) (*time.Time, error) {
+	dueAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("due_at должен быть в формате RFC 3339, например 2024-05-01T18:00:00+03:00")
+	}
+	return &dueAt, nil
+}
+
+// validateTagNames проверяет названия меток задачи
+func validateTagNames(names []string) (bool, string) {
+	for _, name := range names {
+		if ok, msg := utils.ValidateTagName(name); !ok {
+			return false, msg
+		}
+	}
+	return true, ""
+}
+
+// isTaskInputError — ошибки в данных задачи (проект, родительская задача, повторение), на которые отвечаем 400
+func isTaskInputError(err error) bool {
+	return errors.Is(err, services.ErrProjectNotFound) ||
+		errors.Is(err, services.ErrParentTaskNotFound) ||
+		errors.Is(err, services.ErrTaskCycle) ||
+		errors.Is(err, services.ErrTaskTooDeep) ||
+		errors.Is(err, services.ErrSubtaskProject) ||
+		errors.Is(err, services.ErrRecurrenceNeedsDue)
+}
+
+// parseRecurrence проверяет правило повторения и часовой пояс и приводит правило к каноническому виду
+func parseRecurrence(rrule, timezone string) (*models.TaskRecurrence, error) {
+	rule, err := utils.ParseRRule(rrule)
+	if err != nil {
+		return nil, fmt.Errorf("Некорректное правило повторения: %v", err)
+	}
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return nil, errors.New("Неизвестный часовой пояс повторения")
+	}
+	return &models.TaskRecurrence{RRule: rule.String(), Timezone: timezone}, nil
+}
+
+// recurrenceRequest — правило повторения в теле запроса
+type recurrenceRequest struct {
+	RRule    string `json:"rrule"`
+	Timezone string `json:"timezone"`
+}
+
+// validatePriority проверяет приоритет задачи
+func validatePriority(priority string) bool {
+	_, ok := models.PriorityRank(priority)
+	return ok
+}
+
+// parseTaskListQuery разбирает параметры списка задач: пагинацию, фильтры и сортировку
+func parseTaskListQuery(r *http.Request) (int, int, services.TaskFilter, error) {
+	query := r.URL.Query()
+
+	page, _ := strconv.Atoi(query.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	filter, err := services.ParseTaskFilter(query)
+	if err != nil {
+		return 0, 0, filter, err
+	}
+
+	return page, limit, filter, nil
+}
+
+// usesDefaultView — в запросе списка задач нет параметров, кроме пагинации (page, cursor, count)
+func usesDefaultView(query url.Values) bool {
+	for key := range query {
+		if key != "page" && key != "cursor" && key != "count" {
+			return false
+		}
+	}
+	return true
+}
+
+// sendTaskListQueryError отвечает 400 на ошибку в параметрах списка задач; для ошибки в языке
+// фильтров добавляет позицию и фрагмент запроса, в котором она найдена
+func sendTaskListQueryError(w http.ResponseWriter, err error) {
+	var queryErr *taskquery.Error
+	if errors.As(err, &queryErr) {
+		sendJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error":    err.Error(),
+			"position": queryErr.Pos,
+			"token":    queryErr.Token,
+		})
+		return
+	}
+	sendError(w, http.StatusBadRequest, err.Error())
+}
+
+// SearchTasks ищет задачи по названию и описанию с теми же фильтрами и пагинацией, что и GET /tasks
+// @Summary Полнотекстовый поиск задач
+// @Description Слова ищутся по префиксу, текст в кавычках — точной фразой. Без sort — по релевантности.
+// @Tags tasks
+// @Produce json
+// @Param q query string true "Поисковый запрос"
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество на странице" default(10)
+// @Param status query string false "Фильтр по статусу" Enums(pending, in_progress, completed)
+// @Success 200 {array} models.TaskSearchResult
+// @Header 200 {string} X-Total-Count "Общее количество найденных задач"
+// @Failure 400 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /tasks/search [get]
+// @Security BearerAuth
+func (h *TasksHandler) SearchTasks(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	page, limit, filter, err := parseTaskListQuery(r)
+	if err != nil {
+		sendTaskListQueryError(w, err)
+		return
+	}
+
+	results, total, err := h.service.SearchTasks(userID, r.URL.Query().Get("q"), page, limit, filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidSearchQuery):
+			sendError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrSearchUnavailable):
+			sendError(w, http.StatusServiceUnavailable, err.Error())
+		default:
+			utils.LogError(err, "Ошибка поиска задач", "userID", userID)
+			sendError(w, http.StatusInternalServerError, "Не удалось выполнить поиск")
+		}
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	sendJSON(w, http.StatusOK, results)
+}
+
 // GetTasks получение списка задач
 // @Summary Получить список задач
-// @Description Возвращает список задач текущего пользователя с пагинацией
+// @Description Возвращает список задач текущего пользователя с пагинацией, фильтрами и сортировкой
 // @Tags tasks
 // @Accept json
 // @Produce json
 // @Param page query int false "Номер страницы" default(1)
 // @Param limit query int false "Количество на странице" default(10)
 // @Param status query string false "Фильтр по статусу" Enums(pending, in_progress, completed)
+// @Param priority query string false "Фильтр по приоритету" Enums(low, normal, high, urgent)
+// @Param project_id query int false "Фильтр по проекту"
+// @Param assignee_id query int false "Фильтр по исполнителю"
+// @Param created_by query int false "Фильтр по автору"
+// @Param series_id query int false "Повторения одной серии"
+// @Param tags_any query string false "Метки через запятую: задача помечена хотя бы одной"
+// @Param tags_all query string false "Метки через запятую: задача помечена всеми"
+// @Param due query string false "Фильтр по сроку" Enums(overdue, today, this_week)
+// @Param filter query string false "Условия на языке фильтров, например: status:pending priority>=high"
+// @Param sort query string false "Поля сортировки через запятую (title, priority, due_at, completed_at, created_at, updated_at), '-' — по убыванию, например: title,-created_at"
+// @Param tz query string false "Часовой пояс для today и this_week (IANA)" default(UTC)
+// @Param cursor query string false "Курсор страницы (next_cursor или prev_cursor); пустой — первая страница, ответ — taskListPage"
+// @Param count query bool false "Считать общее количество (по умолчанию — только без cursor)"
+// @Param view query string false "none — не применять вид по умолчанию"
 // @Success 200 {array} models.Task
 // @Header 200 {string} X-Total-Count "Общее количество задач"
+// @Header 200 {string} X-View-ID "ID применённого вида по умолчанию"
+// @Header 200 {string} Link "Ссылки на соседние страницы в режиме курсоров"
+// @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Router /tasks [get]
 // @Security BearerAuth
@@ -62,28 +250,32 @@ func (h *TasksHandler) GetTasks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	if page < 1 {
-		page = 1
+	// Без параметров (кроме пагинации) показываем вид по умолчанию, если он выбран
+	query := r.URL.Query()
+	if view := query.Get("view"); view != "" && view != "none" {
+		sendError(w, http.StatusBadRequest, "Параметр view может быть только none")
+		return
 	}
-
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	if limit < 1 || limit > 100 {
-		limit = 10
+	if usesDefaultView(query) {
+		view, err := h.views.Default(userID)
+		if err == nil {
+			sendViewTasks(w, r, h.service, userID, view)
+			return
+		}
+		if !errors.Is(err, services.ErrSavedViewNotFound) {
+			utils.LogError(err, "Ошибка получения вида по умолчанию", "userID", userID)
+			sendError(w, http.StatusInternalServerError, "Не удалось получить задачи")
+			return
+		}
 	}
 
-	status := r.URL.Query().Get("status")
-
-	tasks, total, err := h.service.GetTasksByUserID(userID, page, limit, status)
+	page, limit, filter, err := parseTaskListQuery(r)
 	if err != nil {
-		sendError(w, http.StatusInternalServerError, "Не удалось получить задачи")
+		sendTaskListQueryError(w, err)
 		return
 	}
 
-	// Устанавливаем заголовок с общим количеством задач
-	w.Header().Set("X-Total-Count", strconv.Itoa(total))
-
-	sendJSON(w, http.StatusOK, tasks)
+	sendTaskList(w, r, h.service, userID, page, limit, filter, "Не удалось получить задачи")
 }
 
 func (h *TasksHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
@@ -94,9 +286,15 @@ func (h *TasksHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var requestData struct {
-		Title       string `json:"title"`
-		Description string `json:"description"`
-		Status      string `json:"status"`
+		Title       string             `json:"title"`
+		Description string             `json:"description"`
+		Status      string             `json:"status"`
+		Priority    string             `json:"priority"`
+		DueAt       string             `json:"due_at"`
+		ProjectID   *int               `json:"project_id"`
+		ParentID    *int               `json:"parent_id"`
+		Tags        []string           `json:"tags"`
+		Recurrence  *recurrenceRequest `json:"recurrence"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
@@ -110,17 +308,61 @@ func (h *TasksHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	description := strings.TrimSpace(requestData.Description)
-	status := strings.TrimSpace(requestData.Status)
-	if status == "" {
-		status = "pending"
+	input := services.NewTask{
+		Title:       title,
+		Description: strings.TrimSpace(requestData.Description),
+		Status:      strings.TrimSpace(requestData.Status),
+		Priority:    strings.TrimSpace(requestData.Priority),
+		ProjectID:   requestData.ProjectID,
+		ParentID:    requestData.ParentID,
+		Tags:        requestData.Tags,
+	}
+	if input.Status == "" {
+		input.Status = models.TaskStatusPending
+	}
+	if !utils.ValidateTaskStatus(input.Status) {
+		sendError(w, http.StatusBadRequest, "Статус должен быть: pending, in_progress или completed")
+		return
+	}
+	if input.Priority == "" {
+		input.Priority = models.PriorityNormal
+	}
+	if !validatePriority(input.Priority) {
+		sendError(w, http.StatusBadRequest, "Приоритет должен быть: low, normal, high или urgent")
+		return
+	}
+	if ok, msg := validateTagNames(input.Tags); !ok {
+		sendError(w, http.StatusBadRequest, msg)
+		return
+	}
+	if requestData.DueAt != "" {
+		input.DueAt, err = parseDueAt(requestData.DueAt)
+		if err != nil {
+			sendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if requestData.Recurrence != nil {
+		input.Recurrence, err = parseRecurrence(requestData.Recurrence.RRule, requestData.Recurrence.Timezone)
+		if err != nil {
+			sendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 	}
 
 	// Логируем начало операции
 	utils.LogInfo("Создание задачи", "userID", userID, "title", title)
 
-	task, err := h.service.CreateTask(title, description, status, userID)
+	task, err := h.service.CreateTask(userID, input)
 	if err != nil {
+		if isTaskInputError(err) {
+			sendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrProjectForbidden) {
+			sendError(w, http.StatusForbidden, err.Error())
+			return
+		}
 		// Логируем ошибку
 		utils.LogError(err, "Ошибка создания задачи", "userID", userID)
 		sendError(w, http.StatusInternalServerError, "Не удалось создать задачу")
@@ -132,3 +374,375 @@ func (h *TasksHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 
 	sendJSON(w, http.StatusCreated, task)
 }
+
+// Task обрабатывает /tasks/{id}: GET — получить, PUT — обновить, DELETE — удалить,
+// /tasks/{id}/assignee: PUT — назначить исполнителя, DELETE — снять,
+// GET /tasks/{id}/subtasks и чек-лист: /tasks/{id}/checklist (GET, POST),
+// PUT /tasks/{id}/checklist/order, /tasks/{id}/checklist/{itemID} (PUT, DELETE),
+// зависимости: /tasks/{id}/dependencies (GET, POST), DELETE /tasks/{id}/dependencies/{blockerID},
+// повторение: /tasks/{id}/recurrence (GET — ближайшие повторения, DELETE — остановить серию),
+// GET /tasks/{id}/history, комментарии: /tasks/{id}/comments (GET, POST),
+// /tasks/{id}/comments/{commentID} (GET, PUT, DELETE), GET /tasks/{id}/comments/{commentID}/history,
+// а также поиск: GET /tasks/search
+func (h *TasksHandler) Task(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/tasks/"), "/")
+
+	switch {
+	case len(parts) == 1 && parts[0] == "search":
+		if r.Method != http.MethodGet {
+			sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+			return
+		}
+		h.SearchTasks(w, r)
+	case len(parts) == 1:
+		switch r.Method {
+		case http.MethodGet:
+			h.GetTask(w, r)
+		case http.MethodPut:
+			h.UpdateTask(w, r)
+		case http.MethodDelete:
+			h.DeleteTask(w, r)
+		default:
+			sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+		}
+	case len(parts) == 2 && parts[1] == "assignee":
+		switch r.Method {
+		case http.MethodPut:
+			h.AssignTask(w, r)
+		case http.MethodDelete:
+			h.UnassignTask(w, r)
+		default:
+			sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+		}
+	case len(parts) == 2 && parts[1] == "subtasks":
+		if r.Method != http.MethodGet {
+			sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+			return
+		}
+		h.Subtasks(w, r)
+	case len(parts) == 2 && parts[1] == "checklist":
+		switch r.Method {
+		case http.MethodGet:
+			h.ListChecklist(w, r)
+		case http.MethodPost:
+			h.CreateChecklistItem(w, r)
+		default:
+			sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+		}
+	case len(parts) == 3 && parts[1] == "checklist" && parts[2] == "order":
+		if r.Method != http.MethodPut {
+			sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+			return
+		}
+		h.ReorderChecklist(w, r)
+	case len(parts) == 3 && parts[1] == "checklist":
+		itemID, err := strconv.Atoi(parts[2])
+		if err != nil || itemID < 1 {
+			sendError(w, http.StatusBadRequest, "Неверный ID пункта чек-листа")
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			h.UpdateChecklistItem(w, r, itemID)
+		case http.MethodDelete:
+			h.DeleteChecklistItem(w, r, itemID)
+		default:
+			sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+		}
+	case len(parts) == 2 && parts[1] == "dependencies":
+		switch r.Method {
+		case http.MethodGet:
+			h.ListDependencies(w, r)
+		case http.MethodPost:
+			h.AddDependency(w, r)
+		default:
+			sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+		}
+	case len(parts) == 3 && parts[1] == "dependencies":
+		blockerID, err := strconv.Atoi(parts[2])
+		if err != nil || blockerID < 1 {
+			sendError(w, http.StatusBadRequest, "Неверный ID блокирующей задачи")
+			return
+		}
+		if r.Method != http.MethodDelete {
+			sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+			return
+		}
+		h.RemoveDependency(w, r, blockerID)
+	case len(parts) == 2 && parts[1] == "recurrence":
+		switch r.Method {
+		case http.MethodGet:
+			h.PreviewRecurrence(w, r)
+		case http.MethodDelete:
+			h.StopRecurrence(w, r)
+		default:
+			sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+		}
+	case len(parts) == 2 && parts[1] == "history":
+		if r.Method != http.MethodGet {
+			sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+			return
+		}
+		h.TaskHistory(w, r)
+	case len(parts) == 2 && parts[1] == "comments":
+		switch r.Method {
+		case http.MethodGet:
+			h.ListComments(w, r)
+		case http.MethodPost:
+			h.CreateComment(w, r)
+		default:
+			sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+		}
+	case (len(parts) == 3 || len(parts) == 4 && parts[3] == "history") && parts[1] == "comments":
+		commentID, err := strconv.Atoi(parts[2])
+		if err != nil || commentID < 1 {
+			sendError(w, http.StatusBadRequest, "Неверный ID комментария")
+			return
+		}
+		if len(parts) == 4 {
+			if r.Method != http.MethodGet {
+				sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+				return
+			}
+			h.CommentHistory(w, r, commentID)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			h.GetComment(w, r, commentID)
+		case http.MethodPut:
+			h.UpdateComment(w, r, commentID)
+		case http.MethodDelete:
+			h.DeleteComment(w, r, commentID)
+		default:
+			sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+		}
+	default:
+		sendError(w, http.StatusNotFound, "Маршрут не найден")
+	}
+}
+
+// GetTask возвращает задачу текущего пользователя по ID
+func (h *TasksHandler) GetTask(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	taskID, err := getTaskID(r)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "ID должен быть числом")
+		return
+	}
+
+	task, err := h.service.GetTaskByID(taskID, userID)
+	if err != nil {
+		h.sendTaskError(w, err, "Не удалось получить задачу", taskID)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, task)
+}
+
+// UpdateTask частично обновляет задачу: меняются только переданные поля,
+// "due_at": null убирает срок выполнения, "project_id": null выносит задачу из проекта,
+// "parent_id": null делает подзадачу задачей верхнего уровня, "recurrence": null отключает повторение,
+// "tags": [] снимает все метки
+func (h *TasksHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	taskID, err := getTaskID(r)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "ID должен быть числом")
+		return
+	}
+
+	var requestData struct {
+		Title       *string         `json:"title"`
+		Description *string         `json:"description"`
+		Status      *string         `json:"status"`
+		Priority    *string         `json:"priority"`
+		DueAt       json.RawMessage `json:"due_at"`
+		ProjectID   json.RawMessage `json:"project_id"`
+		ParentID    json.RawMessage `json:"parent_id"`
+		Tags        *[]string       `json:"tags"`
+		Recurrence  json.RawMessage `json:"recurrence"`
+		// CompleteSubtasks — при переходе в completed завершить и открытые подзадачи
+		CompleteSubtasks bool `json:"complete_subtasks"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		sendError(w, http.StatusBadRequest, "Неверный формат JSON")
+		return
+	}
+
+	update := services.TaskUpdate{
+		Description:      requestData.Description,
+		Status:           requestData.Status,
+		Priority:         requestData.Priority,
+		Tags:             requestData.Tags,
+		CompleteSubtasks: requestData.CompleteSubtasks,
+	}
+
+	if requestData.Title != nil {
+		if ok, msg := utils.ValidateTaskTitle(*requestData.Title); !ok {
+			sendError(w, http.StatusBadRequest, msg)
+			return
+		}
+		title := strings.TrimSpace(*requestData.Title)
+		update.Title = &title
+	}
+	if update.Description != nil {
+		description := strings.TrimSpace(*update.Description)
+		update.Description = &description
+	}
+	if update.Status != nil && !utils.ValidateTaskStatus(*update.Status) {
+		sendError(w, http.StatusBadRequest, "Статус должен быть: pending, in_progress или completed")
+		return
+	}
+	if update.Priority != nil && !validatePriority(*update.Priority) {
+		sendError(w, http.StatusBadRequest, "Приоритет должен быть: low, normal, high или urgent")
+		return
+	}
+	if update.Tags != nil {
+		if ok, msg := validateTagNames(*update.Tags); !ok {
+			sendError(w, http.StatusBadRequest, msg)
+			return
+		}
+	}
+	if len(requestData.DueAt) > 0 {
+		if string(requestData.DueAt) == "null" {
+			update.ClearDueAt = true
+		} else {
+			var value string
+			if err := json.Unmarshal(requestData.DueAt, &value); err != nil {
+				sendError(w, http.StatusBadRequest, "due_at должен быть строкой или null")
+				return
+			}
+			update.DueAt, err = parseDueAt(value)
+			if err != nil {
+				sendError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+	}
+
+	if len(requestData.ProjectID) > 0 {
+		if string(requestData.ProjectID) == "null" {
+			update.ClearProject = true
+		} else {
+			var projectID int
+			if err := json.Unmarshal(requestData.ProjectID, &projectID); err != nil {
+				sendError(w, http.StatusBadRequest, "project_id должен быть числом или null")
+				return
+			}
+			update.ProjectID = &projectID
+		}
+	}
+
+	if len(requestData.ParentID) > 0 {
+		if string(requestData.ParentID) == "null" {
+			update.ClearParent = true
+		} else {
+			var parentID int
+			if err := json.Unmarshal(requestData.ParentID, &parentID); err != nil {
+				sendError(w, http.StatusBadRequest, "parent_id должен быть числом или null")
+				return
+			}
+			update.ParentID = &parentID
+		}
+	}
+
+	if len(requestData.Recurrence) > 0 {
+		if string(requestData.Recurrence) == "null" {
+			update.ClearRecurrence = true
+		} else {
+			var recurrence recurrenceRequest
+			if err := json.Unmarshal(requestData.Recurrence, &recurrence); err != nil {
+				sendError(w, http.StatusBadRequest, "recurrence должен быть объектом {rrule, timezone} или null")
+				return
+			}
+			update.Recurrence, err = parseRecurrence(recurrence.RRule, recurrence.Timezone)
+			if err != nil {
+				sendError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+	}
+
+	task, err := h.service.UpdateTask(taskID, userID, update)
+	if err != nil {
+		if errors.Is(err, services.ErrNoTaskChanges) || isTaskInputError(err) {
+			sendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.sendTaskError(w, err, "Не удалось обновить задачу", taskID)
+		return
+	}
+
+	utils.LogInfo("Задача обновлена", "taskID", taskID, "userID", userID)
+	sendJSON(w, http.StatusOK, task)
+}
+
+// DeleteTask удаляет задачу текущего пользователя
+func (h *TasksHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	taskID, err := getTaskID(r)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "ID должен быть числом")
+		return
+	}
+
+	if err := h.service.DeleteTask(taskID, userID); err != nil {
+		h.sendTaskError(w, err, "Не удалось удалить задачу", taskID)
+		return
+	}
+
+	utils.LogInfo("Задача удалена", "taskID", taskID, "userID", userID)
+	sendJSON(w, http.StatusOK, map[string]string{"message": "Задача удалена"})
+}
+
+// sendTaskError отвечает 404 для недоступной или несуществующей задачи, 403 — если роли
+// в общем проекте не хватает для изменения, 400 — если исполнитель не видит задачу,
+// 409 — если смене статуса мешают открытые подзадачи или блокирующие задачи, 500 — для остальных ошибок
+func (h *TasksHandler) sendTaskError(w http.ResponseWriter, err error, message string, taskID int) {
+	if errors.Is(err, services.ErrTaskNotFound) {
+		sendError(w, http.StatusNotFound, "Задача не найдена")
+		return
+	}
+	if errors.Is(err, services.ErrProjectForbidden) || errors.Is(err, services.ErrCommentForbidden) {
+		sendError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if errors.Is(err, services.ErrAssigneeNoAccess) || errors.Is(err, services.ErrChecklistOrder) ||
+		errors.Is(err, services.ErrBlockerNotFound) || errors.Is(err, services.ErrDependencyCycle) ||
+		errors.Is(err, services.ErrTaskNotRecurring) || errors.Is(err, services.ErrInvalidCursor) {
+		sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, services.ErrChecklistItemNotFound) || errors.Is(err, services.ErrDependencyNotFound) ||
+		errors.Is(err, services.ErrCommentNotFound) {
+		sendError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, services.ErrOpenSubtasks) {
+		sendError(w, http.StatusConflict, err.Error()+": заверши их или передай \"complete_subtasks\": true")
+		return
+	}
+	if errors.Is(err, services.ErrTaskBlocked) || errors.Is(err, services.ErrDependencyExists) {
+		sendError(w, http.StatusConflict, err.Error())
+		return
+	}
+	utils.LogError(err, message, "taskID", taskID)
+	sendError(w, http.StatusInternalServerError, message)
+}
@@ -5,35 +5,37 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"database/sql"
 	"server_new/config"
+	"server_new/migrations"
+	"server_new/utils"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
 func setupTestDB(t *testing.T) {
+	// Обработчики пишут в лог, поэтому логгер должен быть инициализирован
+	utils.InitLogger()
+
 	// Используем тестовую БД в памяти
 	var err error
 	config.DB, err = sql.Open("sqlite3", ":memory:")
 	if err != nil {
 		t.Fatal("Ошибка открытия БД:", err)
 	}
+	// Каждое соединение с :memory: — отдельная БД, поэтому держим одно
+	config.DB.SetMaxOpenConns(1)
 
-	// Создаём таблицы
-	_, err = config.DB.Exec(`
-		CREATE TABLE IF NOT EXISTS tasks (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			title TEXT NOT NULL,
-			description TEXT,
-			status TEXT DEFAULT 'pending',
-			userid INTEGER NOT NULL,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		)
-	`)
+	// Создаём таблицы той же схемы, что и в рабочей БД
+	if err := migrations.RunMigrations(config.DB); err != nil {
+		t.Fatal("Ошибка миграций:", err)
+	}
+	_, err = config.DB.Exec("INSERT INTO users (id, username, email, password) VALUES (1, 'test', 'test@example.com', 'hash')")
 	if err != nil {
-		t.Fatal("Ошибка создания таблицы:", err)
+		t.Fatal("Ошибка создания пользователя:", err)
 	}
 }
 
@@ -57,6 +59,31 @@ func TestTasksHandler_CreateTask(t *testing.T) {
 			},
 			expectedStatus: http.StatusCreated,
 		},
+		{
+			name: "приоритет и срок",
+			body: map[string]interface{}{
+				"title":    "Сдать отчёт",
+				"priority": "urgent",
+				"due_at":   "2030-05-01T18:00:00+03:00",
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "неизвестный приоритет",
+			body: map[string]interface{}{
+				"title":    "Тест",
+				"priority": "critical",
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "срок не в RFC 3339",
+			body: map[string]interface{}{
+				"title":  "Тест",
+				"due_at": "01.05.2030",
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
 		{
 			name: "пустой заголовок",
 			body: map[string]interface{}{
@@ -82,3 +109,241 @@ func TestTasksHandler_CreateTask(t *testing.T) {
 		})
 	}
 }
+
+func TestTasksHandler_GetTasksFilter(t *testing.T) {
+	setupTestDB(t)
+	defer config.CloseDB()
+
+	handler := NewTasksHandler()
+
+	tests := []struct {
+		name           string
+		filter         string
+		expectedStatus int
+		position       int
+		token          string
+	}{
+		{"валидный фильтр", `status:pending priority>=high -"черновик"`, http.StatusOK, 0, ""},
+		{"неизвестное поле", "status:pending colour:red", http.StatusBadRequest, 16, "colour:red"},
+		{"неверное значение", "priority>=critical", http.StatusBadRequest, 1, "priority>=critical"},
+		{"синтаксическая ошибка", `title:"abc`, http.StatusBadRequest, 7, `"abc`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tasks?filter="+url.QueryEscape(tt.filter), nil)
+			req.Header.Set("X-User-ID", "1")
+
+			w := httptest.NewRecorder()
+			handler.GetTasks(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("GetTasks() status = %v, want %v: %s", w.Code, tt.expectedStatus, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusBadRequest {
+				return
+			}
+
+			var body struct {
+				Position int    `json:"position"`
+				Token    string `json:"token"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Position != tt.position || body.Token != tt.token {
+				t.Errorf("Ошибка указывает на %d «%s», ожидалось %d «%s»", body.Position, body.Token, tt.position, tt.token)
+			}
+		})
+	}
+}
+
+func TestTasksHandler_GetTasksDefaultView(t *testing.T) {
+	setupTestDB(t)
+	defer config.CloseDB()
+
+	handler := NewTasksHandler()
+	views := NewSavedViewsHandler()
+
+	for _, body := range []string{
+		`{"title": "Открытая", "status": "pending"}`,
+		`{"title": "Готовая", "status": "completed"}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/tasks", bytes.NewBufferString(body))
+		req.Header.Set("X-User-ID", "1")
+		w := httptest.NewRecorder()
+		handler.CreateTask(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("CreateTask() status = %v: %s", w.Code, w.Body.String())
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/me/views", bytes.NewBufferString(`{"name": "Колонка", "filters": {"status": "done"}}`))
+	req.Header.Set("X-User-ID", "1")
+	w := httptest.NewRecorder()
+	views.Create(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Create() с неверным фильтром: status = %v, want 400: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/me/views", bytes.NewBufferString(`{"name": "Открытые", "filters": {"status": "pending"}, "is_default": true}`))
+	req.Header.Set("X-User-ID", "1")
+	w = httptest.NewRecorder()
+	views.Create(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Create() status = %v: %s", w.Code, w.Body.String())
+	}
+	tests := []struct {
+		name   string
+		path   string
+		total  string
+		viewID string
+	}{
+		{"без параметров — вид по умолчанию", "/tasks", "1", "1"},
+		{"page не отключает вид", "/tasks?page=1", "1", "1"},
+		{"view=none — все задачи", "/tasks?view=none", "2", ""},
+		{"явный фильтр — без вида", "/tasks?status=completed", "1", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("X-User-ID", "1")
+			w := httptest.NewRecorder()
+			handler.GetTasks(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("GetTasks() status = %v: %s", w.Code, w.Body.String())
+			}
+			if got := w.Header().Get("X-Total-Count"); got != tt.total {
+				t.Errorf("X-Total-Count = %s, want %s", got, tt.total)
+			}
+			if got := w.Header().Get("X-View-ID"); got != tt.viewID {
+				t.Errorf("X-View-ID = %q, want %q", got, tt.viewID)
+			}
+		})
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/views/1/tasks", nil)
+	req.Header.Set("X-User-ID", "1")
+	w = httptest.NewRecorder()
+	views.Tasks(w, req)
+	if w.Code != http.StatusOK || w.Header().Get("X-Total-Count") != "1" {
+		t.Errorf("Tasks() status = %v, X-Total-Count = %s", w.Code, w.Header().Get("X-Total-Count"))
+	}
+}
+
+func TestTasksHandler_GetTasksCursor(t *testing.T) {
+	setupTestDB(t)
+	defer config.CloseDB()
+
+	handler := NewTasksHandler()
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/tasks", bytes.NewBufferString(`{"title": "Задача"}`))
+		req.Header.Set("X-User-ID", "1")
+		w := httptest.NewRecorder()
+		handler.CreateTask(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("CreateTask() status = %v: %s", w.Code, w.Body.String())
+		}
+	}
+
+	get := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("X-User-ID", "1")
+		w := httptest.NewRecorder()
+		handler.GetTasks(w, req)
+		return w
+	}
+
+	w := get("/tasks?cursor=&limit=2&status=pending")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GetTasks() status = %v: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("X-Total-Count") != "" {
+		t.Errorf("Без count=true количество не должно считаться")
+	}
+	var page struct {
+		Tasks      []map[string]interface{} `json:"tasks"`
+		NextCursor *string                  `json:"next_cursor"`
+		PrevCursor *string                  `json:"prev_cursor"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Tasks) != 2 || page.NextCursor == nil || page.PrevCursor != nil {
+		t.Fatalf("Первая страница: %d задач, next=%v, prev=%v", len(page.Tasks), page.NextCursor, page.PrevCursor)
+	}
+	wantLink := `</tasks?cursor=` + url.QueryEscape(*page.NextCursor) + `&limit=2&status=pending>; rel="next"`
+	if link := w.Header().Get("Link"); link != wantLink {
+		t.Errorf("Link = %s, want %s", link, wantLink)
+	}
+
+	w = get("/tasks?limit=2&status=pending&count=true&cursor=" + url.QueryEscape(*page.NextCursor))
+	if w.Code != http.StatusOK || w.Header().Get("X-Total-Count") != "3" {
+		t.Fatalf("Вторая страница: status = %v, X-Total-Count = %q", w.Code, w.Header().Get("X-Total-Count"))
+	}
+	page.NextCursor, page.PrevCursor = nil, nil
+	if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Tasks) != 1 || page.NextCursor != nil || page.PrevCursor == nil {
+		t.Errorf("Вторая страница: %d задач, next=%v, prev=%v", len(page.Tasks), page.NextCursor, page.PrevCursor)
+	}
+
+	if w := get("/tasks?cursor=bad"); w.Code != http.StatusBadRequest {
+		t.Errorf("Некорректный курсор: status = %v, want 400", w.Code)
+	}
+	if w := get("/tasks?count=maybe"); w.Code != http.StatusBadRequest {
+		t.Errorf("Некорректный count: status = %v, want 400", w.Code)
+	}
+}
+
+func TestTasksHandler_GetTasksSort(t *testing.T) {
+	setupTestDB(t)
+	defer config.CloseDB()
+
+	handler := NewTasksHandler()
+	for _, body := range []string{
+		`{"title": "b", "priority": "low"}`,
+		`{"title": "a", "priority": "low"}`,
+		`{"title": "b", "priority": "urgent"}`,
+		`{"title": "a", "status": "completed"}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/tasks", bytes.NewBufferString(body))
+		req.Header.Set("X-User-ID", "1")
+		w := httptest.NewRecorder()
+		handler.CreateTask(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("CreateTask() status = %v: %s", w.Code, w.Body.String())
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/tasks?status=pending&sort=title,-priority,-created_at&limit=2", nil)
+	req.Header.Set("X-User-ID", "1")
+	w := httptest.NewRecorder()
+	handler.GetTasks(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GetTasks() status = %v: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("X-Total-Count"); got != "3" {
+		t.Errorf("X-Total-Count = %s, want 3", got)
+	}
+	var tasks []struct {
+		Title    string `json:"title"`
+		Priority string `json:"priority"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&tasks); err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 2 || tasks[0].Title != "a" || tasks[1].Title != "b" || tasks[1].Priority != "urgent" {
+		t.Errorf("Порядок задач: %+v", tasks)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/tasks?sort=title,password", nil)
+	req.Header.Set("X-User-ID", "1")
+	w = httptest.NewRecorder()
+	handler.GetTasks(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Неизвестное поле сортировки: status = %v, want 400", w.Code)
+	}
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"server_new/services"
+	"server_new/utils"
+)
+
+// TwoFactorHandler обрабатывает настройку двухфакторной аутентификации (/me/2fa)
+type TwoFactorHandler struct {
+	service *services.TwoFactorService
+}
+
+func NewTwoFactorHandler() *TwoFactorHandler {
+	return &TwoFactorHandler{
+		service: services.NewTwoFactorService(),
+	}
+}
+
+// Status возвращает состояние 2FA
+// @Summary Состояние 2FA
+// @Tags 2fa
+// @Produce json
+// @Success 200 {object} services.TwoFactorStatus
+// @Router /me/2fa [get]
+// @Security BearerAuth
+func (h *TwoFactorHandler) Status(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	status, err := h.service.Status(userID)
+	if err != nil {
+		utils.LogError(err, "Ошибка получения состояния 2FA", "userID", userID)
+		sendError(w, http.StatusInternalServerError, "Не удалось получить состояние 2FA")
+		return
+	}
+
+	sendJSON(w, http.StatusOK, status)
+}
+
+// Setup начинает настройку 2FA и возвращает секрет и otpauth:// ссылку
+// @Summary Начать настройку 2FA
+// @Tags 2fa
+// @Produce json
+// @Success 200 {object} services.TwoFactorSetup
+// @Failure 409 {object} map[string]string
+// @Router /me/2fa/setup [post]
+// @Security BearerAuth
+func (h *TwoFactorHandler) Setup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	setup, err := h.service.BeginSetup(userID)
+	if err != nil {
+		if errors.Is(err, services.ErrTwoFactorAlreadyEnabled) {
+			sendError(w, http.StatusConflict, err.Error())
+			return
+		}
+		utils.LogError(err, "Ошибка настройки 2FA", "userID", userID)
+		sendError(w, http.StatusInternalServerError, "Не удалось начать настройку 2FA")
+		return
+	}
+
+	sendJSON(w, http.StatusOK, setup)
+}
+
+// Confirm включает 2FA по первому коду из приложения и возвращает коды восстановления
+// @Summary Подтвердить включение 2FA
+// @Tags 2fa
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string][]string
+// @Failure 400 {object} map[string]string
+// @Router /me/2fa/confirm [post]
+// @Security BearerAuth
+func (h *TwoFactorHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	var requestData struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		sendError(w, http.StatusBadRequest, "Неверный формат JSON")
+		return
+	}
+
+	codes, err := h.service.Confirm(userID, requestData.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidTwoFactorCode), errors.Is(err, services.ErrTwoFactorSetupNotStarted):
+			sendError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrTwoFactorAlreadyEnabled):
+			sendError(w, http.StatusConflict, err.Error())
+		default:
+			utils.LogError(err, "Ошибка подтверждения 2FA", "userID", userID)
+			sendError(w, http.StatusInternalServerError, "Не удалось включить 2FA")
+		}
+		return
+	}
+
+	utils.LogInfo("2FA включена", "userID", userID)
+	sendJSON(w, http.StatusOK, map[string][]string{"recoveryCodes": codes})
+}
+
+// Disable выключает 2FA (требуется текущий пароль)
+// @Summary Выключить 2FA
+// @Tags 2fa
+// @Accept json
+// @Success 204
+// @Failure 401 {object} map[string]string
+// @Router /me/2fa/disable [post]
+// @Security BearerAuth
+func (h *TwoFactorHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+		return
+	}
+
+	userID, err := getUserID(r)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+		return
+	}
+
+	var requestData struct {
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		sendError(w, http.StatusBadRequest, "Неверный формат JSON")
+		return
+	}
+	password := strings.TrimSpace(requestData.Password)
+	if password == "" {
+		sendError(w, http.StatusBadRequest, "Укажи текущий пароль")
+		return
+	}
+
+	if err := h.service.Disable(userID, password); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidPassword):
+			sendError(w, http.StatusUnauthorized, err.Error())
+		case errors.Is(err, services.ErrTwoFactorNotEnabled):
+			sendError(w, http.StatusBadRequest, err.Error())
+		default:
+			utils.LogError(err, "Ошибка выключения 2FA", "userID", userID)
+			sendError(w, http.StatusInternalServerError, "Не удалось выключить 2FA")
+		}
+		return
+	}
+
+	utils.LogInfo("2FA выключена", "userID", userID)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,19 @@
+package mailer
+
+import (
+	"log"
+)
+
+// LogMailer не отправляет письма, а пишет их в лог (для разработки)
+type LogMailer struct{}
+
+// NewLogMailer создаёт LogMailer
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+// Send выводит письмо в лог
+func (m *LogMailer) Send(msg Message) error {
+	log.Printf("[mail] Кому: %s | Тема: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
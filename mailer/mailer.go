@@ -0,0 +1,36 @@
+// Пакет mailer отвечает за отправку писем пользователям.
+// Конкретная реализация выбирается через MAIL_DRIVER: "smtp" или "log".
+package mailer
+
+import (
+	"fmt"
+
+	"server_new/config"
+)
+
+// Message — письмо, которое нужно отправить
+type Message struct {
+	To      string
+	Subject string
+	Body    string // текст письма (text/plain)
+}
+
+// Mailer — интерфейс отправки писем
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// New создаёт Mailer в соответствии с конфигурацией
+func New() (Mailer, error) {
+	switch config.MailDriver {
+	case "", "log":
+		return NewLogMailer(), nil
+	case "smtp":
+		return NewSMTPMailer(config.SMTPHost, config.SMTPPort, config.SMTPUsername, config.SMTPPassword, config.MailFrom), nil
+	default:
+		return nil, fmt.Errorf("неизвестный MAIL_DRIVER: %s", config.MailDriver)
+	}
+}
+
+// Default — почтовый сервис приложения, задаётся в main после загрузки конфигурации
+var Default Mailer = NewLogMailer()
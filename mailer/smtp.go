@@ -0,0 +1,67 @@
+package mailer
+
+import (
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPMailer отправляет письма через SMTP сервер.
+// Для локальной разработки можно направить его на MailHog (localhost:1025) без логина и пароля.
+type SMTPMailer struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+// NewSMTPMailer создаёт SMTPMailer
+func NewSMTPMailer(host, port, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+// Send отправляет письмо
+func (m *SMTPMailer) Send(msg Message) error {
+	// Защита от подстановки заголовков через адрес или тему
+	if strings.ContainsAny(msg.To+msg.Subject, "\r\n") {
+		return fmt.Errorf("недопустимые символы в адресе или теме письма")
+	}
+
+	addr := net.JoinHostPort(m.host, m.port)
+
+	// Авторизуемся только если заданы логин и пароль (MailHog авторизацию не требует)
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	if err := smtp.SendMail(addr, auth, m.from, []string{msg.To}, m.buildMessage(msg)); err != nil {
+		return fmt.Errorf("не удалось отправить письмо через %s: %v", addr, err)
+	}
+	return nil
+}
+
+// buildMessage формирует письмо в формате RFC 5322
+func (m *SMTPMailer) buildMessage(msg Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + m.from + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -2,8 +2,8 @@ package main
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -17,8 +17,10 @@ import (
 
 	"server_new/config"     // наш пакет с конфигурацией БД
 	"server_new/handlers"   // обработчики запросов
+	"server_new/mailer"     // отправка писем
 	"server_new/middleware" // middleware для аутентификации
 	"server_new/models"     // модели данных
+	"server_new/services"   // бизнес-логика
 	"server_new/utils"      // утилиты для работы с токенами и паролями
 )
 
@@ -26,15 +28,6 @@ type InfoResponse struct {
 	Message string `json:"message"`
 }
 
-// Структура для задачи (соответствует таблице в БД)
-type Task struct {
-	ID          int    `json:"id"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Status      string `json:"status"`
-	UserID      int    `json:"userid"` // новое поле для ID пользователя
-}
-
 // Структура для ошибки
 type ErrorResponse struct {
 	Error string `json:"error"`
@@ -50,6 +43,17 @@ func infoHandler(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, `{"message": "Сервер работает на порту %s"}`, config.Port)
 }
 
+// Обработчик для маршрута GET /.well-known/jwks.json: открытые ключи для проверки
+// наших JWT другими сервисами (при подписи HMAC секретом список пуст)
+func jwksHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+		return
+	}
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	sendJSON(w, http.StatusOK, map[string]interface{}{"keys": utils.PublicJWKS()})
+}
+
 // Обработчик для маршрута POST /auth/register
 func registerHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -91,6 +95,14 @@ func registerHandler(w http.ResponseWriter, r *http.Request) {
 	cleanUsername := strings.TrimSpace(requestData.Username)
 	rawPassword := strings.TrimSpace(requestData.Password)
 
+	// Ограничиваем число регистраций на один email и с одного IP: учитывается каждая попытка
+	clientIP := middleware.ClientIP(r)
+	guardKeys := []string{services.RegistrationAccountKey(normalizedEmail), services.RegistrationIPKey(clientIP)}
+	if guardLocked(w, "Слишком много попыток регистрации. Попробуйте позже", guardKeys...) {
+		return
+	}
+	registerLoginFailure(w, clientIP, guardKeys...)
+
 	// Создаём хэш пароля
 	hashedPassword, err := utils.HashPassword(rawPassword)
 	if err != nil {
@@ -123,12 +135,19 @@ func registerHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Отправляем письмо для подтверждения email (аккаунт создан в неподтверждённом состоянии)
+	if err := services.NewEmailVerificationService().SendVerification(int(id), normalizedEmail); err != nil {
+		log.Printf("Ошибка отправки письма подтверждения: %v", err)
+	}
+
 	// Отправляем ответ без пароля
 	response := models.UserResponse{
-		ID:        int(id),
-		Username:  cleanUsername,
-		Email:     normalizedEmail,
-		CreatedAt: time.Now(),
+		ID:            int(id),
+		Username:      cleanUsername,
+		Email:         normalizedEmail,
+		CreatedAt:     time.Now(),
+		EmailVerified: false,
+		Role:          models.RoleUser,
 	}
 
 	sendJSON(w, http.StatusCreated, response)
@@ -142,8 +161,9 @@ func loginHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var requestData struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email     string `json:"email"`
+		Password  string `json:"password"`
+		UseCookie bool   `json:"useCookie"` // выдать токены в HttpOnly cookie (для веб-клиента)
 	}
 
 	// Читаем и парсим JSON
@@ -162,110 +182,202 @@ func loginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Защита от перебора: счётчики неудачных попыток по аккаунту и по IP
+	clientIP := middleware.ClientIP(r)
+	accountKey := services.AccountKey(normalizedEmail)
+	if loginLocked(w, accountKey, services.IPKey(clientIP)) {
+		return
+	}
+
 	// Ищем пользователя по email
 	var user models.User
 	err = config.DB.QueryRow(
-		"SELECT id, username, email, password, created_at FROM users WHERE email = ?",
+		"SELECT id, username, email, password, created_at, email_verified_at IS NOT NULL, role, disabled_at IS NOT NULL FROM users WHERE email = ?",
 		normalizedEmail,
-	).Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.CreatedAt)
+	).Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.CreatedAt, &user.EmailVerified, &user.Role, &user.Disabled)
 
-	if err != nil {
-		// Пользователь не найден или ошибка БД
+	// Пользователь не найден, ошибка БД или неверный пароль — ответ одинаковый
+	if err != nil || !utils.CheckPassword(rawPassword, user.Password) {
+		registerLoginFailure(w, clientIP, accountKey, services.IPKey(clientIP))
 		sendError(w, http.StatusBadRequest, "Неверные email или пароль")
 		return
 	}
 
-	// Проверяем пароль
-	if !utils.CheckPassword(rawPassword, user.Password) {
-		sendError(w, http.StatusBadRequest, "Неверные email или пароль")
+	// Отключённый администратором аккаунт не может войти даже с верным паролем
+	if user.Disabled {
+		sendError(w, http.StatusForbidden, services.ErrAccountDisabled.Error())
+		return
+	}
+
+	// При строгой политике неподтверждённые пользователи не могут войти
+	if !user.EmailVerified && config.UnverifiedPolicy == config.UnverifiedPolicyBlockLogin {
+		sendError(w, http.StatusForbidden, "Подтвердите email, чтобы войти. Письмо можно запросить повторно")
 		return
 	}
 
-	// Создаём JWT токен
-	token, err := utils.GenerateToken(user.ID)
+	// Если включена 2FA, выдаём только промежуточный токен: настоящие токены — после ввода кода
+	twoFactorEnabled, err := services.NewTwoFactorService().IsEnabled(user.ID)
 	if err != nil {
-		log.Printf("Ошибка создания токена: %v", err)
-		sendError(w, http.StatusInternalServerError, "Не удалось создать токен")
+		log.Printf("Ошибка проверки 2FA: %v", err)
+		sendError(w, http.StatusInternalServerError, "Не удалось выполнить вход")
+		return
+	}
+	if twoFactorEnabled {
+		pendingToken, err := utils.GenerateTwoFactorToken(user.ID)
+		if err != nil {
+			log.Printf("Ошибка создания токена 2FA: %v", err)
+			sendError(w, http.StatusInternalServerError, "Не удалось создать токен")
+			return
+		}
+		sendJSON(w, http.StatusOK, map[string]interface{}{
+			"twoFactorRequired": true,
+			"twoFactorToken":    pendingToken,
+		})
 		return
 	}
 
-	// Отправляем ответ с токеном и данными пользователя
-	response := map[string]interface{}{
-		"jwt": token,
-		"user": models.UserResponse{
-			ID:        user.ID,
-			Username:  user.Username,
-			Email:     user.Email,
-			CreatedAt: user.CreatedAt,
-		},
+	if err := services.NewLoginGuardService().RegisterSuccess(accountKey); err != nil {
+		log.Printf("Ошибка сброса счётчика попыток: %v", err)
 	}
 
-	sendJSON(w, http.StatusOK, response)
+	sendLoginResponse(w, r, user, requestData.UseCookie)
+}
+
+// loginLocked отвечает 429 с Retry-After, если вход по одному из ключей заблокирован
+func loginLocked(w http.ResponseWriter, keys ...string) bool {
+	return guardLocked(w, "Слишком много неудачных попыток входа. Попробуйте позже", keys...)
 }
 
-// Обработчик для маршрута POST /auth/reset-simple
-func resetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+// guardLocked отвечает 429 с Retry-After и сообщением message, если один из ключей заблокирован
+func guardLocked(w http.ResponseWriter, message string, keys ...string) bool {
+	wait, err := services.NewLoginGuardService().LockedFor(keys...)
+	if err != nil {
+		log.Printf("Ошибка проверки блокировки: %v", err)
+		sendError(w, http.StatusInternalServerError, "Не удалось выполнить запрос")
+		return true
+	}
+	if wait > 0 {
+		middleware.SetRetryAfter(w, wait)
+		sendError(w, http.StatusTooManyRequests, message)
+		return true
+	}
+	return false
+}
+
+// registerLoginFailure учитывает неудачную попытку входа (или регистрацию). Если после неё
+// ключ заблокирован, сразу выставляет Retry-After, чтобы клиент знал, сколько ждать.
+func registerLoginFailure(w http.ResponseWriter, ip string, keys ...string) {
+	lock, err := services.NewLoginGuardService().RegisterFailure(ip, keys...)
+	if err != nil {
+		log.Printf("Ошибка учёта попытки: %v", err)
+		return
+	}
+	if lock > 0 {
+		middleware.SetRetryAfter(w, lock)
+	}
+}
+
+// Обработчик для маршрута POST /auth/2fa/verify (второй шаг входа при включённой 2FA)
+func loginTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
 		return
 	}
 
 	var requestData struct {
-		Email       string `json:"email"`
-		NewPassword string `json:"newPassword"`
+		TwoFactorToken string `json:"twoFactorToken"`
+		Code           string `json:"code"` // код из приложения или код восстановления
+		UseCookie      bool   `json:"useCookie"`
 	}
 
-	err := json.NewDecoder(r.Body).Decode(&requestData)
-	if err != nil {
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
 		sendError(w, http.StatusBadRequest, "Неверный формат JSON")
 		return
 	}
 
-	normalizedEmail := strings.TrimSpace(strings.ToLower(requestData.Email))
-	newPassword := strings.TrimSpace(requestData.NewPassword)
-
-	if !strings.Contains(normalizedEmail, "@") {
-		sendError(w, http.StatusBadRequest, "Укажи корректный email")
+	userID, err := utils.ParseTwoFactorToken(requestData.TwoFactorToken)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Токен 2FA недействителен или истёк, войдите заново")
 		return
 	}
 
-	if len(newPassword) < 6 {
-		sendError(w, http.StatusBadRequest, "Новый пароль должен быть не короче 6 символов")
+	var user models.User
+	err = config.DB.QueryRow(
+		"SELECT id, username, email, created_at, email_verified_at IS NOT NULL, role, disabled_at IS NOT NULL FROM users WHERE id = ?",
+		userID,
+	).Scan(&user.ID, &user.Username, &user.Email, &user.CreatedAt, &user.EmailVerified, &user.Role, &user.Disabled)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Пользователь не найден")
+		return
+	}
+	if user.Disabled {
+		sendError(w, http.StatusForbidden, services.ErrAccountDisabled.Error())
 		return
 	}
 
-	// Проверяем, существует ли пользователь
-	var userID int
-	err = config.DB.QueryRow(
-		"SELECT id FROM users WHERE email = ?",
-		normalizedEmail,
-	).Scan(&userID)
-
-	if err != nil {
-		sendError(w, http.StatusNotFound, "Пользователь не найден")
+	// Перебор кодов 2FA учитывается в тех же счётчиках, что и перебор паролей
+	clientIP := middleware.ClientIP(r)
+	accountKey := services.AccountKey(user.Email)
+	if loginLocked(w, accountKey, services.IPKey(clientIP)) {
 		return
 	}
 
-	// Хэшируем новый пароль
-	hashedPassword, err := utils.HashPassword(newPassword)
-	if err != nil {
-		log.Printf("Ошибка хэширования пароля: %v", err)
-		sendError(w, http.StatusInternalServerError, "Не удалось обновить пароль")
+	if err := services.NewTwoFactorService().Verify(userID, requestData.Code); err != nil {
+		if errors.Is(err, services.ErrInvalidTwoFactorCode) || errors.Is(err, services.ErrTwoFactorNotEnabled) {
+			registerLoginFailure(w, clientIP, accountKey, services.IPKey(clientIP))
+			sendError(w, http.StatusUnauthorized, services.ErrInvalidTwoFactorCode.Error())
+			return
+		}
+		log.Printf("Ошибка проверки кода 2FA: %v", err)
+		sendError(w, http.StatusInternalServerError, "Не удалось выполнить вход")
 		return
 	}
 
-	// Обновляем пароль
-	_, err = config.DB.Exec(
-		"UPDATE users SET password = ? WHERE id = ?",
-		hashedPassword, userID,
-	)
+	if err := services.NewLoginGuardService().RegisterSuccess(accountKey); err != nil {
+		log.Printf("Ошибка сброса счётчика попыток: %v", err)
+	}
+
+	sendLoginResponse(w, r, user, requestData.UseCookie)
+}
+
+// sendLoginResponse создаёт сессию с токенами и отправляет их вместе с данными пользователя.
+// В режиме cookie токены не попадают в тело ответа и недоступны JavaScript.
+func sendLoginResponse(w http.ResponseWriter, r *http.Request, user models.User, useCookie bool) {
+	// Создаём access (JWT) и refresh токены
+	tokens, err := services.NewAuthService().IssueTokens(user.ID, r.UserAgent(), middleware.ClientIP(r))
 	if err != nil {
-		log.Printf("Ошибка обновления пароля: %v", err)
-		sendError(w, http.StatusInternalServerError, "Не удалось обновить пароль")
+		log.Printf("Ошибка создания токена: %v", err)
+		sendError(w, http.StatusInternalServerError, "Не удалось создать токен")
 		return
 	}
 
-	sendJSON(w, http.StatusOK, map[string]bool{"success": true})
+	// Отправляем ответ с токенами и данными пользователя
+	response := map[string]interface{}{
+		"expiresIn": tokens.ExpiresIn,
+		"user": models.UserResponse{
+			ID:            user.ID,
+			Username:      user.Username,
+			Email:         user.Email,
+			CreatedAt:     user.CreatedAt,
+			EmailVerified: user.EmailVerified,
+			Role:          user.Role,
+		},
+	}
+
+	if useCookie && config.CookieAuth {
+		csrfToken, err := middleware.SetAuthCookies(w, tokens.AccessToken, tokens.RefreshToken)
+		if err != nil {
+			log.Printf("Ошибка создания CSRF токена: %v", err)
+			sendError(w, http.StatusInternalServerError, "Не удалось создать токен")
+			return
+		}
+		response["csrfToken"] = csrfToken
+	} else {
+		response["jwt"] = tokens.AccessToken
+		response["refreshToken"] = tokens.RefreshToken
+	}
+
+	sendJSON(w, http.StatusOK, response)
 }
 
 // Обработчик для маршрута GET /me (защищённый)
@@ -286,9 +398,9 @@ func meHandler(w http.ResponseWriter, r *http.Request) {
 	// Получаем данные пользователя из базы
 	var user models.User
 	err = config.DB.QueryRow(
-		"SELECT id, username, email, created_at FROM users WHERE id = ?",
+		"SELECT id, username, email, created_at, email_verified_at IS NOT NULL, role FROM users WHERE id = ?",
 		userID,
-	).Scan(&user.ID, &user.Username, &user.Email, &user.CreatedAt)
+	).Scan(&user.ID, &user.Username, &user.Email, &user.CreatedAt, &user.EmailVerified, &user.Role)
 
 	if err != nil {
 		sendError(w, http.StatusNotFound, "Пользователь не найден")
@@ -297,10 +409,12 @@ func meHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Отправляем ответ без пароля
 	response := models.UserResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt,
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		CreatedAt:     user.CreatedAt,
+		EmailVerified: user.EmailVerified,
+		Role:          user.Role,
 	}
 
 	sendJSON(w, http.StatusOK, response)
@@ -346,16 +460,16 @@ func mePutHandler(w http.ResponseWriter, r *http.Request) {
 	updates := []string{}
 	params := []interface{}{}
 
-	// Смена email
+	// Смена email: адрес изменится только после подтверждения по ссылке из письма
+	pendingEmail := ""
 	if requestData.Email != "" {
 		newEmail := strings.TrimSpace(strings.ToLower(requestData.Email))
 		if newEmail != user.Email {
-			if !strings.Contains(newEmail, "@") {
+			if !utils.ValidateEmail(newEmail) {
 				sendError(w, http.StatusBadRequest, "Укажи корректный email")
 				return
 			}
-			updates = append(updates, "email = ?")
-			params = append(params, newEmail)
+			pendingEmail = newEmail
 		}
 	}
 
@@ -388,26 +502,47 @@ func mePutHandler(w http.ResponseWriter, r *http.Request) {
 		params = append(params, newHash)
 	}
 
-	if len(updates) == 0 {
+	if len(updates) == 0 && pendingEmail == "" {
 		sendError(w, http.StatusBadRequest, "Нет данных для обновления")
 		return
 	}
 
-	params = append(params, userID)
-
-	sql := fmt.Sprintf("UPDATE users SET %s WHERE id = ?", strings.Join(updates, ", "))
-	_, err = config.DB.Exec(sql, params...)
-	if err != nil {
-		if strings.Contains(err.Error(), "UNIQUE constraint") {
+	if pendingEmail != "" {
+		err := services.NewEmailVerificationService().RequestEmailChange(userID, pendingEmail)
+		var throttled *services.ResendThrottledError
+		switch {
+		case errors.Is(err, services.ErrEmailTaken):
 			sendError(w, http.StatusConflict, "Этот email уже занят")
-		} else {
+			return
+		case errors.As(err, &throttled):
+			middleware.SetRetryAfter(w, throttled.RetryAfter)
+			sendError(w, http.StatusTooManyRequests, throttled.Error())
+			return
+		case err != nil:
+			log.Printf("Ошибка запроса смены email: %v", err)
+			sendError(w, http.StatusInternalServerError, "Не удалось отправить письмо подтверждения")
+			return
+		}
+	}
+
+	if len(updates) > 0 {
+		params = append(params, userID)
+
+		sql := fmt.Sprintf("UPDATE users SET %s WHERE id = ?", strings.Join(updates, ", "))
+		_, err = config.DB.Exec(sql, params...)
+		if err != nil {
 			log.Printf("Ошибка обновления профиля: %v", err)
 			sendError(w, http.StatusInternalServerError, "Не удалось обновить профиль")
+			return
 		}
-		return
 	}
 
-	sendJSON(w, http.StatusOK, map[string]bool{"success": true})
+	response := map[string]interface{}{"success": true}
+	if pendingEmail != "" {
+		// Новый адрес ждёт подтверждения
+		response["pendingEmail"] = pendingEmail
+	}
+	sendJSON(w, http.StatusOK, response)
 }
 
 // Обработчик для маршрута DELETE /me (удаление аккаунта)
@@ -423,29 +558,17 @@ func meDeleteHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Удаляем задачи пользователя (благодаря CASCADE это произойдёт автоматически,
-	// но лучше сделать явно для ясности)
-	_, err = config.DB.Exec("DELETE FROM tasks WHERE userid = ?", userID)
-	if err != nil {
-		log.Printf("Ошибка удаления задач: %v", err)
-		sendError(w, http.StatusInternalServerError, "Не удалось удалить задачи пользователя")
-		return
-	}
-
-	// Удаляем пользователя
-	result, err := config.DB.Exec("DELETE FROM users WHERE id = ?", userID)
-	if err != nil {
-		log.Printf("Ошибка удаления пользователя: %v", err)
+	// Личные задачи и задачи своих проектов удаляются, задачи в чужих проектах остаются их владельцам
+	if err := services.NewAccountService().Delete(userID); err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			sendError(w, http.StatusNotFound, "Пользователь не найден")
+			return
+		}
+		log.Printf("Ошибка удаления аккаунта: %v", err)
 		sendError(w, http.StatusInternalServerError, "Не удалось удалить аккаунт")
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		sendError(w, http.StatusNotFound, "Пользователь не найден")
-		return
-	}
-
 	// Отправляем статус 204 без тела
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -464,384 +587,6 @@ func meHandlerWrapper(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// Обработчик для маршрута GET /tasks (только свои задачи)
-func tasksGetHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
-		return
-	}
-
-	// Получаем userID из запроса (установлен middleware)
-	userID, err := getUserIDFromRequest(r)
-	if err != nil {
-		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
-		return
-	}
-
-	// Ключевая часть — фильтрация по владельцу
-	rows, err := config.DB.Query(
-		"SELECT id, title, description, status, userid, created_at FROM tasks WHERE userid = ? ORDER BY id DESC",
-		userID,
-	)
-	if err != nil {
-		log.Printf("Ошибка запроса к БД: %v", err)
-		sendError(w, http.StatusInternalServerError, "Не удалось получить список задач")
-		return
-	}
-	defer rows.Close()
-
-	var tasks []Task
-	for rows.Next() {
-		var task Task
-		var createdAt string
-		err := rows.Scan(&task.ID, &task.Title, &task.Description, &task.Status, &task.UserID, &createdAt)
-		if err != nil {
-			log.Printf("Ошибка чтения строки: %v", err)
-			continue
-		}
-		tasks = append(tasks, task)
-	}
-
-	if tasks == nil {
-		tasks = []Task{}
-	}
-
-	sendJSON(w, http.StatusOK, tasks)
-}
-
-// Обработчик для маршрута POST /tasks (создать задачу текущему пользователю)
-func tasksPostHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
-		return
-	}
-
-	// Получаем userID из запроса
-	userID, err := getUserIDFromRequest(r)
-	if err != nil {
-		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
-		return
-	}
-
-	// Проверяем заголовок Content-Type
-	contentType := r.Header.Get("Content-Type")
-	if !strings.Contains(contentType, "application/json") {
-		sendError(w, http.StatusBadRequest, "Ожидается Content-Type: application/json")
-		return
-	}
-
-	// Структура для входящих данных
-	var requestData struct {
-		Title       string `json:"title"`
-		Description string `json:"description"` // новое поле
-		Status      string `json:"status"`      // новое поле
-	}
-
-	// Читаем тело запроса и парсим JSON
-	err = json.NewDecoder(r.Body).Decode(&requestData)
-	if err != nil {
-		middleware.SendValidationError(w, "Неверный формат JSON")
-		return
-	}
-
-	// Валидация title
-	if ok, msg := utils.ValidateTaskTitle(requestData.Title); !ok {
-		middleware.SendValidationError(w, msg)
-		return
-	}
-
-	// Устанавливаем значения по умолчанию
-	description := strings.TrimSpace(requestData.Description)
-	status := strings.TrimSpace(requestData.Status)
-	if status == "" {
-		status = "pending" // по умолчанию
-	}
-
-	// Валидация status (если передан)
-	if requestData.Status != "" && !utils.ValidateTaskStatus(requestData.Status) {
-		middleware.SendValidationError(w, "Статус должен быть: pending, in_progress или completed")
-		return
-	}
-
-	title := strings.TrimSpace(requestData.Title)
-
-	// Сохраняем задачу с userid текущего пользователя
-	result, err := config.DB.Exec(
-		"INSERT INTO tasks (title, description, status, userid) VALUES (?, ?, ?, ?)",
-		title, description, status, userID,
-	)
-	if err != nil {
-		log.Printf("Ошибка вставки в БД: %v", err)
-		sendError(w, http.StatusInternalServerError, "Не удалось создать задачу")
-		return
-	}
-
-	// Получаем ID созданной задачи
-	id, err := result.LastInsertId()
-	if err != nil {
-		log.Printf("Ошибка получения ID: %v", err)
-		sendError(w, http.StatusInternalServerError, "Не удалось получить ID задачи")
-		return
-	}
-
-	// Создаём ответ
-	newTask := Task{
-		ID:          int(id),
-		Title:       title,
-		Description: description,
-		Status:      status,
-		UserID:      userID,
-	}
-
-	// Отправляем ответ со статусом 201 (Created)
-	sendJSON(w, http.StatusCreated, newTask)
-}
-
-// Обработчик для маршрута GET /tasks/:id (только своя задача)
-func taskGetByIDHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
-		return
-	}
-
-	userID, err := getUserIDFromRequest(r)
-	if err != nil {
-		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
-		return
-	}
-
-	// Извлекаем ID из пути
-	path := r.URL.Path
-	parts := strings.Split(path, "/")
-	if len(parts) < 3 {
-		sendError(w, http.StatusBadRequest, "Неверный формат пути")
-		return
-	}
-
-	taskID, err := strconv.Atoi(parts[2])
-	if err != nil {
-		sendError(w, http.StatusBadRequest, "ID должен быть числом")
-		return
-	}
-
-	// Ищем задачу по ID И userid (важно: проверяем владельца!)
-	var task Task
-	var createdAt string
-	err = config.DB.QueryRow(
-		"SELECT id, title, description, status, userid, created_at FROM tasks WHERE id = ? AND userid = ?",
-		taskID, userID,
-	).Scan(&task.ID, &task.Title, &task.Description, &task.Status, &task.UserID, &createdAt)
-
-	if err != nil {
-		if err == sql.ErrNoRows {
-			sendError(w, http.StatusNotFound, "Задача не найдена")
-		} else {
-			log.Printf("Ошибка запроса к БД: %v", err)
-			sendError(w, http.StatusInternalServerError, "Не удалось получить задачу")
-		}
-		return
-	}
-
-	sendJSON(w, http.StatusOK, task)
-}
-
-// Обработчик для маршрута PUT /tasks/:id (обновить только свою задачу)
-func taskPutHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPut {
-		sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
-		return
-	}
-
-	userID, err := getUserIDFromRequest(r)
-	if err != nil {
-		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
-		return
-	}
-
-	// Извлекаем ID из пути
-	path := r.URL.Path
-	parts := strings.Split(path, "/")
-	if len(parts) < 3 {
-		sendError(w, http.StatusBadRequest, "Неверный формат пути")
-		return
-	}
-
-	taskID, err := strconv.Atoi(parts[2])
-	if err != nil {
-		sendError(w, http.StatusBadRequest, "ID должен быть числом")
-		return
-	}
-
-	// Проверяем, что задача существует и принадлежит пользователю
-	var exists bool
-	err = config.DB.QueryRow(
-		"SELECT EXISTS(SELECT 1 FROM tasks WHERE id = ? AND userid = ?)",
-		taskID, userID,
-	).Scan(&exists)
-
-	if err != nil || !exists {
-		sendError(w, http.StatusNotFound, "Задача не найдена")
-		return
-	}
-
-	// Читаем данные для обновления
-	var requestData struct {
-		Title       string `json:"title"`
-		Description string `json:"description"`
-		Status      string `json:"status"`
-	}
-
-	err = json.NewDecoder(r.Body).Decode(&requestData)
-	if err != nil {
-		sendError(w, http.StatusBadRequest, "Неверный формат JSON")
-		return
-	}
-
-	// Формируем запрос на обновление
-	updates := []string{}
-	params := []interface{}{}
-
-	if requestData.Title != "" {
-		updates = append(updates, "title = ?")
-		params = append(params, strings.TrimSpace(requestData.Title))
-	}
-	if requestData.Description != "" {
-		updates = append(updates, "description = ?")
-		params = append(params, strings.TrimSpace(requestData.Description))
-	}
-	if requestData.Status != "" {
-		validStatuses := []string{"pending", "in_progress", "completed"}
-		isValid := false
-		for _, s := range validStatuses {
-			if requestData.Status == s {
-				isValid = true
-				break
-			}
-		}
-		if isValid {
-			updates = append(updates, "status = ?")
-			params = append(params, requestData.Status)
-		}
-	}
-
-	if len(updates) == 0 {
-		sendError(w, http.StatusBadRequest, "Нет данных для обновления")
-		return
-	}
-
-	// Добавляем условия WHERE
-	params = append(params, taskID, userID)
-
-	sql := fmt.Sprintf("UPDATE tasks SET %s WHERE id = ? AND userid = ?", strings.Join(updates, ", "))
-	_, err = config.DB.Exec(sql, params...)
-	if err != nil {
-		log.Printf("Ошибка обновления в БД: %v", err)
-		sendError(w, http.StatusInternalServerError, "Не удалось обновить задачу")
-		return
-	}
-
-	// Получаем обновлённую задачу
-	var task Task
-	var createdAt string
-	err = config.DB.QueryRow(
-		"SELECT id, title, description, status, userid, created_at FROM tasks WHERE id = ? AND userid = ?",
-		taskID, userID,
-	).Scan(&task.ID, &task.Title, &task.Description, &task.Status, &task.UserID, &createdAt)
-
-	if err != nil {
-		log.Printf("Ошибка получения обновлённой задачи: %v", err)
-		sendError(w, http.StatusInternalServerError, "Задача обновлена, но не удалось получить данные")
-		return
-	}
-
-	sendJSON(w, http.StatusOK, task)
-}
-
-// Обработчик для маршрута DELETE /tasks/:id (удалить только свою задачу)
-func taskDeleteHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodDelete {
-		sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
-		return
-	}
-
-	userID, err := getUserIDFromRequest(r)
-	if err != nil {
-		sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
-		return
-	}
-
-	// Извлекаем ID из пути
-	path := r.URL.Path
-	parts := strings.Split(path, "/")
-	if len(parts) < 3 {
-		sendError(w, http.StatusBadRequest, "Неверный формат пути")
-		return
-	}
-
-	taskID, err := strconv.Atoi(parts[2])
-	if err != nil {
-		sendError(w, http.StatusBadRequest, "ID должен быть числом")
-		return
-	}
-
-	// Удаляем задачу (только если она принадлежит пользователю)
-	result, err := config.DB.Exec(
-		"DELETE FROM tasks WHERE id = ? AND userid = ?",
-		taskID, userID,
-	)
-	if err != nil {
-		log.Printf("Ошибка удаления из БД: %v", err)
-		sendError(w, http.StatusInternalServerError, "Не удалось удалить задачу")
-		return
-	}
-
-	// Проверяем, была ли удалена хотя бы одна строка
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		log.Printf("Ошибка проверки удалённых строк: %v", err)
-		sendError(w, http.StatusInternalServerError, "Не удалось проверить результат удаления")
-		return
-	}
-
-	if rowsAffected == 0 {
-		sendError(w, http.StatusNotFound, "Задача не найдена")
-		return
-	}
-
-	sendJSON(w, http.StatusOK, map[string]string{"message": "Задача удалена"})
-}
-
-// Главная функция для обработки всех запросов к /tasks
-func tasksHandler(w http.ResponseWriter, r *http.Request) {
-	path := r.URL.Path
-
-	// Если путь содержит ID (например, /tasks/123)
-	if strings.HasPrefix(path, "/tasks/") && len(path) > len("/tasks/") {
-		// Определяем метод и вызываем нужный обработчик
-		switch r.Method {
-		case http.MethodGet:
-			taskGetByIDHandler(w, r)
-		case http.MethodPut:
-			taskPutHandler(w, r)
-		case http.MethodDelete:
-			taskDeleteHandler(w, r)
-		default:
-			sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
-		}
-		return
-	}
-
-	// Если путь просто /tasks
-	switch r.Method {
-	case http.MethodGet:
-		tasksGetHandler(w, r)
-	case http.MethodPost:
-		tasksPostHandler(w, r)
-	default:
-		sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
-	}
-}
-
 // Вспомогательная функция для получения userID из запроса
 func getUserIDFromRequest(r *http.Request) (int, error) {
 	userIDStr := r.Header.Get("X-User-ID")
@@ -871,13 +616,24 @@ func main() {
 	// Инициализируем логирование
 	utils.InitLogger()
 
+	// Служебные команды (например, create-admin) выполняются вместо запуска сервера
+	if runCommand(os.Args[1:]) {
+		return
+	}
+
 	// Загружаем конфигурацию
 	if err := config.Load(); err != nil {
 		utils.LogError(err, "Ошибка загрузки конфигурации")
 		log.Fatal("Ошибка загрузки конфигурации:", err)
 	}
 
-	utils.LogInfo("Запуск сервера", "port", config.Port)
+	utils.LogInfo("Запуск сервера", "port", config.Port, "env", config.AppEnv)
+
+	// Загружаем ключи подписи JWT (HMAC секрет или PEM файлы)
+	if err := utils.LoadKeys(); err != nil {
+		utils.LogError(err, "Ошибка загрузки ключей JWT")
+		log.Fatal("Ошибка загрузки ключей JWT:", err)
+	}
 
 	// Инициализируем базу данных
 	if err := config.InitDB(); err != nil {
@@ -886,7 +642,27 @@ func main() {
 	}
 	defer config.CloseDB()
 
+	// Настраиваем отправку писем (SMTP или вывод в лог)
+	m, err := mailer.New()
+	if err != nil {
+		utils.LogError(err, "Ошибка настройки почты")
+		log.Fatal("Ошибка настройки почты:", err)
+	}
+	mailer.Default = m
+
 	tasksHandlerNew := handlers.NewTasksHandler()
+	passwordResetHandler := handlers.NewPasswordResetHandler()
+	authHandler := handlers.NewAuthHandler()
+	sessionsHandler := handlers.NewSessionsHandler()
+	twoFactorHandler := handlers.NewTwoFactorHandler()
+	emailVerificationHandler := handlers.NewEmailVerificationHandler()
+	adminHandler := handlers.NewAdminHandler()
+	apiKeysHandler := handlers.NewAPIKeysHandler()
+	tagsHandler := handlers.NewTagsHandler()
+	projectsHandler := handlers.NewProjectsHandler()
+	invitationsHandler := handlers.NewInvitationsHandler()
+	notificationsHandler := handlers.NewNotificationsHandler()
+	savedViewsHandler := handlers.NewSavedViewsHandler()
 
 	// Используем порт из конфигурации
 	port := config.Port
@@ -894,11 +670,21 @@ func main() {
 	// Используем разрешённые origins из конфигурации
 	allowedOrigins := config.AllowedOrigins
 
+	// Общий лимит запросов с одного IP для входа, регистрации и сброса пароля
+	authRateLimit := middleware.RateLimit(middleware.NewRateLimiter(config.AuthRateLimit, config.AuthRateWindow))
+
 	// Регистрируем маршруты с CORS middleware (API маршруты регистрируются первыми)
 	http.HandleFunc("/info", middleware.CORS(allowedOrigins)(infoHandler))
-	http.HandleFunc("/auth/register", middleware.CORS(allowedOrigins)(registerHandler))
-	http.HandleFunc("/auth/login", middleware.CORS(allowedOrigins)(loginHandler))
-	http.HandleFunc("/auth/reset-simple", middleware.CORS(allowedOrigins)(resetPasswordHandler))
+	http.HandleFunc("/.well-known/jwks.json", middleware.CORS(allowedOrigins)(jwksHandler))
+	http.HandleFunc("/auth/register", middleware.CORS(allowedOrigins)(authRateLimit(registerHandler)))
+	http.HandleFunc("/auth/login", middleware.CORS(allowedOrigins)(authRateLimit(loginHandler)))
+	http.HandleFunc("/auth/verify", middleware.CORS(allowedOrigins)(emailVerificationHandler.Verify))
+	http.HandleFunc("/auth/verify/resend", middleware.CORS(allowedOrigins)(authRateLimit(emailVerificationHandler.Resend)))
+	http.HandleFunc("/auth/2fa/verify", middleware.CORS(allowedOrigins)(authRateLimit(loginTwoFactorHandler)))
+	http.HandleFunc("/auth/refresh", middleware.CORS(allowedOrigins)(authHandler.Refresh))
+	http.HandleFunc("/auth/logout", middleware.CORS(allowedOrigins)(authHandler.Logout))
+	http.HandleFunc("/auth/password-reset/request", middleware.CORS(allowedOrigins)(authRateLimit(passwordResetHandler.Request)))
+	http.HandleFunc("/auth/password-reset/confirm", middleware.CORS(allowedOrigins)(authRateLimit(passwordResetHandler.Confirm)))
 
 	// Маршрут /me с разными методами
 	http.HandleFunc("/me", middleware.CORS(allowedOrigins)(func(w http.ResponseWriter, r *http.Request) {
@@ -917,8 +703,57 @@ func main() {
 		}
 	}))
 
+	// Сессии пользователя: список, завершение одной или всех
+	http.HandleFunc("/me/sessions", middleware.CORS(allowedOrigins)(middleware.Authenticate(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			sessionsHandler.List(w, r)
+		case http.MethodDelete:
+			sessionsHandler.RevokeAll(w, r)
+		default:
+			sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+		}
+	})))
+	http.HandleFunc("/me/sessions/", middleware.CORS(allowedOrigins)(middleware.Authenticate(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+			return
+		}
+		sessionsHandler.Revoke(w, r)
+	})))
+
+	// Двухфакторная аутентификация
+	http.HandleFunc("/me/2fa", middleware.CORS(allowedOrigins)(middleware.Authenticate(twoFactorHandler.Status)))
+	http.HandleFunc("/me/2fa/setup", middleware.CORS(allowedOrigins)(middleware.Authenticate(twoFactorHandler.Setup)))
+	http.HandleFunc("/me/2fa/confirm", middleware.CORS(allowedOrigins)(middleware.Authenticate(twoFactorHandler.Confirm)))
+	http.HandleFunc("/me/2fa/disable", middleware.CORS(allowedOrigins)(middleware.Authenticate(twoFactorHandler.Disable)))
+
+	// Персональные API ключи
+	http.HandleFunc("/me/api-keys", middleware.CORS(allowedOrigins)(middleware.Authenticate(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			apiKeysHandler.List(w, r)
+		case http.MethodPost:
+			apiKeysHandler.Create(w, r)
+		default:
+			sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+		}
+	})))
+	http.HandleFunc("/me/api-keys/", middleware.CORS(allowedOrigins)(middleware.Authenticate(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+			return
+		}
+		apiKeysHandler.Revoke(w, r)
+	})))
+
+	// Администрирование: только для пользователей с ролью admin
+	requireAdmin := middleware.RequireRole(models.RoleAdmin)
+	http.HandleFunc("/admin/users", middleware.CORS(allowedOrigins)(middleware.Authenticate(requireAdmin(adminHandler.ListUsers))))
+	http.HandleFunc("/admin/users/", middleware.CORS(allowedOrigins)(middleware.Authenticate(requireAdmin(adminHandler.User))))
+
 	// Регистрируем маршруты для задач с использованием handlers
-	http.HandleFunc("/tasks", middleware.CORS(allowedOrigins)(middleware.Authenticate(func(w http.ResponseWriter, r *http.Request) {
+	http.HandleFunc("/tasks", middleware.CORS(allowedOrigins)(middleware.Authenticate(middleware.RequireVerifiedEmail(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			tasksHandlerNew.GetTasks(w, r)
@@ -927,11 +762,61 @@ func main() {
 		default:
 			http.Error(w, "Метод не разрешён", http.StatusMethodNotAllowed)
 		}
-	})))
+	}))))
 
 	// Маршрут /tasks/ для операций с конкретной задачей (GET, PUT, DELETE по ID)
-	// Используем старую функцию tasksHandler для обработки запросов к /tasks/:id
-	http.HandleFunc("/tasks/", middleware.CORS(allowedOrigins)(middleware.Authenticate(tasksHandler)))
+	http.HandleFunc("/tasks/", middleware.CORS(allowedOrigins)(middleware.Authenticate(middleware.RequireVerifiedEmail(tasksHandlerNew.Task))))
+
+	// Метки задач
+	http.HandleFunc("/tags", middleware.CORS(allowedOrigins)(middleware.Authenticate(middleware.RequireVerifiedEmail(tagsHandler.Tags))))
+	http.HandleFunc("/tags/", middleware.CORS(allowedOrigins)(middleware.Authenticate(middleware.RequireVerifiedEmail(tagsHandler.Tag))))
+
+	// Проекты (списки задач)
+	http.HandleFunc("/projects", middleware.CORS(allowedOrigins)(middleware.Authenticate(middleware.RequireVerifiedEmail(projectsHandler.Projects))))
+	http.HandleFunc("/projects/", middleware.CORS(allowedOrigins)(middleware.Authenticate(middleware.RequireVerifiedEmail(projectsHandler.Project))))
+
+	// Приглашения в общие проекты
+	http.HandleFunc("/me/invitations", middleware.CORS(allowedOrigins)(middleware.Authenticate(middleware.RequireVerifiedEmail(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+			return
+		}
+		invitationsHandler.List(w, r)
+	}))))
+	http.HandleFunc("/me/invitations/", middleware.CORS(allowedOrigins)(middleware.Authenticate(middleware.RequireVerifiedEmail(invitationsHandler.Respond))))
+
+	// Задачи, где текущий пользователь — исполнитель
+	http.HandleFunc("/me/assigned", middleware.CORS(allowedOrigins)(middleware.Authenticate(middleware.RequireVerifiedEmail(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+			return
+		}
+		tasksHandlerNew.AssignedToMe(w, r)
+	}))))
+
+	// Лента изменений в задачах
+	http.HandleFunc("/me/activity", middleware.CORS(allowedOrigins)(middleware.Authenticate(middleware.RequireVerifiedEmail(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+			return
+		}
+		tasksHandlerNew.Activity(w, r)
+	}))))
+
+	// Уведомления (упоминания в комментариях)
+	http.HandleFunc("/me/notifications", middleware.CORS(allowedOrigins)(middleware.Authenticate(middleware.RequireVerifiedEmail(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			sendError(w, http.StatusMethodNotAllowed, "Метод не разрешён")
+			return
+		}
+		notificationsHandler.List(w, r)
+	}))))
+	http.HandleFunc("/me/notifications/", middleware.CORS(allowedOrigins)(middleware.Authenticate(middleware.RequireVerifiedEmail(notificationsHandler.MarkRead))))
+
+	// Сохранённые виды списка задач
+	http.HandleFunc("/me/views", middleware.CORS(allowedOrigins)(middleware.Authenticate(middleware.RequireVerifiedEmail(savedViewsHandler.Views))))
+	http.HandleFunc("/me/views/", middleware.CORS(allowedOrigins)(middleware.Authenticate(middleware.RequireVerifiedEmail(savedViewsHandler.View))))
+	http.HandleFunc("/views/", middleware.CORS(allowedOrigins)(middleware.Authenticate(middleware.RequireVerifiedEmail(savedViewsHandler.Tasks))))
 
 	// Маршрут для загрузки файлов
 	http.HandleFunc("/upload", handlers.UploadFileHandler)
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"server_new/models"
+	"server_new/services"
+	"server_new/utils"
+)
+
+// apiKeyRoute — маршрут, доступный по API ключу, и области доступа для чтения и изменения
+type apiKeyRoute struct {
+	path  string // путь или префикс пути (с завершающим "/")
+	read  string
+	write string
+}
+
+// apiKeyRoutes — куда можно ходить с API ключом. Всё остальное (профиль, сессии,
+// сами ключи, администрирование) доступно только по JWT.
+var apiKeyRoutes = []apiKeyRoute{
+	{path: "/tasks", read: models.ScopeTasksRead, write: models.ScopeTasksWrite},
+	{path: "/tasks/", read: models.ScopeTasksRead, write: models.ScopeTasksWrite},
+	{path: "/tags", read: models.ScopeTasksRead, write: models.ScopeTasksWrite},
+	{path: "/tags/", read: models.ScopeTasksRead, write: models.ScopeTasksWrite},
+	{path: "/projects", read: models.ScopeTasksRead, write: models.ScopeTasksWrite},
+	{path: "/projects/", read: models.ScopeTasksRead, write: models.ScopeTasksWrite},
+	{path: "/me/assigned", read: models.ScopeTasksRead, write: models.ScopeTasksWrite},
+	{path: "/me/activity", read: models.ScopeTasksRead, write: models.ScopeTasksWrite},
+	{path: "/me/notifications", read: models.ScopeTasksRead, write: models.ScopeTasksWrite},
+	{path: "/me/notifications/", read: models.ScopeTasksRead, write: models.ScopeTasksWrite},
+	{path: "/me/views", read: models.ScopeTasksRead, write: models.ScopeTasksWrite},
+	{path: "/me/views/", read: models.ScopeTasksRead, write: models.ScopeTasksWrite},
+	{path: "/views/", read: models.ScopeTasksRead, write: models.ScopeTasksWrite},
+}
+
+// authenticateAPIKey проверяет ключ из заголовка "Authorization: ApiKey <ключ>"
+// и то, что его областей доступа хватает для запроса
+func authenticateAPIKey(next http.HandlerFunc, w http.ResponseWriter, r *http.Request, rawKey string) {
+	principal, err := services.NewAPIKeysService().Authenticate(rawKey)
+	if err != nil {
+		if !errors.Is(err, services.ErrInvalidAPIKey) {
+			utils.LogError(err, "Ошибка проверки API ключа")
+		}
+		sendError(w, http.StatusUnauthorized, "API ключ недействителен или истёк")
+		return
+	}
+
+	scope, ok := requiredScope(r)
+	if !ok {
+		sendError(w, http.StatusForbidden, "Этот ресурс недоступен по API ключу")
+		return
+	}
+	if !principal.HasScope(scope) {
+		sendError(w, http.StatusForbidden, "У API ключа нет доступа "+scope)
+		return
+	}
+
+	// У ключа нет сессии и роли: административные маршруты по нему недоступны
+	r.Header.Set("X-User-ID", fmt.Sprintf("%d", principal.UserID))
+	r.Header.Set("X-API-Key-ID", fmt.Sprintf("%d", principal.KeyID))
+	r.Header.Del("X-Session-ID")
+	r.Header.Del("X-User-Role")
+
+	next(w, r)
+}
+
+// requiredScope определяет, какая область доступа нужна для запроса
+func requiredScope(r *http.Request) (string, bool) {
+	for _, route := range apiKeyRoutes {
+		matched := r.URL.Path == route.path
+		if strings.HasSuffix(route.path, "/") {
+			matched = strings.HasPrefix(r.URL.Path, route.path)
+		}
+		if !matched {
+			continue
+		}
+		if isSafeMethod(r.Method) {
+			return route.read, true
+		}
+		return route.write, true
+	}
+	return "", false
+}
@@ -7,42 +7,74 @@ import (
 	"net/http"
 	"strings"
 
+	"server_new/services"
 	"server_new/utils"
 )
 
-// Authenticate проверяет JWT токен и добавляет userID в контекст запроса
+// Authenticate проверяет JWT токен (из заголовка или cookie) или API ключ и добавляет userID в контекст запроса
 func Authenticate(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Извлекаем токен из заголовка Authorization
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
+			// Встроенный веб-клиент может хранить токен в HttpOnly cookie
+			if cookie, err := r.Cookie(AccessTokenCookie); err == nil && cookie.Value != "" {
+				authenticateCookie(next, w, r, cookie.Value)
+				return
+			}
 			sendError(w, http.StatusUnauthorized, "Нужен токен авторизации")
 			return
 		}
 
-		// Формат: "Bearer <токен>"
+		// Формат: "Bearer <токен>" или "ApiKey <ключ>"
 		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
+		if len(parts) != 2 || (parts[0] != "Bearer" && parts[0] != "ApiKey") {
 			sendError(w, http.StatusUnauthorized, "Неверный формат токена")
 			return
 		}
 
-		token := parts[1]
-
-		// Проверяем токен
-		userID, err := utils.ValidateToken(token)
-		if err != nil {
-			sendError(w, http.StatusUnauthorized, "Токен недействителен или истёк")
+		if parts[0] == "ApiKey" {
+			authenticateAPIKey(next, w, r, parts[1])
 			return
 		}
 
-		// Сохраняем userID в заголовке запроса (временное решение)
-		// В более продвинутых версиях можно использовать контекст
-		r.Header.Set("X-User-ID", fmt.Sprintf("%d", userID))
+		authenticateJWT(next, w, r, parts[1])
+	}
+}
+
+// authenticateCookie проверяет токен из cookie. Браузер отправляет cookie и с чужих сайтов,
+// поэтому изменяющие запросы должны подтверждаться CSRF токеном (double-submit).
+func authenticateCookie(next http.HandlerFunc, w http.ResponseWriter, r *http.Request, token string) {
+	if !isSafeMethod(r.Method) && !ValidCSRF(r) {
+		sendError(w, http.StatusForbidden, "Неверный или отсутствующий CSRF токен")
+		return
+	}
+	authenticateJWT(next, w, r, token)
+}
+
+// authenticateJWT проверяет access токен и передаёт запрос дальше
+func authenticateJWT(next http.HandlerFunc, w http.ResponseWriter, r *http.Request, token string) {
+	// Проверяем токен (включая то, что его сессия не завершена)
+	claims, err := utils.ParseToken(token)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Токен недействителен или истёк")
+		return
+	}
 
-		// Вызываем следующий обработчик
-		next(w, r)
+	// Сохраняем userID, ID сессии и роль в заголовках запроса (временное решение)
+	// В более продвинутых версиях можно использовать контекст
+	r.Header.Set("X-User-ID", fmt.Sprintf("%d", claims.UserID))
+	r.Header.Set("X-Session-ID", claims.ID)
+	r.Header.Set("X-User-Role", claims.Role)
+	r.Header.Del("X-API-Key-ID")
+
+	// Запоминаем активность сессии (устройство, IP, время)
+	if err := services.NewSessionsService().Touch(claims.ID, r.UserAgent(), ClientIP(r)); err != nil {
+		utils.LogError(err, "Не удалось обновить сессию", "sessionID", claims.ID)
 	}
+
+	// Вызываем следующий обработчик
+	next(w, r)
 }
 
 // sendError отправляет ошибку в формате JSON
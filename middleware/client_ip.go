@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"server_new/config"
+)
+
+// ClientIP возвращает IP адрес клиента без порта.
+// X-Forwarded-For учитывается только если включён TRUST_PROXY.
+func ClientIP(r *http.Request) string {
+	if config.TrustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			// Первый адрес в списке — исходный клиент
+			return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
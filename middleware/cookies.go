@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"server_new/config"
+	"server_new/utils"
+)
+
+// Имена cookie и заголовка для режима входа через cookie
+const (
+	AccessTokenCookie  = "access_token"
+	RefreshTokenCookie = "refresh_token"
+	CSRFCookie         = "csrf_token"
+	CSRFHeader         = "X-CSRF-Token"
+)
+
+// refreshCookiePath — refresh токен нужен только /auth/refresh и /auth/logout
+const refreshCookiePath = "/auth/"
+
+// SetAuthCookies кладёт токены в HttpOnly cookie и выдаёт новый CSRF токен.
+// CSRF cookie доступна JavaScript: клиент копирует её в заголовок X-CSRF-Token
+// (double-submit), а чужой сайт прочитать её не может.
+func SetAuthCookies(w http.ResponseWriter, accessToken, refreshToken string) (string, error) {
+	csrfToken, err := utils.GenerateSecureToken()
+	if err != nil {
+		return "", err
+	}
+
+	accessMaxAge := int(config.AccessTokenTTL.Seconds())
+	refreshMaxAge := int(config.RefreshTokenTTL.Seconds())
+
+	http.SetCookie(w, authCookie(AccessTokenCookie, accessToken, "/", accessMaxAge, true))
+	http.SetCookie(w, authCookie(RefreshTokenCookie, refreshToken, refreshCookiePath, refreshMaxAge, true))
+	http.SetCookie(w, authCookie(CSRFCookie, csrfToken, "/", refreshMaxAge, false))
+
+	return csrfToken, nil
+}
+
+// ClearAuthCookies удаляет cookie входа
+func ClearAuthCookies(w http.ResponseWriter) {
+	http.SetCookie(w, authCookie(AccessTokenCookie, "", "/", -1, true))
+	http.SetCookie(w, authCookie(RefreshTokenCookie, "", refreshCookiePath, -1, true))
+	http.SetCookie(w, authCookie(CSRFCookie, "", "/", -1, false))
+}
+
+// ValidCSRF проверяет, что заголовок X-CSRF-Token совпадает с CSRF cookie
+func ValidCSRF(r *http.Request) bool {
+	cookie, err := r.Cookie(CSRFCookie)
+	if err != nil || cookie.Value == "" {
+		return false
+	}
+	header := r.Header.Get(CSRFHeader)
+	return header != "" && subtle.ConstantTimeCompare([]byte(header), []byte(cookie.Value)) == 1
+}
+
+// authCookie собирает cookie с общими настройками (Secure, SameSite, домен)
+func authCookie(name, value, path string, maxAge int, httpOnly bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   config.CookieDomain,
+		MaxAge:   maxAge,
+		HttpOnly: httpOnly,
+		Secure:   config.CookieSecure,
+		SameSite: config.CookieSameSite,
+	}
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestValidCSRF(t *testing.T) {
+	tests := []struct {
+		name   string
+		cookie string
+		header string
+		want   bool
+	}{
+		{"совпадают", "abc", "abc", true},
+		{"нет заголовка", "abc", "", false},
+		{"не совпадают", "abc", "abd", false},
+		{"нет cookie", "", "abc", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/tasks", nil)
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: CSRFCookie, Value: tt.cookie})
+			}
+			if tt.header != "" {
+				r.Header.Set(CSRFHeader, tt.header)
+			}
+			if got := ValidCSRF(r); got != tt.want {
+				t.Errorf("ValidCSRF() = %v, ожидалось %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthenticate_CookieRequiresCSRF(t *testing.T) {
+	called := false
+	handler := Authenticate(func(w http.ResponseWriter, r *http.Request) { called = true })
+
+	// Изменяющий запрос с cookie, но без CSRF токена отклоняется ещё до проверки токена
+	r := httptest.NewRequest(http.MethodPost, "/tasks", nil)
+	r.AddCookie(&http.Cookie{Name: AccessTokenCookie, Value: "token"})
+	w := httptest.NewRecorder()
+	handler(w, r)
+
+	if w.Code != http.StatusForbidden || called {
+		t.Errorf("Ожидался 403 без вызова обработчика, получено %d", w.Code)
+	}
+}
+
+func TestCORS_OnlyAllowedOriginsGetCredentials(t *testing.T) {
+	handler := CORS([]string{"https://app.example.com"})(func(w http.ResponseWriter, r *http.Request) {})
+
+	r := httptest.NewRequest(http.MethodGet, "/me", nil)
+	r.Header.Set("Origin", "https://evil.example.com")
+	w := httptest.NewRecorder()
+	handler(w, r)
+	if w.Header().Get("Access-Control-Allow-Credentials") != "" || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("Чужой origin не должен получать CORS заголовки")
+	}
+
+	r = httptest.NewRequest(http.MethodOptions, "/me", nil)
+	r.Header.Set("Origin", "https://evil.example.com")
+	w = httptest.NewRecorder()
+	handler(w, r)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Preflight с чужого origin: ожидался 403, получено %d", w.Code)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/me", nil)
+	r.Header.Set("Origin", "https://app.example.com")
+	w = httptest.NewRecorder()
+	handler(w, r)
+	if w.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" ||
+		w.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Error("Разрешённый origin должен получать CORS заголовки с credentials")
+	}
+}
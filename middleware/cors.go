@@ -4,44 +4,21 @@ import (
 	"net/http"
 )
 
-// CORS настраивает заголовки для междоменных запросов
+// CORS настраивает заголовки для междоменных запросов.
+// Заголовки (в том числе разрешение на credentials — cookie) получают только origin
+// из списка разрешённых: для остальных браузер не даст прочитать ответ,
+// а preflight запрос отклоняется.
 func CORS(allowedOrigins []string) func(http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
-			origin := r.Header.Get("Origin")
-
-			// Проверяем, разрешён ли origin
-			allowed := false
-			if origin == "" {
-				// Некоторые клиенты (curl, Postman) не отправляют Origin
-				allowed = true
-			} else {
-				for _, allowedOrigin := range allowedOrigins {
-					if origin == allowedOrigin {
-						allowed = true
-						break
-					}
-				}
-			}
-
-			if allowed {
-				w.Header().Set("Access-Control-Allow-Origin", origin)
-			}
-
-			// Разрешаем методы
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-
-			// Разрешаем заголовки
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-			// Разрешаем отправку credentials (куки, авторизация)
-			w.Header().Set("Access-Control-Allow-Credentials", "true")
-
-			// Кэш preflight запросов (OPTIONS) на 10 минут
-			w.Header().Set("Access-Control-Max-Age", "600")
+			allowed := setCORSHeaders(w, r, allowedOrigins)
 
 			// Если это preflight запрос (OPTIONS), сразу отвечаем
 			if r.Method == http.MethodOptions {
+				if !allowed {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
 				w.WriteHeader(http.StatusNoContent)
 				return
 			}
@@ -55,29 +32,53 @@ func CORS(allowedOrigins []string) func(http.HandlerFunc) http.HandlerFunc {
 // CORSHandler обрабатывает OPTIONS запросы
 func CORSHandler(allowedOrigins []string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		origin := r.Header.Get("Origin")
-
-		allowed := false
-		if origin == "" {
-			allowed = true
-		} else {
-			for _, allowedOrigin := range allowedOrigins {
-				if origin == allowedOrigin {
-					allowed = true
-					break
-				}
-			}
+		if !setCORSHeaders(w, r, allowedOrigins) {
+			w.WriteHeader(http.StatusForbidden)
+			return
 		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
 
-		if allowed {
-			w.Header().Set("Access-Control-Allow-Origin", origin)
-		}
+// setCORSHeaders выставляет CORS заголовки, если origin разрешён.
+// Запросы без Origin (curl, Postman, запросы с той же страницы) CORS не касаются.
+func setCORSHeaders(w http.ResponseWriter, r *http.Request, allowedOrigins []string) bool {
+	// Ответ зависит от Origin — кэши должны это учитывать
+	w.Header().Add("Vary", "Origin")
 
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		w.Header().Set("Access-Control-Allow-Credentials", "true")
-		w.Header().Set("Access-Control-Max-Age", "600")
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if !isAllowedOrigin(origin, allowedOrigins) {
+		return false
+	}
 
-		w.WriteHeader(http.StatusNoContent)
+	// Конкретный origin, а не "*": иначе браузер не отправит cookie
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+
+	// Разрешаем отправку credentials (куки, авторизация) — только для разрешённых origin
+	w.Header().Set("Access-Control-Allow-Credentials", "true")
+
+	// Разрешаем методы
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+
+	// Разрешаем заголовки
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+CSRFHeader)
+
+	// Кэш preflight запросов (OPTIONS) на 10 минут
+	w.Header().Set("Access-Control-Max-Age", "600")
+
+	return true
+}
+
+// isAllowedOrigin проверяет origin по списку (точное совпадение; "*" не поддерживается,
+// так как несовместим с credentials)
+func isAllowedOrigin(origin string, allowedOrigins []string) bool {
+	for _, allowedOrigin := range allowedOrigins {
+		if origin == allowedOrigin {
+			return true
+		}
 	}
+	return false
 }
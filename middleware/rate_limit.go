@@ -2,10 +2,14 @@ package middleware
 
 import (
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 )
 
+// maxTrackedClients — после скольких отслеживаемых адресов чистить устаревшие записи
+const maxTrackedClients = 10000
+
 type RateLimiter struct {
 	requests map[string][]time.Time
 	mu       sync.Mutex
@@ -21,13 +25,24 @@ func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
 	}
 }
 
-func (rl *RateLimiter) Allow(ip string) bool {
+// Allow учитывает запрос с ip. Если лимит исчерпан, возвращает false и время,
+// через которое освободится место в окне.
+func (rl *RateLimiter) Allow(ip string) (bool, time.Duration) {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
 	now := time.Now()
 	cutoff := now.Add(-rl.window)
 
+	// Время от времени убираем адреса, от которых давно не было запросов
+	if len(rl.requests) > maxTrackedClients {
+		for key, times := range rl.requests {
+			if len(times) == 0 || !times[len(times)-1].After(cutoff) {
+				delete(rl.requests, key)
+			}
+		}
+	}
+
 	// Удаляем старые запросы
 	validRequests := []time.Time{}
 	for _, t := range rl.requests[ip] {
@@ -37,23 +52,39 @@ func (rl *RateLimiter) Allow(ip string) bool {
 	}
 
 	if len(validRequests) >= rl.limit {
-		return false
+		rl.requests[ip] = validRequests
+		return false, validRequests[0].Add(rl.window).Sub(now)
 	}
 
 	validRequests = append(validRequests, now)
 	rl.requests[ip] = validRequests
-	return true
+	return true, 0
 }
 
 func RateLimit(limiter *RateLimiter) func(http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
-			ip := r.RemoteAddr
-			if !limiter.Allow(ip) {
-				http.Error(w, "Слишком много запросов", http.StatusTooManyRequests)
+			// Preflight запросы не считаем
+			if r.Method == http.MethodOptions {
+				next(w, r)
+				return
+			}
+
+			if ok, retryAfter := limiter.Allow(ClientIP(r)); !ok {
+				SetRetryAfter(w, retryAfter)
+				SendError(w, "Слишком много запросов", http.StatusTooManyRequests)
 				return
 			}
 			next(w, r)
 		}
 	}
 }
+
+// SetRetryAfter устанавливает заголовок Retry-After в секундах (с округлением вверх)
+func SetRetryAfter(w http.ResponseWriter, d time.Duration) {
+	seconds := int((d + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+}
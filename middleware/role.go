@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+)
+
+// RequireRole пропускает запрос, только если роль пользователя из токена одна из перечисленных.
+// Используется после Authenticate, который кладёт роль в заголовок X-User-Role.
+func RequireRole(roles ...string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			role := r.Header.Get("X-User-Role")
+			for _, allowed := range roles {
+				if role != "" && role == allowed {
+					next(w, r)
+					return
+				}
+			}
+			sendError(w, http.StatusForbidden, "Недостаточно прав")
+		}
+	}
+}
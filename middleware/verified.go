@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"server_new/config"
+	"server_new/services"
+	"server_new/utils"
+)
+
+// RequireVerifiedEmail запрещает изменяющие запросы пользователям с неподтверждённым email,
+// если включена политика UNVERIFIED_POLICY=read_only. Читать данные можно всегда.
+// Используется после Authenticate.
+func RequireVerifiedEmail(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if config.UnverifiedPolicy != config.UnverifiedPolicyReadOnly || isSafeMethod(r.Method) {
+			next(w, r)
+			return
+		}
+
+		userID, err := strconv.Atoi(r.Header.Get("X-User-ID"))
+		if err != nil {
+			sendError(w, http.StatusUnauthorized, "Не удалось определить пользователя")
+			return
+		}
+
+		verified, err := services.NewEmailVerificationService().IsVerified(userID)
+		if err != nil {
+			utils.LogError(err, "Ошибка проверки подтверждения email", "userID", userID)
+			sendError(w, http.StatusInternalServerError, "Внутренняя ошибка сервера")
+			return
+		}
+		if !verified {
+			sendError(w, http.StatusForbidden, "Подтвердите email, чтобы изменять данные")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// isSafeMethod — методы, которые не изменяют данные
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
@@ -0,0 +1,20 @@
+-- Миграция 007: Подтверждение email
+ALTER TABLE users ADD COLUMN email_verified_at DATETIME;
+
+-- Пользователи, зарегистрированные до появления подтверждения, считаются подтверждёнными
+UPDATE users SET email_verified_at = COALESCE(created_at, CURRENT_TIMESTAMP);
+
+-- Токены подтверждения. email — адрес, который подтверждается:
+-- при регистрации он совпадает с users.email, при смене — это новый адрес
+CREATE TABLE IF NOT EXISTS email_verifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    email TEXT NOT NULL,
+    token_hash TEXT UNIQUE NOT NULL,
+    expires_at DATETIME NOT NULL,
+    used_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_verifications_user_id ON email_verifications(user_id);
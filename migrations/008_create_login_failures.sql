@@ -0,0 +1,20 @@
+-- Миграция 008: Защита от перебора паролей
+-- key: "account:<email>" или "ip:<адрес>"
+CREATE TABLE IF NOT EXISTS auth_failures (
+    key TEXT PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at DATETIME NOT NULL,
+    locked_until DATETIME
+);
+
+-- Журнал блокировок (аудит)
+CREATE TABLE IF NOT EXISTS lockout_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    key TEXT NOT NULL,
+    ip TEXT NOT NULL DEFAULT '',
+    failures INTEGER NOT NULL,
+    locked_until DATETIME NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_lockout_events_key ON lockout_events(key);
@@ -0,0 +1,7 @@
+-- Миграция 009: Роли пользователей и отключение аккаунтов
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin'));
+
+-- Отключённый администратором аккаунт не может войти, пока его не включат обратно
+ALTER TABLE users ADD COLUMN disabled_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_users_role ON users(role);
@@ -0,0 +1,17 @@
+-- Миграция 010: Персональные API ключи для скриптов и интеграций
+-- Сам ключ не хранится: только его SHA-256 и короткий префикс, чтобы пользователь мог узнать ключ в списке
+CREATE TABLE IF NOT EXISTS api_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    key_hash TEXT UNIQUE NOT NULL,
+    scopes TEXT NOT NULL DEFAULT '', -- через пробел, например "tasks:read tasks:write"
+    expires_at DATETIME,
+    last_used_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    revoked_at DATETIME,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys(user_id);
@@ -0,0 +1,11 @@
+-- Миграция 011: Сроки, приоритеты и отметки времени задач
+-- priority хранится числом (0 — low, 1 — normal, 2 — high, 3 — urgent), чтобы по нему можно было сортировать и сравнивать
+ALTER TABLE tasks ADD COLUMN due_at DATETIME;
+ALTER TABLE tasks ADD COLUMN priority INTEGER NOT NULL DEFAULT 1 CHECK (priority BETWEEN 0 AND 3);
+ALTER TABLE tasks ADD COLUMN completed_at DATETIME;
+ALTER TABLE tasks ADD COLUMN updated_at DATETIME;
+
+-- Для существующих задач время изменения неизвестно — считаем им время создания
+UPDATE tasks SET updated_at = created_at;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_userid_due_at ON tasks(userid, due_at);
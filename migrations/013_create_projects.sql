@@ -0,0 +1,23 @@
+-- Миграция 013: Проекты (списки задач)
+CREATE TABLE IF NOT EXISTS projects (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    color TEXT NOT NULL DEFAULT '',
+    archived BOOLEAN NOT NULL DEFAULT 0,
+    position INTEGER NOT NULL DEFAULT 0,
+    is_inbox BOOLEAN NOT NULL DEFAULT 0, -- «Входящие»: сюда переносятся задачи удалённых проектов
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_projects_user_id_position ON projects(user_id, position);
+
+-- У каждого пользователя не больше одного проекта «Входящие»
+CREATE UNIQUE INDEX IF NOT EXISTS idx_projects_user_inbox ON projects(user_id) WHERE is_inbox = 1;
+
+-- Задача может не входить ни в один проект
+ALTER TABLE tasks ADD COLUMN project_id INTEGER REFERENCES projects(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_project_id ON tasks(project_id);
@@ -0,0 +1,19 @@
+-- Миграция 014: Участники общих проектов
+-- Владелец проекта — projects.user_id, в этой таблице его нет.
+-- Приглашение хранится здесь же со статусом pending, до принятия доступа к проекту нет.
+CREATE TABLE IF NOT EXISTS project_members (
+    project_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('viewer', 'editor', 'admin')),
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted')),
+    invited_by INTEGER,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    accepted_at DATETIME,
+    PRIMARY KEY(project_id, user_id),
+    FOREIGN KEY(project_id) REFERENCES projects(id) ON DELETE CASCADE,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY(invited_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Для поиска проектов и приглашений пользователя
+CREATE INDEX IF NOT EXISTS idx_project_members_user_id ON project_members(user_id, status);
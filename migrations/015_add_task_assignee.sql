@@ -0,0 +1,7 @@
+-- Миграция 015: Исполнитель задачи (отдельно от автора userid)
+ALTER TABLE tasks ADD COLUMN assignee_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE tasks ADD COLUMN assigned_by INTEGER REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE tasks ADD COLUMN assigned_at DATETIME;
+
+-- Для списка «назначено мне»
+CREATE INDEX IF NOT EXISTS idx_tasks_assignee_id_status ON tasks(assignee_id, status);
@@ -0,0 +1,18 @@
+-- Миграция 016: Подзадачи и чек-листы
+-- Подзадачи удаляются вместе с родительской задачей
+ALTER TABLE tasks ADD COLUMN parent_id INTEGER REFERENCES tasks(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_parent_id ON tasks(parent_id);
+
+-- Пункты чек-листа внутри задачи
+CREATE TABLE IF NOT EXISTS task_checklist_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    done BOOLEAN NOT NULL DEFAULT 0,
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_checklist_items_task_id ON task_checklist_items(task_id, position);
@@ -0,0 +1,12 @@
+-- Миграция 017: Зависимости между задачами («задача task_id заблокирована задачей blocked_by_id»)
+CREATE TABLE IF NOT EXISTS task_dependencies (
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    blocked_by_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (task_id, blocked_by_id),
+    CHECK (task_id != blocked_by_id)
+);
+
+-- Для поиска задач, которые блокирует данная
+CREATE INDEX IF NOT EXISTS idx_task_dependencies_blocked_by_id ON task_dependencies(blocked_by_id);
@@ -0,0 +1,9 @@
+-- Миграция 018: Повторяющиеся задачи (RRULE по RFC 5545)
+-- Правило хранится у текущего (последнего) повторения серии; после его завершения
+-- создаётся следующее повторение, а правило переходит к нему
+ALTER TABLE tasks ADD COLUMN recurrence_rule TEXT;
+ALTER TABLE tasks ADD COLUMN recurrence_tz TEXT NOT NULL DEFAULT 'UTC';
+ALTER TABLE tasks ADD COLUMN recurrence_start DATETIME; -- срок первого повторения серии (DTSTART)
+ALTER TABLE tasks ADD COLUMN series_id INTEGER;         -- ID первой задачи серии
+
+CREATE INDEX IF NOT EXISTS idx_tasks_series_id ON tasks(series_id);
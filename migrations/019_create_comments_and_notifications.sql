@@ -0,0 +1,43 @@
+-- Миграция 019: Комментарии к задачам, упоминания и уведомления
+CREATE TABLE IF NOT EXISTS task_comments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL, -- автор (NULL — пользователь удалён)
+    body TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    edited_at DATETIME -- время последней правки (NULL — не правился)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_comments_task_id ON task_comments(task_id, id);
+
+-- История правок: прежний текст комментария до каждой правки
+CREATE TABLE IF NOT EXISTS task_comment_revisions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    comment_id INTEGER NOT NULL REFERENCES task_comments(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    edited_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_comment_revisions_comment_id ON task_comment_revisions(comment_id, id);
+
+-- Кто упомянут в комментарии (чтобы при правке уведомлять только новых)
+CREATE TABLE IF NOT EXISTS task_comment_mentions (
+    comment_id INTEGER NOT NULL REFERENCES task_comments(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (comment_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- получатель
+    type TEXT NOT NULL,
+    actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,       -- кто вызвал уведомление
+    task_id INTEGER REFERENCES tasks(id) ON DELETE CASCADE,
+    comment_id INTEGER REFERENCES task_comments(id) ON DELETE CASCADE,
+    read_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications(user_id, read_at, id);
@@ -0,0 +1,13 @@
+-- Миграция 020: История изменений задач
+-- task_id без внешнего ключа: история остаётся и после удаления задачи
+CREATE TABLE IF NOT EXISTS task_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL,
+    actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL, -- кто изменил (NULL — пользователь удалён)
+    type TEXT NOT NULL,                                       -- created, updated, status_changed, deleted
+    changes TEXT NOT NULL DEFAULT '{}',                       -- JSON: {"поле": {"old": ..., "new": ...}}
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_events_task_id ON task_events(task_id, id);
+CREATE INDEX IF NOT EXISTS idx_task_events_actor_id ON task_events(actor_id, id);
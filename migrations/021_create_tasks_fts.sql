@@ -0,0 +1,28 @@
+-- Миграция 021: Полнотекстовый поиск по задачам (FTS5)
+-- Нужна сборка с тегом sqlite_fts5; без него миграция пропускается и применится после пересборки.
+-- После применения БД открывается только сборкой с FTS5 (см. RunMigrations): триггеры ниже
+-- без модуля fts5 не дали бы изменять задачи.
+-- unicode61 приводит русские и английские слова к нижнему регистру,
+-- porter дополнительно отбрасывает окончания английских слов.
+CREATE VIRTUAL TABLE IF NOT EXISTS tasks_fts USING fts5(
+    title,
+    description,
+    content = 'tasks',
+    content_rowid = 'id',
+    tokenize = 'porter unicode61 remove_diacritics 2'
+);
+
+INSERT INTO tasks_fts(tasks_fts) VALUES ('rebuild');
+
+CREATE TRIGGER IF NOT EXISTS tasks_fts_insert AFTER INSERT ON tasks BEGIN
+    INSERT INTO tasks_fts(rowid, title, description) VALUES (new.id, new.title, new.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS tasks_fts_delete AFTER DELETE ON tasks BEGIN
+    INSERT INTO tasks_fts(tasks_fts, rowid, title, description) VALUES ('delete', old.id, old.title, old.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS tasks_fts_update AFTER UPDATE OF title, description ON tasks BEGIN
+    INSERT INTO tasks_fts(tasks_fts, rowid, title, description) VALUES ('delete', old.id, old.title, old.description);
+    INSERT INTO tasks_fts(rowid, title, description) VALUES (new.id, new.title, new.description);
+END;
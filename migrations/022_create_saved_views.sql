@@ -0,0 +1,17 @@
+-- Миграция 022: Сохранённые виды списка задач (именованные фильтры)
+-- filters — параметры GET /tasks в виде JSON-объекта {"status": "pending", ...}
+CREATE TABLE IF NOT EXISTS saved_views (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL COLLATE NOCASE,
+    filters TEXT NOT NULL DEFAULT '{}',
+    sort TEXT NOT NULL DEFAULT '',
+    page_size INTEGER NOT NULL DEFAULT 10,
+    is_default INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, name)
+);
+
+-- Вид по умолчанию у пользователя может быть только один
+CREATE UNIQUE INDEX IF NOT EXISTS idx_saved_views_default ON saved_views(user_id) WHERE is_default = 1;
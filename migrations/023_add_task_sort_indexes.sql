@@ -0,0 +1,13 @@
+-- Миграция 023: Индексы для сортировки списка задач (?sort=title,-created_at,priority)
+-- id в конце каждого индекса — тот же ключ, которым сортировка разрешает равенства,
+-- поэтому порядок индекса совпадает с порядком списка и с условием курсора
+CREATE INDEX IF NOT EXISTS idx_tasks_title ON tasks(title COLLATE NOCASE, id);
+CREATE INDEX IF NOT EXISTS idx_tasks_priority ON tasks(priority, id);
+CREATE INDEX IF NOT EXISTS idx_tasks_due_at ON tasks(due_at, id);
+CREATE INDEX IF NOT EXISTS idx_tasks_completed_at ON tasks(completed_at, id);
+CREATE INDEX IF NOT EXISTS idx_tasks_created_at ON tasks(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_tasks_updated_at ON tasks(updated_at, id);
+
+-- Фильтр по статусу вместе с сортировкой по приоритету или сроку (типичные списки «что делать»)
+CREATE INDEX IF NOT EXISTS idx_tasks_status_priority ON tasks(status, priority, id);
+CREATE INDEX IF NOT EXISTS idx_tasks_status_due_at ON tasks(status, due_at, id);
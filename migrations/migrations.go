@@ -3,14 +3,34 @@ package migrations
 import (
 	"database/sql"
 	"embed"
+	"fmt"
 	"io/fs"
+	"strings"
 )
 
 //go:embed *.sql
 var migrationsFS embed.FS
 
+// RunMigrations применяет миграции, которые ещё не были применены.
+// Список применённых хранится в таблице schema_migrations, поэтому
+// миграции с ALTER TABLE выполняются ровно один раз. Миграции с FTS5 пропускаются,
+// если SQLite собран без него (без тега sqlite_fts5), и применяются после пересборки.
+// БД, где индекс FTS5 уже создан, сборка без FTS5 не открывает: его триггеры
+// ломали бы любое изменение задач.
 func RunMigrations(db *sql.DB) error {
-	// Читаем все SQL файлы
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version TEXT PRIMARY KEY,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return err
+	}
+
+	if err := checkFTS5(db); err != nil {
+		return err
+	}
+
+	// Читаем все SQL файлы (fs.ReadDir возвращает их отсортированными по имени)
 	files, err := fs.ReadDir(migrationsFS, ".")
 	if err != nil {
 		return err
@@ -18,15 +38,86 @@ func RunMigrations(db *sql.DB) error {
 
 	// Применяем каждую миграцию
 	for _, file := range files {
-		sql, err := migrationsFS.ReadFile(file.Name())
+		var applied bool
+		err := db.QueryRow(
+			"SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE version = ?)",
+			file.Name(),
+		).Scan(&applied)
 		if err != nil {
 			return err
 		}
+		if applied {
+			continue
+		}
 
-		if _, err := db.Exec(string(sql)); err != nil {
+		sql, err := migrationsFS.ReadFile(file.Name())
+		if err != nil {
 			return err
 		}
+
+		if strings.Contains(string(sql), "USING fts5") {
+			available, err := FTS5Available(db)
+			if err != nil {
+				return err
+			}
+			if !available {
+				continue
+			}
+		}
+
+		if err := applyMigration(db, file.Name(), string(sql)); err != nil {
+			return fmt.Errorf("миграция %s: %v", file.Name(), err)
+		}
 	}
 
 	return nil
 }
+
+// applyMigration выполняет миграцию и отмечает её применённой в одной транзакции
+func applyMigration(db *sql.DB, version, query string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(query); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO schema_migrations (version) VALUES (?)", version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// checkFTS5 отказывается работать с БД, где есть таблица tasks_fts, если SQLite собран без FTS5:
+// триггеры индекса на tasks падают с «no such module: fts5» при каждой записи в задачи
+func checkFTS5(db *sql.DB) error {
+	var indexed bool
+	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE name = 'tasks_fts')").Scan(&indexed)
+	if err != nil {
+		return err
+	}
+	if !indexed {
+		return nil
+	}
+
+	available, err := FTS5Available(db)
+	if err != nil {
+		return err
+	}
+	if !available {
+		return fmt.Errorf("в БД есть полнотекстовый индекс tasks_fts, а сервер собран без FTS5: соберите его с -tags sqlite_fts5")
+	}
+	return nil
+}
+
+// FTS5Available сообщает, собран ли SQLite с полнотекстовым поиском FTS5
+func FTS5Available(db *sql.DB) (bool, error) {
+	var used bool
+	if err := db.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&used); err != nil {
+		return false, err
+	}
+	return used, nil
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Области доступа (scopes) API ключей
+const (
+	ScopeTasksRead  = "tasks:read"
+	ScopeTasksWrite = "tasks:write"
+)
+
+// APIKeyScopes — все допустимые области доступа
+var APIKeyScopes = []string{ScopeTasksRead, ScopeTasksWrite}
+
+// APIKey — персональный API ключ пользователя (сам ключ показывается только при создании)
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // начало ключа, чтобы его можно было узнать
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
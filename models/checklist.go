@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// ChecklistItem — пункт чек-листа внутри задачи
+type ChecklistItem struct {
+	ID        int       `json:"id"`
+	TaskID    int       `json:"task_id"`
+	Title     string    `json:"title"`
+	Done      bool      `json:"done"`
+	Position  int       `json:"position"` // порядок в чек-листе, с нуля
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TaskProgress — сколько из подзадач или пунктов чек-листа выполнено, например 3 из 5
+type TaskProgress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
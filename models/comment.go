@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Comment — комментарий к задаче
+type Comment struct {
+	ID         int        `json:"id"`
+	TaskID     int        `json:"task_id"`
+	AuthorID   *int       `json:"author_id"` // nil — автор удалён
+	AuthorName string     `json:"author_name"`
+	Body       string     `json:"body"`
+	Mentions   []string   `json:"mentions"` // имена упомянутых пользователей
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	EditedAt   *time.Time `json:"edited_at"` // nil — комментарий не правился
+}
+
+// CommentRevision — прежний текст комментария до правки
+type CommentRevision struct {
+	ID        int       `json:"id"`
+	Body      string    `json:"body"`
+	EditedBy  *int      `json:"edited_by"`
+	CreatedAt time.Time `json:"created_at"` // когда текст был заменён
+}
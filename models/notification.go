@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Типы уведомлений
+const (
+	NotificationMention = "mention" // пользователя упомянули в комментарии
+)
+
+// Notification — уведомление пользователя
+type Notification struct {
+	ID        int        `json:"id"`
+	Type      string     `json:"type"`
+	ActorID   *int       `json:"actor_id"`
+	ActorName string     `json:"actor_name"`
+	TaskID    *int       `json:"task_id"`
+	CommentID *int       `json:"comment_id"`
+	ReadAt    *time.Time `json:"read_at"` // nil — не прочитано
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Project — проект (список задач) пользователя
+type Project struct {
+	ID        int       `json:"id"`
+	OwnerID   int       `json:"owner_id"`
+	Role      string    `json:"role"` // роль текущего пользователя в проекте
+	Name      string    `json:"name"`
+	Color     string    `json:"color"` // "#rrggbb" или пустая строка
+	Archived  bool      `json:"archived"`
+	Position  int       `json:"position"`   // порядок в списке проектов
+	Inbox     bool      `json:"inbox"`      // проект «Входящие»
+	TaskCount int       `json:"task_count"` // сколько задач в проекте
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
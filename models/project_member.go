@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Роли в проекте: владелец может всё, admin управляет участниками,
+// editor создаёт и меняет задачи, viewer только просматривает
+const (
+	ProjectRoleOwner  = "owner"
+	ProjectRoleAdmin  = "admin"
+	ProjectRoleEditor = "editor"
+	ProjectRoleViewer = "viewer"
+)
+
+// Статусы участия в проекте
+const (
+	MemberStatusPending  = "pending"  // приглашение ещё не принято
+	MemberStatusAccepted = "accepted" // участник проекта
+)
+
+// projectRoles — роли по возрастанию прав
+var projectRoles = []string{ProjectRoleViewer, ProjectRoleEditor, ProjectRoleAdmin, ProjectRoleOwner}
+
+// ProjectRoleRank возвращает уровень прав роли (чем больше, тем больше прав)
+func ProjectRoleRank(role string) (int, bool) {
+	for i, r := range projectRoles {
+		if r == role {
+			return i + 1, true
+		}
+	}
+	return 0, false
+}
+
+// ProjectMember — участник проекта или приглашённый пользователь
+type ProjectMember struct {
+	UserID     int        `json:"user_id"`
+	Username   string     `json:"username"`
+	Email      string     `json:"email"`
+	Role       string     `json:"role"`
+	Status     string     `json:"status"`
+	InvitedBy  *int       `json:"invited_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+}
+
+// ProjectInvitation — приглашение текущего пользователя в чужой проект
+type ProjectInvitation struct {
+	ProjectID   int       `json:"project_id"`
+	ProjectName string    `json:"project_name"`
+	Role        string    `json:"role"`
+	InvitedBy   string    `json:"invited_by"` // имя пригласившего
+	CreatedAt   time.Time `json:"created_at"`
+}
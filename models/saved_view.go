@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// SavedView — сохранённый вид списка задач: фильтры, сортировка и размер страницы
+type SavedView struct {
+	ID        int               `json:"id"`
+	Name      string            `json:"name"`
+	Filters   map[string]string `json:"filters"` // параметры GET /tasks: status, tags_any, filter, ...
+	Sort      string            `json:"sort"`
+	PageSize  int               `json:"page_size"`
+	IsDefault bool              `json:"is_default"` // применяется к GET /tasks без параметров
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Session — вход пользователя с конкретного устройства
+type Session struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"userAgent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"` // сессия, из которой сделан запрос
+}
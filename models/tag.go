@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// Tag — метка, которой пользователь помечает свои задачи
+type Tag struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Color     string    `json:"color"`      // "#rrggbb" или пустая строка
+	TaskCount int       `json:"task_count"` // сколько задач с этой меткой
+	CreatedAt time.Time `json:"created_at"`
+}
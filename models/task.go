@@ -1,10 +1,89 @@
 package models
 
+import "time"
+
+// Статусы задачи
+const (
+	TaskStatusPending    = "pending"
+	TaskStatusInProgress = "in_progress"
+	TaskStatusCompleted  = "completed"
+)
+
+// Приоритеты задачи (в БД хранятся числом — см. PriorityRank)
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityHigh   = "high"
+	PriorityUrgent = "urgent"
+)
+
+// priorities — приоритеты по возрастанию, индекс совпадает со значением в БД
+var priorities = []string{PriorityLow, PriorityNormal, PriorityHigh, PriorityUrgent}
+
+// PriorityRank возвращает числовое значение приоритета для хранения и сравнения
+func PriorityRank(priority string) (int, bool) {
+	for i, p := range priorities {
+		if p == priority {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// PriorityName возвращает название приоритета по его числовому значению
+func PriorityName(rank int) string {
+	if rank < 0 || rank >= len(priorities) {
+		return PriorityNormal
+	}
+	return priorities[rank]
+}
+
 // Task представляет задачу в базе данных
 type Task struct {
-	ID          int    `json:"id"`
+	ID          int             `json:"id"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Status      string          `json:"status"`
+	UserID      int             `json:"userid"`      // ID пользователя, которому принадлежит задача
+	ProjectID   *int            `json:"project_id"`  // nil — задача вне проектов
+	ParentID    *int            `json:"parent_id"`   // родительская задача (nil — задача верхнего уровня)
+	AssigneeID  *int            `json:"assignee_id"` // исполнитель (nil — не назначен)
+	AssignedBy  *int            `json:"assigned_by"` // кто назначил исполнителя
+	AssignedAt  *time.Time      `json:"assigned_at"`
+	Priority    string          `json:"priority"`
+	Tags        []string        `json:"tags"` // названия меток по алфавиту
+	DueAt       *time.Time      `json:"due_at"`
+	CompletedAt *time.Time      `json:"completed_at"` // выставляется автоматически при переходе в completed
+	Subtasks    TaskProgress    `json:"subtasks"`     // выполнено прямых подзадач из общего числа
+	Checklist   TaskProgress    `json:"checklist"`    // отмечено пунктов чек-листа из общего числа
+	Blocked     bool            `json:"blocked"`      // есть незавершённые задачи, которые блокируют эту
+	SeriesID    *int            `json:"series_id"`    // ID первой задачи серии повторений
+	Recurrence  *TaskRecurrence `json:"recurrence"`   // nil — задача не повторяется
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// TaskRecurrence — правило повторения задачи
+type TaskRecurrence struct {
+	RRule    string    `json:"rrule"`    // например "FREQ=WEEKLY;BYDAY=MO,WE"
+	Timezone string    `json:"timezone"` // часовой пояс IANA, в котором считаются дни недели и числа месяца
+	Start    time.Time `json:"start"`    // срок первого повторения серии
+}
+
+// TaskDependencies — зависимости задачи: какие задачи её блокируют и какие блокирует она
+type TaskDependencies struct {
+	BlockedBy []Task `json:"blocked_by"`
+	Blocking  []Task `json:"blocking"`
+}
+
+// TaskSearchResult — задача, найденная полнотекстовым поиском
+type TaskSearchResult struct {
+	Task
+	Highlights TaskHighlights `json:"highlights"`
+}
+
+// TaskHighlights — текст с совпадениями в <mark>…</mark>; остальное экранировано для вставки в HTML
+type TaskHighlights struct {
 	Title       string `json:"title"`
-	Description string `json:"description"`
-	Status      string `json:"status"`
-	UserID      int    `json:"userid"` // ID пользователя, которому принадлежит задача
+	Description string `json:"description"` // фрагмент описания вокруг совпадений
 }
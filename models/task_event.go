@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Типы событий истории задачи
+const (
+	TaskEventCreated       = "created"
+	TaskEventUpdated       = "updated"
+	TaskEventStatusChanged = "status_changed"
+	TaskEventDeleted       = "deleted"
+)
+
+// TaskEvent — запись истории задачи: кто, когда и что изменил
+type TaskEvent struct {
+	ID        int                    `json:"id"`
+	TaskID    int                    `json:"task_id"`
+	TaskTitle string                 `json:"task_title"` // текущее название ("" — задача удалена)
+	ActorID   *int                   `json:"actor_id"`   // nil — пользователь удалён
+	ActorName string                 `json:"actor_name"`
+	Type      string                 `json:"type"`
+	Changes   map[string]FieldChange `json:"changes"` // изменённые поля; для created — начальные значения, для deleted — последние
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// FieldChange — старое и новое значение поля (null — значения не было)
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
@@ -5,6 +5,12 @@ import (
 	"time"
 )
 
+// Роли пользователей
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 // User представляет пользователя в базе данных
 type User struct {
 	ID        int       `json:"id"`
@@ -12,6 +18,10 @@ type User struct {
 	Email     string    `json:"email"`
 	Password  string    `json:"-"` // не включаем пароль в JSON ответы
 	CreatedAt time.Time `json:"created_at"`
+
+	EmailVerified bool   `json:"emailVerified"`
+	Role          string `json:"role"`
+	Disabled      bool   `json:"disabled"`
 }
 
 // UserResponse — данные пользователя для ответа (без пароля)
@@ -20,4 +30,7 @@ type UserResponse struct {
 	Username  string    `json:"username"`
 	Email     string    `json:"email"`
 	CreatedAt time.Time `json:"created_at"`
+
+	EmailVerified bool   `json:"emailVerified"`
+	Role          string `json:"role"`
 }
\ No newline at end of file
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"server_new/config"
+)
+
+// AccountService содержит операции с аккаунтом текущего пользователя
+type AccountService struct {
+	db *sql.DB
+}
+
+// NewAccountService создаёт новый экземпляр сервиса
+func NewAccountService() *AccountService {
+	return &AccountService{db: config.DB}
+}
+
+// Delete удаляет аккаунт пользователя. Удаляются его личные задачи и все задачи
+// его проектов; задачи, которые он создал в чужих проектах, остаются в проекте
+// и переходят к владельцу проекта, а участие в чужих проектах прекращается.
+func (s *AccountService) Delete(userID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM users WHERE id = ?)", userID).Scan(&exists); err != nil {
+		return fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	if !exists {
+		return ErrUserNotFound
+	}
+
+	_, err = tx.Exec(
+		`DELETE FROM tasks WHERE (userid = ? AND project_id IS NULL)
+		 OR project_id IN (SELECT id FROM projects WHERE user_id = ?)`,
+		userID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления задач: %v", err)
+	}
+
+	// Остальные задачи пользователя лежат в чужих проектах: без переноса
+	// их удалил бы каскад users → tasks
+	_, err = tx.Exec(
+		`UPDATE tasks SET userid = (SELECT p.user_id FROM projects p WHERE p.id = tasks.project_id), updated_at = ?
+		 WHERE userid = ?`,
+		formatDBTime(time.Now()), userID,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка передачи задач владельцам проектов: %v", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM project_members WHERE user_id = ?", userID); err != nil {
+		return fmt.Errorf("ошибка выхода из проектов: %v", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM users WHERE id = ?", userID); err != nil {
+		return fmt.Errorf("ошибка удаления пользователя: %v", err)
+	}
+
+	return tx.Commit()
+}
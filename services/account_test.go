@@ -0,0 +1,85 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"server_new/config"
+	"server_new/models"
+)
+
+func TestAccountService_DeleteKeepsTasksInOtherProjects(t *testing.T) {
+	setupPooledServiceDB(t)
+	ownerID := createTestUser(t, "owner")
+	userID := createTestUser(t, "leaver")
+	projects := NewProjectsService()
+	members := NewProjectMembersService()
+	tasks := NewTasksService()
+
+	join := func(ownerID, memberID, projectID int, username string) {
+		t.Helper()
+		if _, err := members.Invite(ownerID, projectID, username, models.ProjectRoleEditor); err != nil {
+			t.Fatal(err)
+		}
+		if err := members.AcceptInvitation(memberID, projectID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	create := func(authorID int, title string, projectID *int) *models.Task {
+		t.Helper()
+		task, err := tasks.CreateTask(authorID, NewTask{Title: title, Status: models.TaskStatusPending, Priority: models.PriorityNormal, ProjectID: projectID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return task
+	}
+
+	shared, err := projects.Create(ownerID, "Чужой проект", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	join(ownerID, userID, shared.ID, "leaver")
+	own, err := projects.Create(userID, "Свой проект", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	join(userID, ownerID, own.ID, "owner")
+
+	kept := create(userID, "В чужом проекте", &shared.ID)
+	personal := create(userID, "Личная", nil)
+	inOwn := create(ownerID, "В проекте уходящего", &own.ID)
+
+	holdConnection(t)
+	if err := NewAccountService().Delete(userID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Задача в чужом проекте осталась и перешла к владельцу проекта
+	got, err := tasks.GetTaskByID(kept.ID, ownerID)
+	if err != nil {
+		t.Fatalf("Задача в чужом проекте должна остаться: %v", err)
+	}
+	if got.ProjectID == nil || *got.ProjectID != shared.ID {
+		t.Errorf("Задача должна остаться в проекте %d: %+v", shared.ID, got.ProjectID)
+	}
+	var authorID int
+	config.DB.QueryRow("SELECT userid FROM tasks WHERE id = ?", kept.ID).Scan(&authorID)
+	if authorID != ownerID {
+		t.Errorf("Автор задачи = %d, ожидался владелец проекта %d", authorID, ownerID)
+	}
+
+	// Личные задачи и задачи своих проектов удалены
+	var left, memberships int
+	config.DB.QueryRow("SELECT COUNT(*) FROM tasks WHERE id IN (?, ?)", personal.ID, inOwn.ID).Scan(&left)
+	if left != 0 {
+		t.Errorf("Осталось задач уходящего пользователя: %d", left)
+	}
+	config.DB.QueryRow("SELECT COUNT(*) FROM project_members WHERE user_id = ?", userID).Scan(&memberships)
+	if memberships != 0 {
+		t.Errorf("Осталось участий в проектах: %d", memberships)
+	}
+
+	if err := NewAccountService().Delete(userID); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Повторное удаление: ожидалась ErrUserNotFound, получено %v", err)
+	}
+}
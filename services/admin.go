@@ -0,0 +1,275 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"server_new/config"
+	"server_new/models"
+	"server_new/utils"
+)
+
+var (
+	// ErrUserNotFound — пользователь не найден
+	ErrUserNotFound = errors.New("пользователь не найден")
+	// ErrCannotDisableSelf — администратор пытается отключить сам себя
+	ErrCannotDisableSelf = errors.New("нельзя отключить собственный аккаунт")
+)
+
+// TaskCounts — количество задач пользователя по статусам
+type TaskCounts struct {
+	Total      int `json:"total"`
+	Pending    int `json:"pending"`
+	InProgress int `json:"in_progress"`
+	Completed  int `json:"completed"`
+}
+
+// AdminUserDetails — карточка пользователя для администратора
+type AdminUserDetails struct {
+	models.User
+	TaskCounts TaskCounts `json:"taskCounts"`
+}
+
+// UserFilter — параметры поиска пользователей
+type UserFilter struct {
+	Query    string // подстрока в username или email
+	Role     string
+	Disabled *bool
+}
+
+// AdminService содержит операции администрирования пользователей
+type AdminService struct {
+	db *sql.DB
+}
+
+// NewAdminService создаёт новый экземпляр сервиса
+func NewAdminService() *AdminService {
+	return &AdminService{db: config.DB}
+}
+
+const adminUserColumns = "id, username, email, created_at, email_verified_at IS NOT NULL, role, disabled_at IS NOT NULL"
+
+// ListUsers возвращает пользователей по фильтру с пагинацией и общее количество
+func (s *AdminService) ListUsers(filter UserFilter, page, limit int) ([]models.User, int, error) {
+	where := " WHERE 1 = 1"
+	args := []interface{}{}
+
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		pattern := "%" + escapeLike(strings.ToLower(q)) + "%"
+		where += ` AND (LOWER(username) LIKE ? ESCAPE '\' OR email LIKE ? ESCAPE '\')`
+		args = append(args, pattern, pattern)
+	}
+	if filter.Role != "" {
+		where += " AND role = ?"
+		args = append(args, filter.Role)
+	}
+	if filter.Disabled != nil {
+		if *filter.Disabled {
+			where += " AND disabled_at IS NOT NULL"
+		} else {
+			where += " AND disabled_at IS NULL"
+		}
+	}
+
+	var total int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM users"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("ошибка подсчёта пользователей: %v", err)
+	}
+
+	rows, err := s.db.Query(
+		"SELECT "+adminUserColumns+" FROM users"+where+" ORDER BY id LIMIT ? OFFSET ?",
+		append(args, limit, (page-1)*limit)...,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var u models.User
+		if err := rows.Scan(&u.ID, &u.Username, &u.Email, &u.CreatedAt, &u.EmailVerified, &u.Role, &u.Disabled); err != nil {
+			return nil, 0, fmt.Errorf("ошибка чтения пользователя: %v", err)
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("ошибка при итерации: %v", err)
+	}
+
+	return users, total, nil
+}
+
+// GetUser возвращает пользователя вместе с количеством его задач
+func (s *AdminService) GetUser(userID int) (*AdminUserDetails, error) {
+	var details AdminUserDetails
+	u := &details.User
+	err := s.db.QueryRow(
+		"SELECT "+adminUserColumns+" FROM users WHERE id = ?",
+		userID,
+	).Scan(&u.ID, &u.Username, &u.Email, &u.CreatedAt, &u.EmailVerified, &u.Role, &u.Disabled)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	counts, err := s.TaskCounts(userID)
+	if err != nil {
+		return nil, err
+	}
+	details.TaskCounts = *counts
+
+	return &details, nil
+}
+
+// TaskCounts считает задачи пользователя по статусам
+func (s *AdminService) TaskCounts(userID int) (*TaskCounts, error) {
+	rows, err := s.db.Query("SELECT status, COUNT(*) FROM tasks WHERE userid = ? GROUP BY status", userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подсчёта задач: %v", err)
+	}
+	defer rows.Close()
+
+	counts := &TaskCounts{}
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, fmt.Errorf("ошибка чтения счётчика: %v", err)
+		}
+		counts.Total += n
+		switch status {
+		case "pending":
+			counts.Pending = n
+		case "in_progress":
+			counts.InProgress = n
+		case "completed":
+			counts.Completed = n
+		}
+	}
+	return counts, rows.Err()
+}
+
+// SetDisabled отключает или снова включает аккаунт.
+// При отключении все сессии пользователя сразу завершаются.
+func (s *AdminService) SetDisabled(adminID, userID int, disabled bool) error {
+	if disabled && adminID == userID {
+		return ErrCannotDisableSelf
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+
+	var result sql.Result
+	if disabled {
+		result, err = tx.Exec(
+			"UPDATE users SET disabled_at = COALESCE(disabled_at, ?) WHERE id = ?",
+			formatDBTime(now), userID,
+		)
+	} else {
+		result, err = tx.Exec("UPDATE users SET disabled_at = NULL WHERE id = ?", userID)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка обновления пользователя: %v", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrUserNotFound
+	}
+
+	if disabled {
+		if err := revokeUserSessions(tx, userID, now); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ForcePasswordReset сбрасывает пароль пользователя: старый пароль перестаёт подходить,
+// все сессии завершаются, а на email уходит ссылка для установки нового пароля
+func (s *AdminService) ForcePasswordReset(userID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	var email string
+	err = tx.QueryRow("SELECT email FROM users WHERE id = ?", userID).Scan(&email)
+	if err == sql.ErrNoRows {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	// Пустой хэш не совпадает ни с одним паролем
+	if _, err := tx.Exec("UPDATE users SET password = '' WHERE id = ?", userID); err != nil {
+		return fmt.Errorf("ошибка сброса пароля: %v", err)
+	}
+	if err := revokeUserSessions(tx, userID, time.Now()); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка сброса пароля: %v", err)
+	}
+
+	return NewPasswordResetService().SendResetLink(userID, email)
+}
+
+// CreateAdmin создаёт администратора или, если пользователь с таким email уже есть,
+// назначает ему роль admin (и включает аккаунт). Возвращает ID и признак создания.
+// Используется из CLI, поэтому email считается подтверждённым.
+func (s *AdminService) CreateAdmin(username, email, password string) (int, bool, error) {
+	var userID int
+	err := s.db.QueryRow("SELECT id FROM users WHERE email = ?", email).Scan(&userID)
+	if err == nil {
+		_, err = s.db.Exec(
+			"UPDATE users SET role = ?, disabled_at = NULL WHERE id = ?",
+			models.RoleAdmin, userID,
+		)
+		if err != nil {
+			return 0, false, fmt.Errorf("ошибка назначения роли: %v", err)
+		}
+		return userID, false, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, false, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	if password == "" {
+		return 0, false, errors.New("для нового администратора нужен пароль")
+	}
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return 0, false, fmt.Errorf("ошибка хэширования пароля: %v", err)
+	}
+
+	result, err := s.db.Exec(
+		"INSERT INTO users (username, email, password, role, email_verified_at) VALUES (?, ?, ?, ?, ?)",
+		username, email, hashedPassword, models.RoleAdmin, formatDBTime(time.Now()),
+	)
+	if err != nil {
+		return 0, false, fmt.Errorf("ошибка создания пользователя: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, false, fmt.Errorf("ошибка получения ID: %v", err)
+	}
+	return int(id), true, nil
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
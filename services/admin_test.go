@@ -0,0 +1,136 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"server_new/config"
+	"server_new/models"
+)
+
+func TestAdminService_ListUsersSearch(t *testing.T) {
+	setupServiceDB(t)
+	createTestUser(t, "alice")
+	createTestUser(t, "bob")
+	createTestUser(t, "alina")
+	service := NewAdminService()
+
+	users, total, err := service.ListUsers(UserFilter{Query: "ALI"}, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || len(users) != 2 {
+		t.Fatalf("Ожидалось 2 пользователя, получено %d (total %d)", len(users), total)
+	}
+
+	// Спецсимволы LIKE ищутся буквально
+	_, total, err = service.ListUsers(UserFilter{Query: "%"}, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 0 {
+		t.Errorf("Поиск по %% не должен находить всех, найдено %d", total)
+	}
+
+	_, total, err = service.ListUsers(UserFilter{Role: models.RoleAdmin}, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 0 {
+		t.Errorf("Администраторов быть не должно, найдено %d", total)
+	}
+}
+
+func TestAdminService_DisableRevokesSessions(t *testing.T) {
+	setupServiceDB(t)
+	adminID := createTestUser(t, "admin")
+	userID := createTestUser(t, "alice")
+	service := NewAdminService()
+
+	pair, err := NewAuthService().IssueTokens(userID, "test-agent", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.SetDisabled(adminID, adminID, true); !errors.Is(err, ErrCannotDisableSelf) {
+		t.Errorf("Ожидалась ErrCannotDisableSelf, получено %v", err)
+	}
+
+	if err := service.SetDisabled(adminID, userID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewAuthService().Refresh(pair.RefreshToken); err == nil {
+		t.Error("Refresh отключённого пользователя должен завершаться ошибкой")
+	}
+
+	details, err := service.GetUser(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !details.Disabled {
+		t.Error("Пользователь должен быть отключён")
+	}
+
+	if err := service.SetDisabled(adminID, userID, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewAuthService().IssueTokens(userID, "test-agent", "127.0.0.1"); err != nil {
+		t.Errorf("После включения вход должен работать: %v", err)
+	}
+
+	if err := service.SetDisabled(adminID, 999, true); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Ожидалась ErrUserNotFound, получено %v", err)
+	}
+}
+
+func TestAdminService_TaskCounts(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "alice")
+
+	for _, status := range []string{"pending", "pending", "completed"} {
+		if _, err := config.DB.Exec("INSERT INTO tasks (title, status, userid) VALUES ('t', ?, ?)", status, userID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	details, err := NewAdminService().GetUser(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := TaskCounts{Total: 3, Pending: 2, Completed: 1}
+	if details.TaskCounts != want {
+		t.Errorf("TaskCounts = %+v, ожидалось %+v", details.TaskCounts, want)
+	}
+}
+
+func TestAdminService_CreateAdmin(t *testing.T) {
+	setupServiceDB(t)
+	service := NewAdminService()
+
+	_, created, err := service.CreateAdmin("root", "root@example.com", "password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created {
+		t.Error("Ожидалось создание нового пользователя")
+	}
+
+	// Повторный вызов только назначает роль существующему пользователю
+	existingID := createTestUser(t, "alice")
+	id2, created, err := service.CreateAdmin("ignored", "alice@example.com", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created || id2 != existingID {
+		t.Errorf("Ожидалось повышение пользователя %d, получено id=%d created=%v", existingID, id2, created)
+	}
+
+	_, total, err := service.ListUsers(UserFilter{Role: models.RoleAdmin}, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 {
+		t.Errorf("Ожидалось 2 администратора, получено %d", total)
+	}
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"server_new/config"
+	"server_new/models"
+	"server_new/utils"
+)
+
+var (
+	// ErrAPIKeyNotFound — ключ не найден или принадлежит другому пользователю
+	ErrAPIKeyNotFound = errors.New("API ключ не найден")
+	// ErrInvalidAPIKey — ключ не существует, отозван или истёк
+	ErrInvalidAPIKey = errors.New("API ключ недействителен или истёк")
+	// ErrInvalidScope — запрошена неизвестная область доступа
+	ErrInvalidScope = errors.New("неизвестная область доступа")
+)
+
+const (
+	// apiKeyPrefix — с чего начинаются все ключи (удобно искать утечки в коде)
+	apiKeyPrefix = "tk_"
+	// apiKeyDisplayLength — сколько первых символов ключа хранится открыто для списка
+	apiKeyDisplayLength = len(apiKeyPrefix) + 8
+	// apiKeyTouchInterval — как часто обновлять last_used_at
+	apiKeyTouchInterval = time.Minute
+)
+
+// APIKeyPrincipal — владелец ключа и его права, определённые при проверке ключа
+type APIKeyPrincipal struct {
+	KeyID  int
+	UserID int
+	Scopes []string
+}
+
+// HasScope проверяет, выдана ли ключу область доступа
+func (p *APIKeyPrincipal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeysService управляет персональными API ключами
+type APIKeysService struct {
+	db *sql.DB
+}
+
+// NewAPIKeysService создаёт новый экземпляр сервиса
+func NewAPIKeysService() *APIKeysService {
+	return &APIKeysService{db: config.DB}
+}
+
+// Create создаёт ключ и возвращает его вместе с открытым значением,
+// которое больше нигде не сохраняется и показывается пользователю один раз
+func (s *APIKeysService) Create(userID int, name string, scopes []string, expiresAt *time.Time) (*models.APIKey, string, error) {
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret, err := utils.GenerateSecureToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("ошибка генерации ключа: %v", err)
+	}
+	rawKey := apiKeyPrefix + secret
+
+	var expires interface{}
+	if expiresAt != nil {
+		expires = formatDBTime(*expiresAt)
+	}
+
+	key := &models.APIKey{
+		Name:      name,
+		Prefix:    rawKey[:apiKeyDisplayLength],
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+
+	result, err := s.db.Exec(
+		"INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at) VALUES (?, ?, ?, ?, ?, ?)",
+		userID, name, key.Prefix, utils.HashToken(rawKey), strings.Join(scopes, " "), expires,
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("ошибка сохранения ключа: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, "", fmt.Errorf("ошибка получения ID ключа: %v", err)
+	}
+	key.ID = int(id)
+
+	return key, rawKey, nil
+}
+
+// ListByUserID возвращает действующие ключи пользователя (без самих ключей)
+func (s *APIKeysService) ListByUserID(userID int) ([]models.APIKey, error) {
+	rows, err := s.db.Query(
+		`SELECT id, name, prefix, scopes, expires_at, last_used_at, created_at FROM api_keys
+		 WHERE user_id = ? AND revoked_at IS NULL
+		 ORDER BY id DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		var key models.APIKey
+		var scopes string
+		var expiresAt, lastUsedAt sql.NullTime
+		if err := rows.Scan(&key.ID, &key.Name, &key.Prefix, &scopes, &expiresAt, &lastUsedAt, &key.CreatedAt); err != nil {
+			return nil, fmt.Errorf("ошибка чтения ключа: %v", err)
+		}
+		key.Scopes = strings.Fields(scopes)
+		if expiresAt.Valid {
+			key.ExpiresAt = &expiresAt.Time
+		}
+		if lastUsedAt.Valid {
+			key.LastUsedAt = &lastUsedAt.Time
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// Revoke отзывает ключ пользователя
+func (s *APIKeysService) Revoke(userID, keyID int) error {
+	result, err := s.db.Exec(
+		"UPDATE api_keys SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL",
+		formatDBTime(time.Now()), keyID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка отзыва ключа: %v", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// Authenticate проверяет ключ и возвращает его владельца и области доступа.
+// Ключи отключённых пользователей не принимаются.
+func (s *APIKeysService) Authenticate(rawKey string) (*APIKeyPrincipal, error) {
+	if !strings.HasPrefix(rawKey, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+
+	var (
+		principal  APIKeyPrincipal
+		scopes     string
+		expiresAt  sql.NullTime
+		lastUsedAt sql.NullTime
+		disabled   bool
+	)
+	err := s.db.QueryRow(
+		`SELECT k.id, k.user_id, k.scopes, k.expires_at, k.last_used_at, u.disabled_at IS NOT NULL
+		 FROM api_keys k
+		 JOIN users u ON u.id = k.user_id
+		 WHERE k.key_hash = ? AND k.revoked_at IS NULL`,
+		utils.HashToken(rawKey),
+	).Scan(&principal.KeyID, &principal.UserID, &scopes, &expiresAt, &lastUsedAt, &disabled)
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	if disabled || (expiresAt.Valid && now.After(expiresAt.Time)) {
+		return nil, ErrInvalidAPIKey
+	}
+	principal.Scopes = strings.Fields(scopes)
+
+	// Время последнего использования обновляем не чаще раза в минуту
+	if !lastUsedAt.Valid || now.Sub(lastUsedAt.Time) >= apiKeyTouchInterval {
+		if _, err := s.db.Exec("UPDATE api_keys SET last_used_at = ? WHERE id = ?", formatDBTime(now), principal.KeyID); err != nil {
+			return nil, fmt.Errorf("ошибка обновления ключа: %v", err)
+		}
+	}
+
+	return &principal, nil
+}
+
+// normalizeScopes проверяет области доступа и убирает повторы.
+// Если ничего не указано, ключ получает доступ только на чтение задач.
+func normalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return []string{models.ScopeTasksRead}, nil
+	}
+
+	result := []string{}
+	seen := map[string]bool{}
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if !isKnownScope(scope) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidScope, scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			result = append(result, scope)
+		}
+	}
+	return result, nil
+}
+
+func isKnownScope(scope string) bool {
+	for _, known := range models.APIKeyScopes {
+		if scope == known {
+			return true
+		}
+	}
+	return false
+}
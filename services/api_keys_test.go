@@ -0,0 +1,114 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"server_new/config"
+	"server_new/models"
+)
+
+func TestAPIKeysService_CreateAndAuthenticate(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "alice")
+	service := NewAPIKeysService()
+
+	key, rawKey, err := service.Create(userID, "CI", []string{models.ScopeTasksRead, models.ScopeTasksWrite, models.ScopeTasksRead}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(rawKey, key.Prefix) {
+		t.Errorf("Префикс %q не совпадает с ключом", key.Prefix)
+	}
+	if len(key.Scopes) != 2 {
+		t.Errorf("Повторы областей доступа должны убираться, получено %v", key.Scopes)
+	}
+
+	// В БД хранится только хэш
+	var stored int
+	config.DB.QueryRow("SELECT COUNT(*) FROM api_keys WHERE key_hash = ?", rawKey).Scan(&stored)
+	if stored != 0 {
+		t.Error("Ключ не должен храниться в открытом виде")
+	}
+
+	principal, err := service.Authenticate(rawKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if principal.UserID != userID || !principal.HasScope(models.ScopeTasksWrite) {
+		t.Errorf("Неверный владелец или области доступа: %+v", principal)
+	}
+
+	keys, err := service.ListByUserID(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].LastUsedAt == nil {
+		t.Fatalf("Ожидался 1 ключ с временем использования, получено %+v", keys)
+	}
+
+	if _, err := service.Authenticate(rawKey + "x"); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("Ожидалась ErrInvalidAPIKey, получено %v", err)
+	}
+}
+
+func TestAPIKeysService_DefaultAndInvalidScopes(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "alice")
+	service := NewAPIKeysService()
+
+	key, _, err := service.Create(userID, "readonly", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key.Scopes) != 1 || key.Scopes[0] != models.ScopeTasksRead {
+		t.Errorf("По умолчанию ожидался только tasks:read, получено %v", key.Scopes)
+	}
+
+	if _, _, err := service.Create(userID, "bad", []string{"admin"}, nil); !errors.Is(err, ErrInvalidScope) {
+		t.Errorf("Ожидалась ErrInvalidScope, получено %v", err)
+	}
+}
+
+func TestAPIKeysService_RevokedExpiredAndDisabled(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "alice")
+	otherID := createTestUser(t, "bob")
+	service := NewAPIKeysService()
+
+	key, rawKey, err := service.Create(userID, "revoked", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Revoke(otherID, key.ID); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("Чужой ключ отзывать нельзя, получено %v", err)
+	}
+	if err := service.Revoke(userID, key.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Authenticate(rawKey); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("Отозванный ключ должен отклоняться, получено %v", err)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	_, expiredKey, err := service.Create(userID, "expired", nil, &past)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Authenticate(expiredKey); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("Истёкший ключ должен отклоняться, получено %v", err)
+	}
+
+	_, activeKey, err := service.Create(userID, "active", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewAdminService().SetDisabled(otherID, userID, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Authenticate(activeKey); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("Ключ отключённого пользователя должен отклоняться, получено %v", err)
+	}
+}
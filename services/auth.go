@@ -0,0 +1,208 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"server_new/config"
+	"server_new/utils"
+)
+
+var (
+	// ErrInvalidRefreshToken — refresh токен не найден или истёк
+	ErrInvalidRefreshToken = errors.New("refresh токен недействителен или истёк")
+	// ErrRefreshTokenReused — предъявлен уже обменянный токен, всё семейство отозвано
+	ErrRefreshTokenReused = errors.New("refresh токен уже был использован, все сессии этого входа завершены")
+	// ErrAccountDisabled — аккаунт отключён администратором
+	ErrAccountDisabled = errors.New("аккаунт отключён администратором")
+)
+
+// TokenPair — пара токенов, которая выдаётся при входе и обновлении
+type TokenPair struct {
+	AccessToken  string `json:"jwt"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresIn    int    `json:"expiresIn"` // время жизни access токена в секундах
+}
+
+// AuthService выдаёт, обновляет и отзывает токены
+type AuthService struct {
+	db *sql.DB
+}
+
+// NewAuthService создаёт новый экземпляр сервиса
+func NewAuthService() *AuthService {
+	return &AuthService{db: config.DB}
+}
+
+// IssueTokens выдаёт пару токенов при новом входе: создаёт сессию
+// и начинает новое семейство refresh токенов (его ID совпадает с ID сессии)
+func (s *AuthService) IssueTokens(userID int, userAgent, ip string) (*TokenPair, error) {
+	sessionID, err := utils.GenerateID()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка генерации ID сессии: %v", err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		"INSERT INTO sessions (id, user_id, user_agent, ip, expires_at) VALUES (?, ?, ?, ?, ?)",
+		sessionID, userID, truncate(userAgent, 255), ip, formatDBTime(time.Now().Add(config.RefreshTokenTTL)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания сессии: %v", err)
+	}
+
+	pair, err := s.issueInTx(tx, userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка сохранения токена: %v", err)
+	}
+	return pair, nil
+}
+
+// Refresh обменивает refresh токен на новую пару (ротация).
+// Если токен уже был обменян раньше, считаем, что его украли, и отзываем всё семейство.
+func (s *AuthService) Refresh(refreshToken string) (*TokenPair, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	var (
+		tokenID   int
+		userID    int
+		familyID  string
+		expiresAt time.Time
+		usedAt    sql.NullTime
+		revoked   bool
+	)
+	err = tx.QueryRow(
+		`SELECT rt.id, rt.user_id, rt.family_id, rt.expires_at, rt.used_at, (rt.revoked_at IS NOT NULL OR s.revoked_at IS NOT NULL)
+		 FROM refresh_tokens rt
+		 JOIN sessions s ON s.id = rt.family_id
+		 WHERE rt.token_hash = ?`,
+		utils.HashToken(refreshToken),
+	).Scan(&tokenID, &userID, &familyID, &expiresAt, &usedAt, &revoked)
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	now := time.Now()
+
+	if usedAt.Valid {
+		// Повторное использование: отзываем всё семейство и фиксируем это вне зависимости от исхода запроса
+		if err := revokeSession(tx, familyID, now); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("ошибка отзыва токенов: %v", err)
+		}
+		utils.LogWarn("Повторное использование refresh токена, семейство отозвано", "userID", userID, "familyID", familyID)
+		return nil, ErrRefreshTokenReused
+	}
+
+	if revoked || now.After(expiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// Помечаем токен использованным; условие защищает от одновременного обмена одного токена
+	result, err := tx.Exec(
+		"UPDATE refresh_tokens SET used_at = ? WHERE id = ? AND used_at IS NULL",
+		formatDBTime(now), tokenID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка обновления токена: %v", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	pair, err := s.issueInTx(tx, userID, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка сохранения токена: %v", err)
+	}
+	return pair, nil
+}
+
+// Logout завершает сессию, к которой относится refresh токен:
+// отзывает всю цепочку refresh токенов, а выданные access токены перестают приниматься
+func (s *AuthService) Logout(refreshToken string) error {
+	var familyID string
+	err := s.db.QueryRow(
+		"SELECT family_id FROM refresh_tokens WHERE token_hash = ?",
+		utils.HashToken(refreshToken),
+	).Scan(&familyID)
+	if err == sql.ErrNoRows {
+		return ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	return revokeSession(s.db, familyID, time.Now())
+}
+
+// issueInTx создаёт access токен и новый refresh токен в указанном семействе (сессии)
+func (s *AuthService) issueInTx(tx *sql.Tx, userID int, familyID string) (*TokenPair, error) {
+	// Роль читаем из БД при каждой выдаче, чтобы её изменение вступало в силу с ближайшим обновлением
+	var role string
+	var disabled bool
+	err := tx.QueryRow(
+		"SELECT role, disabled_at IS NOT NULL FROM users WHERE id = ?",
+		userID,
+	).Scan(&role, &disabled)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	if disabled {
+		return nil, ErrAccountDisabled
+	}
+
+	accessToken, err := utils.GenerateToken(userID, familyID, role)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания access токена: %v", err)
+	}
+
+	refreshToken, err := utils.GenerateSecureToken()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка генерации refresh токена: %v", err)
+	}
+
+	expiresAt := formatDBTime(time.Now().Add(config.RefreshTokenTTL))
+
+	_, err = tx.Exec(
+		"INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at) VALUES (?, ?, ?, ?)",
+		userID, familyID, utils.HashToken(refreshToken), expiresAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка сохранения refresh токена: %v", err)
+	}
+
+	// Сессия живёт столько же, сколько её последний refresh токен
+	if _, err := tx.Exec("UPDATE sessions SET expires_at = ? WHERE id = ?", expiresAt, familyID); err != nil {
+		return nil, fmt.Errorf("ошибка продления сессии: %v", err)
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(config.AccessTokenTTL.Seconds()),
+	}, nil
+}
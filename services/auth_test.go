@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"server_new/config"
+	"server_new/migrations"
+	"server_new/utils"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// setupServiceDB создаёт БД в памяти со всеми миграциями
+func setupServiceDB(t *testing.T) {
+	t.Helper()
+	utils.InitLogger()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal("Ошибка открытия БД:", err)
+	}
+	// Каждое соединение с :memory: — отдельная БД, поэтому держим одно
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("PRAGMA foreign_keys = ON;"); err != nil {
+		t.Fatal("Ошибка включения внешних ключей:", err)
+	}
+	if err := migrations.RunMigrations(db); err != nil {
+		t.Fatal("Ошибка миграций:", err)
+	}
+
+	config.DB = db
+	config.AccessTokenTTL = 15 * time.Minute
+	config.RefreshTokenTTL = time.Hour
+	t.Cleanup(func() { db.Close() })
+}
+
+// setupPooledServiceDB создаёт БД в файле с пулом из нескольких соединений, как в InitDB:
+// на ней видно, что настройки соединения (внешние ключи) действуют на каждом из них
+func setupPooledServiceDB(t *testing.T) {
+	t.Helper()
+	utils.InitLogger()
+
+	db, err := sql.Open("sqlite3", config.DataSourceName(filepath.Join(t.TempDir(), "test.sqlite")))
+	if err != nil {
+		t.Fatal("Ошибка открытия БД:", err)
+	}
+	db.SetMaxOpenConns(4)
+
+	if err := migrations.RunMigrations(db); err != nil {
+		t.Fatal("Ошибка миграций:", err)
+	}
+
+	config.DB = db
+	t.Cleanup(func() { db.Close() })
+}
+
+// holdConnection занимает соединение из пула до конца теста, чтобы следующие запросы
+// шли через другие соединения, и проверяет, что на нём включены внешние ключи
+func holdConnection(t *testing.T) {
+	t.Helper()
+	conn, err := config.DB.Conn(context.Background())
+	if err != nil {
+		t.Fatal("Ошибка получения соединения:", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	var foreignKeys bool
+	if err := conn.QueryRowContext(context.Background(), "PRAGMA foreign_keys").Scan(&foreignKeys); err != nil {
+		t.Fatal(err)
+	}
+	if !foreignKeys {
+		t.Fatal("На соединении из пула выключены внешние ключи")
+	}
+}
+
+// createTestUser добавляет пользователя и возвращает его ID
+func createTestUser(t *testing.T, username string) int {
+	t.Helper()
+	result, err := config.DB.Exec(
+		"INSERT INTO users (username, email, password) VALUES (?, ?, ?)",
+		username, username+"@example.com", "hash",
+	)
+	if err != nil {
+		t.Fatal("Ошибка создания пользователя:", err)
+	}
+	id, _ := result.LastInsertId()
+	return int(id)
+}
+
+func TestAuthService_RefreshRotation(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "alice")
+	service := NewAuthService()
+
+	first, err := service.IssueTokens(userID, "test-agent", "127.0.0.1")
+	if err != nil {
+		t.Fatal("IssueTokens():", err)
+	}
+
+	second, err := service.Refresh(first.RefreshToken)
+	if err != nil {
+		t.Fatal("Refresh():", err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Error("Refresh() должен выдавать новый refresh токен")
+	}
+
+	// Повторное использование старого токена отзывает всё семейство
+	if _, err := service.Refresh(first.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Errorf("Refresh(старый) error = %v, want %v", err, ErrRefreshTokenReused)
+	}
+	if _, err := service.Refresh(second.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("Refresh(после отзыва) error = %v, want %v", err, ErrInvalidRefreshToken)
+	}
+}
+
+func TestAuthService_Logout(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "bob")
+	service := NewAuthService()
+
+	pair, err := service.IssueTokens(userID, "test-agent", "127.0.0.1")
+	if err != nil {
+		t.Fatal("IssueTokens():", err)
+	}
+
+	if err := service.Logout(pair.RefreshToken); err != nil {
+		t.Fatal("Logout():", err)
+	}
+	if _, err := service.Refresh(pair.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("Refresh(после выхода) error = %v, want %v", err, ErrInvalidRefreshToken)
+	}
+}
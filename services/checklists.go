@@ -0,0 +1,227 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"server_new/config"
+	"server_new/models"
+)
+
+var (
+	// ErrChecklistItemNotFound — пункт чек-листа не найден в этой задаче
+	ErrChecklistItemNotFound = errors.New("пункт чек-листа не найден")
+	// ErrChecklistOrder — новый порядок должен перечислять все пункты чек-листа ровно по одному разу
+	ErrChecklistOrder = errors.New("порядок должен содержать все пункты чек-листа ровно по одному разу")
+)
+
+// ChecklistService содержит методы для работы с чек-листами задач. Видеть чек-лист может
+// любой, кому доступна задача, менять — те же, кто может менять задачу.
+type ChecklistService struct {
+	db *sql.DB
+}
+
+// NewChecklistService создаёт новый экземпляр сервиса
+func NewChecklistService() *ChecklistService {
+	return &ChecklistService{db: config.DB}
+}
+
+// checklistColumns — колонки, которые читает scanChecklistItem
+const checklistColumns = "id, task_id, title, done, position, created_at, updated_at"
+
+// scanChecklistItem читает пункт чек-листа из строки с колонками checklistColumns
+func scanChecklistItem(row rowScanner) (*models.ChecklistItem, error) {
+	var item models.ChecklistItem
+	err := row.Scan(&item.ID, &item.TaskID, &item.Title, &item.Done, &item.Position, &item.CreatedAt, &item.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// List возвращает пункты чек-листа задачи по порядку
+func (s *ChecklistService) List(userID, taskID int) ([]models.ChecklistItem, error) {
+	if _, err := taskRole(s.db, userID, taskID); err != nil {
+		return nil, err
+	}
+	return listChecklist(s.db, taskID)
+}
+
+// rowsQuerier — общее у *sql.DB и *sql.Tx для запросов нескольких строк
+type rowsQuerier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// listChecklist читает чек-лист задачи без проверки прав
+func listChecklist(q rowsQuerier, taskID int) ([]models.ChecklistItem, error) {
+	rows, err := q.Query(
+		"SELECT "+checklistColumns+" FROM task_checklist_items WHERE task_id = ? ORDER BY position, id",
+		taskID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	items := []models.ChecklistItem{}
+	for rows.Next() {
+		item, err := scanChecklistItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения пункта чек-листа: %v", err)
+		}
+		items = append(items, *item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации: %v", err)
+	}
+
+	return items, nil
+}
+
+// Create добавляет пункт в конец чек-листа
+func (s *ChecklistService) Create(userID, taskID int, title string) (*models.ChecklistItem, error) {
+	if err := requireTaskRole(s.db, userID, taskID, models.ProjectRoleEditor); err != nil {
+		return nil, err
+	}
+
+	now := formatDBTime(time.Now())
+	result, err := s.db.Exec(
+		`INSERT INTO task_checklist_items (task_id, title, position, created_at, updated_at)
+		 SELECT ?, ?, COALESCE(MAX(position), -1) + 1, ?, ? FROM task_checklist_items WHERE task_id = ?`,
+		taskID, title, now, now, taskID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания пункта чек-листа: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения ID пункта чек-листа: %v", err)
+	}
+
+	return s.getItem(taskID, int(id))
+}
+
+// Update переименовывает пункт и/или отмечает его выполненным (nil — поле не меняется)
+func (s *ChecklistService) Update(userID, taskID, itemID int, title *string, done *bool) (*models.ChecklistItem, error) {
+	if err := requireTaskRole(s.db, userID, taskID, models.ProjectRoleEditor); err != nil {
+		return nil, err
+	}
+
+	updates := []string{}
+	params := []interface{}{}
+
+	if title != nil {
+		updates = append(updates, "title = ?")
+		params = append(params, *title)
+	}
+	if done != nil {
+		updates = append(updates, "done = ?")
+		params = append(params, *done)
+	}
+	if len(updates) == 0 {
+		return s.getItem(taskID, itemID)
+	}
+
+	updates = append(updates, "updated_at = ?")
+	params = append(params, formatDBTime(time.Now()), itemID, taskID)
+
+	result, err := s.db.Exec(
+		"UPDATE task_checklist_items SET "+strings.Join(updates, ", ")+" WHERE id = ? AND task_id = ?",
+		params...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка обновления пункта чек-листа: %v", err)
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return nil, ErrChecklistItemNotFound
+	}
+
+	return s.getItem(taskID, itemID)
+}
+
+// Delete удаляет пункт чек-листа
+func (s *ChecklistService) Delete(userID, taskID, itemID int) error {
+	if err := requireTaskRole(s.db, userID, taskID, models.ProjectRoleEditor); err != nil {
+		return err
+	}
+
+	result, err := s.db.Exec("DELETE FROM task_checklist_items WHERE id = ? AND task_id = ?", itemID, taskID)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления пункта чек-листа: %v", err)
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return ErrChecklistItemNotFound
+	}
+
+	return nil
+}
+
+// Reorder задаёт новый порядок пунктов: itemIDs должен перечислять все пункты чек-листа
+func (s *ChecklistService) Reorder(userID, taskID int, itemIDs []int) ([]models.ChecklistItem, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := requireTaskRole(tx, userID, taskID, models.ProjectRoleEditor); err != nil {
+		return nil, err
+	}
+
+	items, err := listChecklist(tx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[int]bool, len(items))
+	for _, item := range items {
+		existing[item.ID] = true
+	}
+	if len(itemIDs) != len(items) {
+		return nil, ErrChecklistOrder
+	}
+	for _, id := range itemIDs {
+		if !existing[id] {
+			return nil, ErrChecklistOrder
+		}
+		delete(existing, id) // повторно тот же ID уже не найдётся
+	}
+
+	now := formatDBTime(time.Now())
+	for position, id := range itemIDs {
+		_, err := tx.Exec(
+			"UPDATE task_checklist_items SET position = ?, updated_at = ? WHERE id = ? AND task_id = ?",
+			position, now, id, taskID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка изменения порядка: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка сохранения: %v", err)
+	}
+
+	return listChecklist(s.db, taskID)
+}
+
+// getItem возвращает пункт чек-листа задачи
+func (s *ChecklistService) getItem(taskID, itemID int) (*models.ChecklistItem, error) {
+	item, err := scanChecklistItem(s.db.QueryRow(
+		"SELECT "+checklistColumns+" FROM task_checklist_items WHERE id = ? AND task_id = ?",
+		itemID, taskID,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrChecklistItemNotFound
+		}
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	return item, nil
+}
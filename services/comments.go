@@ -0,0 +1,393 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"server_new/config"
+	"server_new/models"
+	"server_new/utils"
+)
+
+var (
+	// ErrCommentNotFound — комментарий не найден в этой задаче
+	ErrCommentNotFound = errors.New("комментарий не найден")
+	// ErrCommentForbidden — править комментарий может только автор, удалять — автор или администратор проекта
+	ErrCommentForbidden = errors.New("недостаточно прав для изменения комментария")
+)
+
+// CommentsService содержит методы для работы с комментариями к задачам. Читать и писать
+// комментарии может любой, кому доступна задача.
+type CommentsService struct {
+	db *sql.DB
+}
+
+// NewCommentsService создаёт новый экземпляр сервиса
+func NewCommentsService() *CommentsService {
+	return &CommentsService{db: config.DB}
+}
+
+// commentColumns — колонки, которые читает scanComment (таблица task_comments — c, автор — u)
+const commentColumns = "c.id, c.task_id, c.user_id, COALESCE(u.username, ''), c.body, c.created_at, c.updated_at, c.edited_at"
+
+// scanComment читает комментарий из строки с колонками commentColumns
+func scanComment(row rowScanner) (*models.Comment, error) {
+	var c models.Comment
+	var authorID sql.NullInt64
+	var editedAt sql.NullTime
+	err := row.Scan(&c.ID, &c.TaskID, &authorID, &c.AuthorName, &c.Body, &c.CreatedAt, &c.UpdatedAt, &editedAt)
+	if err != nil {
+		return nil, err
+	}
+	c.AuthorID = nullIntPtr(authorID)
+	if editedAt.Valid {
+		c.EditedAt = &editedAt.Time
+	}
+	c.Mentions = []string{}
+	return &c, nil
+}
+
+// List возвращает комментарии задачи в порядке написания и их общее количество
+func (s *CommentsService) List(userID, taskID, page, limit int) ([]models.Comment, int, error) {
+	if _, err := taskRole(s.db, userID, taskID); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.Query(
+		"SELECT "+commentColumns+` FROM task_comments c LEFT JOIN users u ON u.id = c.user_id
+		 WHERE c.task_id = ? ORDER BY c.id LIMIT ? OFFSET ?`,
+		taskID, limit, (page-1)*limit,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	comments := []models.Comment{}
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("ошибка чтения комментария: %v", err)
+		}
+		comments = append(comments, *c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("ошибка при итерации: %v", err)
+	}
+	rows.Close()
+
+	if err := s.loadMentions(comments); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM task_comments WHERE task_id = ?", taskID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("ошибка получения количества: %v", err)
+	}
+
+	return comments, total, nil
+}
+
+// Get возвращает комментарий задачи
+func (s *CommentsService) Get(userID, taskID, commentID int) (*models.Comment, error) {
+	if _, err := taskRole(s.db, userID, taskID); err != nil {
+		return nil, err
+	}
+	return s.getComment(taskID, commentID)
+}
+
+// Create добавляет комментарий и уведомляет упомянутых в нём пользователей
+func (s *CommentsService) Create(userID, taskID int, body string) (*models.Comment, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := taskRole(tx, userID, taskID); err != nil {
+		return nil, err
+	}
+
+	now := formatDBTime(time.Now())
+	result, err := tx.Exec(
+		"INSERT INTO task_comments (task_id, user_id, body, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
+		taskID, userID, body, now, now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания комментария: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения ID комментария: %v", err)
+	}
+
+	if err := syncMentions(tx, userID, taskID, int(id), body); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка подтверждения транзакции: %v", err)
+	}
+
+	return s.getComment(taskID, int(id))
+}
+
+// Update меняет текст комментария (только автор), сохраняя прежний текст в истории правок.
+// Уведомления получают только пользователи, которых упомянули впервые.
+func (s *CommentsService) Update(userID, taskID, commentID int, body string) (*models.Comment, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := taskRole(tx, userID, taskID); err != nil {
+		return nil, err
+	}
+
+	var authorID sql.NullInt64
+	var oldBody string
+	err = tx.QueryRow(
+		"SELECT user_id, body FROM task_comments WHERE id = ? AND task_id = ?", commentID, taskID,
+	).Scan(&authorID, &oldBody)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrCommentNotFound
+		}
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	if !authorID.Valid || int(authorID.Int64) != userID {
+		return nil, ErrCommentForbidden
+	}
+	if oldBody == body {
+		return s.getComment(taskID, commentID)
+	}
+
+	now := formatDBTime(time.Now())
+	_, err = tx.Exec(
+		"INSERT INTO task_comment_revisions (comment_id, body, edited_by, created_at) VALUES (?, ?, ?, ?)",
+		commentID, oldBody, userID, now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка сохранения истории правок: %v", err)
+	}
+
+	_, err = tx.Exec(
+		"UPDATE task_comments SET body = ?, updated_at = ?, edited_at = ? WHERE id = ?",
+		body, now, now, commentID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка обновления комментария: %v", err)
+	}
+
+	if err := syncMentions(tx, userID, taskID, commentID, body); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка подтверждения транзакции: %v", err)
+	}
+
+	return s.getComment(taskID, commentID)
+}
+
+// Delete удаляет комментарий: свой — любой участник, чужой — владелец задачи или администратор проекта
+func (s *CommentsService) Delete(userID, taskID, commentID int) error {
+	role, err := taskRole(s.db, userID, taskID)
+	if err != nil {
+		return err
+	}
+
+	var authorID sql.NullInt64
+	err = s.db.QueryRow(
+		"SELECT user_id FROM task_comments WHERE id = ? AND task_id = ?", commentID, taskID,
+	).Scan(&authorID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrCommentNotFound
+		}
+		return fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	isAuthor := authorID.Valid && int(authorID.Int64) == userID
+	if !isAuthor && !roleAtLeast(role, models.ProjectRoleAdmin) {
+		return ErrCommentForbidden
+	}
+
+	if _, err := s.db.Exec("DELETE FROM task_comments WHERE id = ?", commentID); err != nil {
+		return fmt.Errorf("ошибка удаления комментария: %v", err)
+	}
+
+	return nil
+}
+
+// History возвращает прежние версии текста комментария, от старых к новым
+func (s *CommentsService) History(userID, taskID, commentID int) ([]models.CommentRevision, error) {
+	if _, err := taskRole(s.db, userID, taskID); err != nil {
+		return nil, err
+	}
+
+	var exists bool
+	err := s.db.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM task_comments WHERE id = ? AND task_id = ?)", commentID, taskID,
+	).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	if !exists {
+		return nil, ErrCommentNotFound
+	}
+
+	rows, err := s.db.Query(
+		"SELECT id, body, edited_by, created_at FROM task_comment_revisions WHERE comment_id = ? ORDER BY id",
+		commentID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	revisions := []models.CommentRevision{}
+	for rows.Next() {
+		var r models.CommentRevision
+		var editedBy sql.NullInt64
+		if err := rows.Scan(&r.ID, &r.Body, &editedBy, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("ошибка чтения истории правок: %v", err)
+		}
+		r.EditedBy = nullIntPtr(editedBy)
+		revisions = append(revisions, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации: %v", err)
+	}
+
+	return revisions, nil
+}
+
+// getComment читает комментарий вместе с упоминаниями без проверки прав
+func (s *CommentsService) getComment(taskID, commentID int) (*models.Comment, error) {
+	c, err := scanComment(s.db.QueryRow(
+		"SELECT "+commentColumns+` FROM task_comments c LEFT JOIN users u ON u.id = c.user_id
+		 WHERE c.id = ? AND c.task_id = ?`,
+		commentID, taskID,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrCommentNotFound
+		}
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	comments := []models.Comment{*c}
+	if err := s.loadMentions(comments); err != nil {
+		return nil, err
+	}
+	return &comments[0], nil
+}
+
+// loadMentions заполняет имена упомянутых пользователей одним запросом
+func (s *CommentsService) loadMentions(comments []models.Comment) error {
+	if len(comments) == 0 {
+		return nil
+	}
+
+	index := make(map[int]int, len(comments))
+	placeholders := make([]string, len(comments))
+	params := make([]interface{}, len(comments))
+	for i, c := range comments {
+		index[c.ID] = i
+		placeholders[i] = "?"
+		params[i] = c.ID
+	}
+
+	rows, err := s.db.Query(
+		`SELECT m.comment_id, u.username FROM task_comment_mentions m JOIN users u ON u.id = m.user_id
+		 WHERE m.comment_id IN (`+strings.Join(placeholders, ", ")+`) ORDER BY u.username`,
+		params...,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка получения упоминаний: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var commentID int
+		var username string
+		if err := rows.Scan(&commentID, &username); err != nil {
+			return fmt.Errorf("ошибка чтения упоминания: %v", err)
+		}
+		c := &comments[index[commentID]]
+		c.Mentions = append(c.Mentions, username)
+	}
+	return rows.Err()
+}
+
+// syncMentions сверяет упоминания комментария с текстом: @имя сопоставляется с пользователями
+// без учёта регистра, упоминания тех, у кого нет доступа к задаче, и самого автора игнорируются.
+// Уведомления создаются только для новых упоминаний.
+func syncMentions(tx *sql.Tx, authorID, taskID, commentID int, body string) error {
+	mentioned := map[int]bool{}
+	for _, username := range utils.ParseMentions(body) {
+		var id int
+		err := tx.QueryRow("SELECT id FROM users WHERE username = ? COLLATE NOCASE", username).Scan(&id)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("ошибка поиска упомянутого пользователя: %v", err)
+		}
+		if id == authorID {
+			continue
+		}
+		if _, err := taskRole(tx, id, taskID); err != nil {
+			if err == ErrTaskNotFound {
+				continue
+			}
+			return err
+		}
+		mentioned[id] = true
+	}
+
+	rows, err := tx.Query("SELECT user_id FROM task_comment_mentions WHERE comment_id = ?", commentID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения упоминаний: %v", err)
+	}
+	previous := map[int]bool{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("ошибка чтения упоминания: %v", err)
+		}
+		previous[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("ошибка при итерации: %v", err)
+	}
+
+	for id := range previous {
+		if !mentioned[id] {
+			if _, err := tx.Exec("DELETE FROM task_comment_mentions WHERE comment_id = ? AND user_id = ?", commentID, id); err != nil {
+				return fmt.Errorf("ошибка удаления упоминания: %v", err)
+			}
+		}
+	}
+	for id := range mentioned {
+		if previous[id] {
+			continue
+		}
+		if _, err := tx.Exec("INSERT INTO task_comment_mentions (comment_id, user_id) VALUES (?, ?)", commentID, id); err != nil {
+			return fmt.Errorf("ошибка сохранения упоминания: %v", err)
+		}
+		if err := createNotification(tx, id, models.NotificationMention, authorID, taskID, commentID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"server_new/config"
+	"server_new/models"
+)
+
+func TestComments_MentionsAndNotifications(t *testing.T) {
+	setupServiceDB(t)
+	ownerID := createTestUser(t, "owner")
+	memberID := createTestUser(t, "member")
+	strangerID := createTestUser(t, "stranger")
+	projects := NewProjectsService()
+	members := NewProjectMembersService()
+	tasks := NewTasksService()
+	comments := NewCommentsService()
+	notifications := NewNotificationsService()
+
+	project, err := projects.Create(ownerID, "Общий", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := members.Invite(ownerID, project.ID, "member", models.ProjectRoleViewer); err != nil {
+		t.Fatal(err)
+	}
+	if err := members.AcceptInvitation(memberID, project.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	task, err := tasks.CreateTask(ownerID, NewTask{Title: "Общая задача", Status: models.TaskStatusPending, Priority: models.PriorityNormal, ProjectID: &project.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Без доступа к задаче нельзя ни читать, ни писать
+	if _, err := comments.Create(strangerID, task.ID, "привет"); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Ожидалась ErrTaskNotFound, получено %v", err)
+	}
+	if _, _, err := comments.List(strangerID, task.ID, 1, 10); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Ожидалась ErrTaskNotFound, получено %v", err)
+	}
+
+	// Упоминания без доступа, несуществующих пользователей и самого себя не учитываются
+	comment, err := comments.Create(ownerID, task.ID, "@Member глянь, @stranger @nobody @owner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comment.Mentions) != 1 || comment.Mentions[0] != "member" {
+		t.Errorf("Упоминания = %v, ожидалось [member]", comment.Mentions)
+	}
+
+	list, total, err := notifications.List(memberID, true, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || list[0].Type != models.NotificationMention || *list[0].CommentID != comment.ID || list[0].ActorName != "owner" {
+		t.Fatalf("Неверные уведомления участника: %d, %+v", total, list)
+	}
+	if _, total, _ := notifications.List(strangerID, false, 1, 10); total != 0 {
+		t.Errorf("Пользователь без доступа не должен получать уведомления, получено %d", total)
+	}
+
+	// Участник (viewer) может комментировать, но не править чужое
+	reply, err := comments.Create(memberID, task.ID, "Готово")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := comments.Update(memberID, task.ID, comment.ID, "чужое"); !errors.Is(err, ErrCommentForbidden) {
+		t.Errorf("Ожидалась ErrCommentForbidden, получено %v", err)
+	}
+
+	// Правка сохраняет историю и не дублирует уведомление для уже упомянутого
+	edited, err := comments.Update(ownerID, task.ID, comment.ID, "@member глянь ещё раз")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edited.EditedAt == nil || edited.Body != "@member глянь ещё раз" {
+		t.Errorf("Комментарий должен быть отмечен правленым: %+v", edited)
+	}
+	history, err := comments.History(memberID, task.ID, comment.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].Body != "@Member глянь, @stranger @nobody @owner" {
+		t.Errorf("Неверная история правок: %+v", history)
+	}
+	if _, total, _ := notifications.List(memberID, false, 1, 10); total != 1 {
+		t.Errorf("Повторное упоминание не должно создавать уведомление, всего %d", total)
+	}
+
+	if err := notifications.MarkRead(ownerID, list[0].ID); !errors.Is(err, ErrNotificationNotFound) {
+		t.Errorf("Чужое уведомление: ожидалась ErrNotificationNotFound, получено %v", err)
+	}
+	if count, err := notifications.MarkAllRead(memberID); err != nil || count != 1 {
+		t.Errorf("MarkAllRead = %d, %v", count, err)
+	}
+	if _, total, _ := notifications.List(memberID, true, 1, 10); total != 0 {
+		t.Errorf("Непрочитанных не должно остаться, получено %d", total)
+	}
+
+	// Чужой комментарий удаляет владелец задачи, но не viewer
+	if err := comments.Delete(memberID, task.ID, comment.ID); !errors.Is(err, ErrCommentForbidden) {
+		t.Errorf("Ожидалась ErrCommentForbidden, получено %v", err)
+	}
+	if err := comments.Delete(ownerID, task.ID, reply.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := comments.Get(ownerID, task.ID, reply.ID); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("Ожидалась ErrCommentNotFound, получено %v", err)
+	}
+
+	all, total, err := comments.List(memberID, task.ID, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(all) != 1 || all[0].ID != comment.ID {
+		t.Errorf("Должен остаться один комментарий, получено %d", total)
+	}
+}
+
+func TestComments_DeletedWithTask(t *testing.T) {
+	setupPooledServiceDB(t)
+	ownerID := createTestUser(t, "owner")
+	memberID := createTestUser(t, "member")
+	projects := NewProjectsService()
+	members := NewProjectMembersService()
+	tasks := NewTasksService()
+	comments := NewCommentsService()
+
+	project, err := projects.Create(ownerID, "Общий", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := members.Invite(ownerID, project.ID, "member", models.ProjectRoleViewer); err != nil {
+		t.Fatal(err)
+	}
+	if err := members.AcceptInvitation(memberID, project.ID); err != nil {
+		t.Fatal(err)
+	}
+	task, err := tasks.CreateTask(ownerID, NewTask{Title: "Общая задача", Status: models.TaskStatusPending, Priority: models.PriorityNormal, ProjectID: &project.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	comment, err := comments.Create(ownerID, task.ID, "@member глянь")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := comments.Update(ownerID, task.ID, comment.ID, "@member глянь ещё раз"); err != nil {
+		t.Fatal(err)
+	}
+
+	holdConnection(t)
+	if err := tasks.DeleteTask(task.ID, ownerID); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, table := range []string{"task_comments", "task_comment_revisions", "task_comment_mentions", "notifications"} {
+		var count int
+		config.DB.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count)
+		if count != 0 {
+			t.Errorf("После удаления задачи в %s осталось строк: %d", table, count)
+		}
+	}
+
+	if _, total, err := NewNotificationsService().List(memberID, false, 1, 10); err != nil || total != 0 {
+		t.Errorf("Уведомления об удалённой задаче: %d, ошибка %v", total, err)
+	}
+}
@@ -0,0 +1,20 @@
+package services
+
+import (
+	"database/sql"
+	"time"
+)
+
+// dbTimeFormat совпадает с форматом CURRENT_TIMESTAMP в SQLite,
+// поэтому значения можно сравнивать прямо в SQL
+const dbTimeFormat = "2006-01-02 15:04:05"
+
+// formatDBTime приводит время к UTC и формату, в котором оно хранится в БД
+func formatDBTime(t time.Time) string {
+	return t.UTC().Format(dbTimeFormat)
+}
+
+// execer — общий интерфейс *sql.DB и *sql.Tx для запросов без результата
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"server_new/config"
+	"server_new/models"
+)
+
+var (
+	// ErrBlockerNotFound — блокирующая задача не найдена или недоступна пользователю
+	ErrBlockerNotFound = errors.New("блокирующая задача не найдена")
+	// ErrDependencyCycle — зависимость замкнула бы цепочку блокировок в цикл
+	ErrDependencyCycle = errors.New("зависимость создаёт цикл: задачи блокировали бы друг друга")
+	// ErrDependencyExists — такая зависимость уже есть
+	ErrDependencyExists = errors.New("зависимость уже существует")
+	// ErrDependencyNotFound — такой зависимости нет
+	ErrDependencyNotFound = errors.New("зависимость не найдена")
+	// ErrTaskBlocked — задачу блокируют незавершённые задачи
+	ErrTaskBlocked = errors.New("задачу блокируют незавершённые задачи")
+)
+
+// taskBlockedSQL — есть ли у задачи из FROM tasks незавершённые блокирующие задачи
+const taskBlockedSQL = `EXISTS (SELECT 1 FROM task_dependencies d JOIN tasks b ON b.id = d.blocked_by_id
+	WHERE d.task_id = tasks.id AND b.status != 'completed')`
+
+// ListDependencies возвращает задачи, которые блокируют taskID, и задачи, которые блокирует она.
+// В списки попадают только задачи, доступные пользователю.
+func (s *TasksService) ListDependencies(userID, taskID int) (*models.TaskDependencies, error) {
+	if _, err := taskRole(s.db, userID, taskID); err != nil {
+		return nil, err
+	}
+
+	blockedBy, err := s.dependencyTasks(userID, "SELECT blocked_by_id FROM task_dependencies WHERE task_id = ?", taskID)
+	if err != nil {
+		return nil, err
+	}
+	blocking, err := s.dependencyTasks(userID, "SELECT task_id FROM task_dependencies WHERE blocked_by_id = ?", taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TaskDependencies{BlockedBy: blockedBy, Blocking: blocking}, nil
+}
+
+// dependencyTasks читает доступные пользователю задачи с ID из подзапроса idsSQL
+func (s *TasksService) dependencyTasks(userID int, idsSQL string, taskID int) ([]models.Task, error) {
+	rows, err := s.db.Query(
+		"SELECT "+taskColumns+" FROM tasks WHERE id IN ("+idsSQL+") AND "+taskAccessSQL+" ORDER BY id",
+		taskID, userID, userID, userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	tasks := []models.Task{}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения задачи: %v", err)
+		}
+		tasks = append(tasks, *task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации: %v", err)
+	}
+	rows.Close()
+
+	if err := loadTaskTags(s.db, userID, tasks); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// AddDependency отмечает, что taskID заблокирована задачей blockerID. Нужны права editor на taskID
+// и доступ к blockerID; зависимость, замыкающая цикл, отклоняется.
+func (s *TasksService) AddDependency(userID, taskID, blockerID int) (*models.TaskDependencies, error) {
+	if taskID == blockerID {
+		return nil, ErrDependencyCycle
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := requireTaskRole(tx, userID, taskID, models.ProjectRoleEditor); err != nil {
+		return nil, err
+	}
+	if _, err := taskRole(tx, userID, blockerID); err != nil {
+		if errors.Is(err, ErrTaskNotFound) {
+			return nil, ErrBlockerNotFound
+		}
+		return nil, err
+	}
+
+	// Цикл получится, если blockerID уже (прямо или через другие задачи) заблокирована задачей taskID
+	var cycle bool
+	err = tx.QueryRow(
+		`WITH RECURSIVE blockers(id) AS (
+			SELECT blocked_by_id FROM task_dependencies WHERE task_id = ?
+			UNION
+			SELECT d.blocked_by_id FROM task_dependencies d JOIN blockers b ON d.task_id = b.id
+		)
+		SELECT EXISTS (SELECT 1 FROM blockers WHERE id = ?)`,
+		blockerID, taskID,
+	).Scan(&cycle)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка проверки цикла зависимостей: %v", err)
+	}
+	if cycle {
+		return nil, ErrDependencyCycle
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO task_dependencies (task_id, blocked_by_id, created_by, created_at) VALUES (?, ?, ?, ?)",
+		taskID, blockerID, userID, formatDBTime(time.Now()),
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint") {
+			return nil, ErrDependencyExists
+		}
+		return nil, fmt.Errorf("ошибка добавления зависимости: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка сохранения: %v", err)
+	}
+
+	return s.ListDependencies(userID, taskID)
+}
+
+// RemoveDependency снимает блокировку taskID задачей blockerID (права editor на taskID)
+func (s *TasksService) RemoveDependency(userID, taskID, blockerID int) error {
+	if err := requireTaskRole(s.db, userID, taskID, models.ProjectRoleEditor); err != nil {
+		return err
+	}
+
+	result, err := s.db.Exec(
+		"DELETE FROM task_dependencies WHERE task_id = ? AND blocked_by_id = ?",
+		taskID, blockerID,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления зависимости: %v", err)
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return ErrDependencyNotFound
+	}
+
+	return nil
+}
+
+// checkBlockers не даёт перевести задачу в completed (и в in_progress, если включено
+// config.DependenciesBlockInProgress), пока её блокируют незавершённые задачи
+func checkBlockers(tx *sql.Tx, taskID int, status string) error {
+	switch {
+	case status == models.TaskStatusCompleted:
+	case status == models.TaskStatusInProgress && config.DependenciesBlockInProgress:
+	default:
+		return nil
+	}
+
+	var blocked bool
+	err := tx.QueryRow("SELECT "+taskBlockedSQL+" FROM tasks WHERE id = ?", taskID).Scan(&blocked)
+	if err != nil {
+		return fmt.Errorf("ошибка проверки зависимостей: %v", err)
+	}
+	if blocked {
+		return ErrTaskBlocked
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"server_new/config"
+	"server_new/models"
+)
+
+func TestDependencies_Cycles(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "alice")
+	otherID := createTestUser(t, "bob")
+	tasks := NewTasksService()
+
+	a := createSubtask(t, tasks, userID, 0, "A")
+	b := createSubtask(t, tasks, userID, 0, "B")
+	c := createSubtask(t, tasks, userID, 0, "C")
+
+	// B заблокирована A, C заблокирована B
+	if _, err := tasks.AddDependency(userID, b.ID, a.ID); err != nil {
+		t.Fatal(err)
+	}
+	deps, err := tasks.AddDependency(userID, c.ID, b.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deps.BlockedBy) != 1 || deps.BlockedBy[0].ID != b.ID {
+		t.Errorf("C должна быть заблокирована B, получено %+v", deps.BlockedBy)
+	}
+
+	if _, err := tasks.AddDependency(userID, c.ID, b.ID); !errors.Is(err, ErrDependencyExists) {
+		t.Errorf("Ожидалась ErrDependencyExists, получено %v", err)
+	}
+	if _, err := tasks.AddDependency(userID, a.ID, a.ID); !errors.Is(err, ErrDependencyCycle) {
+		t.Errorf("Ожидалась ErrDependencyCycle для самой себя, получено %v", err)
+	}
+	// A заблокирована C замкнула бы цепочку A → B → C → A
+	if _, err := tasks.AddDependency(userID, a.ID, c.ID); !errors.Is(err, ErrDependencyCycle) {
+		t.Errorf("Ожидалась ErrDependencyCycle, получено %v", err)
+	}
+
+	// Чужую задачу нельзя сделать блокирующей
+	foreign := createSubtask(t, tasks, otherID, 0, "Чужая")
+	if _, err := tasks.AddDependency(userID, a.ID, foreign.ID); !errors.Is(err, ErrBlockerNotFound) {
+		t.Errorf("Ожидалась ErrBlockerNotFound, получено %v", err)
+	}
+
+	deps, err = tasks.ListDependencies(userID, b.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deps.BlockedBy) != 1 || len(deps.Blocking) != 1 || deps.Blocking[0].ID != c.ID {
+		t.Errorf("Неверные зависимости B: %+v", deps)
+	}
+
+	if err := tasks.RemoveDependency(userID, c.ID, b.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := tasks.RemoveDependency(userID, c.ID, b.ID); !errors.Is(err, ErrDependencyNotFound) {
+		t.Errorf("Ожидалась ErrDependencyNotFound, получено %v", err)
+	}
+}
+
+func TestDependencies_BlockStatusChange(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "alice")
+	tasks := NewTasksService()
+
+	blocker := createSubtask(t, tasks, userID, 0, "Сначала это")
+	task := createSubtask(t, tasks, userID, 0, "Потом это")
+	if _, err := tasks.AddDependency(userID, task.ID, blocker.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := tasks.GetTaskByID(task.ID, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Blocked {
+		t.Error("Задача должна быть заблокирована")
+	}
+
+	completed := models.TaskStatusCompleted
+	inProgress := models.TaskStatusInProgress
+	if _, err := tasks.UpdateTask(task.ID, userID, TaskUpdate{Status: &completed}); !errors.Is(err, ErrTaskBlocked) {
+		t.Errorf("Ожидалась ErrTaskBlocked, получено %v", err)
+	}
+
+	// По умолчанию взять заблокированную задачу в работу можно, с настройкой — нет
+	if _, err := tasks.UpdateTask(task.ID, userID, TaskUpdate{Status: &inProgress}); err != nil {
+		t.Errorf("in_progress по умолчанию разрешён: %v", err)
+	}
+	pending := models.TaskStatusPending
+	if _, err := tasks.UpdateTask(task.ID, userID, TaskUpdate{Status: &pending}); err != nil {
+		t.Fatal(err)
+	}
+	config.DependenciesBlockInProgress = true
+	t.Cleanup(func() { config.DependenciesBlockInProgress = false })
+	if _, err := tasks.UpdateTask(task.ID, userID, TaskUpdate{Status: &inProgress}); !errors.Is(err, ErrTaskBlocked) {
+		t.Errorf("Ожидалась ErrTaskBlocked для in_progress, получено %v", err)
+	}
+
+	// После завершения блокирующей задачи ограничение снимается
+	if _, err := tasks.UpdateTask(blocker.ID, userID, TaskUpdate{Status: &completed}); err != nil {
+		t.Fatal(err)
+	}
+	got, err = tasks.UpdateTask(task.ID, userID, TaskUpdate{Status: &completed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Blocked {
+		t.Error("Задача не должна быть заблокирована")
+	}
+}
+
+func TestDependencies_DeletedBlockerUnblocks(t *testing.T) {
+	setupPooledServiceDB(t)
+	userID := createTestUser(t, "alice")
+	tasks := NewTasksService()
+
+	task := createSubtask(t, tasks, userID, 0, "Задача")
+	blocker := createSubtask(t, tasks, userID, 0, "Блокер")
+	if _, err := tasks.AddDependency(userID, task.ID, blocker.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	holdConnection(t)
+	if err := tasks.DeleteTask(blocker.ID, userID); err != nil {
+		t.Fatal(err)
+	}
+
+	var links int
+	config.DB.QueryRow("SELECT COUNT(*) FROM task_dependencies WHERE blocked_by_id = ?", blocker.ID).Scan(&links)
+	if links != 0 {
+		t.Errorf("После удаления блокера осталось зависимостей: %d", links)
+	}
+
+	deps, err := tasks.ListDependencies(userID, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deps.BlockedBy) != 0 {
+		t.Errorf("Удалённая задача всё ещё в блокерах: %+v", deps.BlockedBy)
+	}
+
+	completed := models.TaskStatusCompleted
+	if _, err := tasks.UpdateTask(task.ID, userID, TaskUpdate{Status: &completed}); err != nil {
+		t.Errorf("Задача без блокеров должна завершаться: %v", err)
+	}
+}
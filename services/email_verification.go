@@ -0,0 +1,196 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"server_new/config"
+	"server_new/mailer"
+	"server_new/utils"
+)
+
+var (
+	// ErrInvalidVerificationToken — токен не найден, использован или истёк
+	ErrInvalidVerificationToken = errors.New("ссылка подтверждения недействительна или устарела")
+	// ErrEmailTaken — адрес уже используется другим аккаунтом
+	ErrEmailTaken = errors.New("этот email уже занят")
+)
+
+// ResendThrottledError — письмо уже отправлялось недавно
+type ResendThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ResendThrottledError) Error() string {
+	return fmt.Sprintf("письмо уже отправлено, повторить можно через %d с", int(e.RetryAfter.Seconds()))
+}
+
+// EmailVerificationService отвечает за подтверждение email при регистрации и смене адреса
+type EmailVerificationService struct {
+	db     *sql.DB
+	mailer mailer.Mailer
+}
+
+// NewEmailVerificationService создаёт новый экземпляр сервиса
+func NewEmailVerificationService() *EmailVerificationService {
+	return &EmailVerificationService{db: config.DB, mailer: mailer.Default}
+}
+
+// IsVerified проверяет, подтверждён ли email пользователя
+func (s *EmailVerificationService) IsVerified(userID int) (bool, error) {
+	var verified bool
+	err := s.db.QueryRow(
+		"SELECT email_verified_at IS NOT NULL FROM users WHERE id = ?",
+		userID,
+	).Scan(&verified)
+	if err != nil {
+		return false, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	return verified, nil
+}
+
+// SendVerification отправляет ссылку для подтверждения адреса email.
+// Предыдущие неиспользованные ссылки пользователя аннулируются.
+func (s *EmailVerificationService) SendVerification(userID int, email string) error {
+	token, err := utils.GenerateSecureToken()
+	if err != nil {
+		return fmt.Errorf("ошибка генерации токена: %v", err)
+	}
+
+	now := time.Now()
+
+	_, err = s.db.Exec(
+		"UPDATE email_verifications SET used_at = ? WHERE user_id = ? AND used_at IS NULL",
+		formatDBTime(now), userID,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления старых токенов: %v", err)
+	}
+
+	_, err = s.db.Exec(
+		"INSERT INTO email_verifications (user_id, email, token_hash, expires_at) VALUES (?, ?, ?, ?)",
+		userID, email, utils.HashToken(token), formatDBTime(now.Add(config.EmailVerificationTTL)),
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения токена: %v", err)
+	}
+
+	link := fmt.Sprintf("%s/auth/verify?token=%s", config.AppBaseURL, token)
+	body := fmt.Sprintf(
+		"Подтвердите адрес электронной почты, перейдя по ссылке:\n%s\n\nСсылка действует %s.",
+		link, config.EmailVerificationTTL,
+	)
+
+	if err := s.mailer.Send(mailer.Message{To: email, Subject: "Подтверждение email", Body: body}); err != nil {
+		return fmt.Errorf("ошибка отправки письма: %v", err)
+	}
+	return nil
+}
+
+// Resend повторно отправляет письмо подтверждения не чаще раза в VerificationResendWait.
+// Для неизвестных и уже подтверждённых адресов ничего не делает.
+func (s *EmailVerificationService) Resend(email string) error {
+	var userID int
+	var verified bool
+	err := s.db.QueryRow(
+		"SELECT id, email_verified_at IS NOT NULL FROM users WHERE email = ?",
+		email,
+	).Scan(&userID, &verified)
+	if err == sql.ErrNoRows || (err == nil && verified) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	if err := s.checkThrottle(userID); err != nil {
+		return err
+	}
+	return s.SendVerification(userID, email)
+}
+
+// RequestEmailChange отправляет подтверждение на новый адрес.
+// Сам адрес в users меняется только после перехода по ссылке.
+func (s *EmailVerificationService) RequestEmailChange(userID int, newEmail string) error {
+	var taken bool
+	err := s.db.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM users WHERE email = ? AND id != ?)",
+		newEmail, userID,
+	).Scan(&taken)
+	if err != nil {
+		return fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	if taken {
+		return ErrEmailTaken
+	}
+
+	if err := s.checkThrottle(userID); err != nil {
+		return err
+	}
+	return s.SendVerification(userID, newEmail)
+}
+
+// Verify подтверждает адрес по токену из письма.
+// Если адрес в токене отличается от текущего, email пользователя меняется на него.
+func (s *EmailVerificationService) Verify(token string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	now := formatDBTime(time.Now())
+
+	var verificationID, userID int
+	var email string
+	err = tx.QueryRow(
+		"SELECT id, user_id, email FROM email_verifications WHERE token_hash = ? AND used_at IS NULL AND expires_at > ?",
+		utils.HashToken(token), now,
+	).Scan(&verificationID, &userID, &email)
+	if err == sql.ErrNoRows {
+		return ErrInvalidVerificationToken
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	_, err = tx.Exec(
+		"UPDATE users SET email = ?, email_verified_at = ? WHERE id = ?",
+		email, now, userID,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint") {
+			return ErrEmailTaken
+		}
+		return fmt.Errorf("ошибка обновления пользователя: %v", err)
+	}
+
+	if _, err := tx.Exec("UPDATE email_verifications SET used_at = ? WHERE id = ?", now, verificationID); err != nil {
+		return fmt.Errorf("ошибка обновления токена: %v", err)
+	}
+
+	return tx.Commit()
+}
+
+// checkThrottle не даёт отправлять письма чаще, чем раз в VerificationResendWait
+func (s *EmailVerificationService) checkThrottle(userID int) error {
+	var lastSent time.Time
+	err := s.db.QueryRow(
+		"SELECT created_at FROM email_verifications WHERE user_id = ? ORDER BY created_at DESC LIMIT 1",
+		userID,
+	).Scan(&lastSent)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	if wait := lastSent.Add(config.VerificationResendWait).Sub(time.Now()); wait > 0 {
+		return &ResendThrottledError{RetryAfter: wait}
+	}
+	return nil
+}
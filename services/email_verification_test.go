@@ -0,0 +1,88 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"server_new/config"
+	"server_new/mailer"
+)
+
+// fakeMailer запоминает отправленные письма
+type fakeMailer struct {
+	sent []mailer.Message
+}
+
+func (m *fakeMailer) Send(msg mailer.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+var tokenInLink = regexp.MustCompile(`token=([A-Za-z0-9_-]+)`)
+
+// lastToken извлекает токен из последнего письма
+func (m *fakeMailer) lastToken(t *testing.T) string {
+	t.Helper()
+	if len(m.sent) == 0 {
+		t.Fatal("письмо не отправлено")
+	}
+	match := tokenInLink.FindStringSubmatch(m.sent[len(m.sent)-1].Body)
+	if match == nil {
+		t.Fatal("в письме нет токена")
+	}
+	return match[1]
+}
+
+func TestEmailVerificationService_ChangeEmail(t *testing.T) {
+	setupServiceDB(t)
+	config.EmailVerificationTTL = time.Hour
+	config.VerificationResendWait = time.Minute
+
+	userID := createTestUser(t, "erin")
+	createTestUser(t, "frank")
+
+	fake := &fakeMailer{}
+	service := &EmailVerificationService{db: config.DB, mailer: fake}
+
+	if err := service.RequestEmailChange(userID, "frank@example.com"); !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("RequestEmailChange(занятый адрес) error = %v, want %v", err, ErrEmailTaken)
+	}
+
+	if err := service.RequestEmailChange(userID, "erin@new.example.com"); err != nil {
+		t.Fatal("RequestEmailChange():", err)
+	}
+	if fake.sent[0].To != "erin@new.example.com" {
+		t.Errorf("письмо отправлено на %s, want новый адрес", fake.sent[0].To)
+	}
+
+	// Повторный запрос сразу же ограничен по частоте
+	var throttled *ResendThrottledError
+	if err := service.RequestEmailChange(userID, "erin@other.example.com"); !errors.As(err, &throttled) {
+		t.Errorf("RequestEmailChange(повтор) error = %v, want ResendThrottledError", err)
+	}
+
+	// До подтверждения адрес не меняется
+	var email string
+	config.DB.QueryRow("SELECT email FROM users WHERE id = ?", userID).Scan(&email)
+	if email != "erin@example.com" {
+		t.Errorf("email до подтверждения = %s", email)
+	}
+
+	token := fake.lastToken(t)
+	if err := service.Verify(token); err != nil {
+		t.Fatal("Verify():", err)
+	}
+	if err := service.Verify(token); !errors.Is(err, ErrInvalidVerificationToken) {
+		t.Errorf("Verify(повтор) error = %v, want %v", err, ErrInvalidVerificationToken)
+	}
+
+	config.DB.QueryRow("SELECT email FROM users WHERE id = ?", userID).Scan(&email)
+	if email != "erin@new.example.com" {
+		t.Errorf("email после подтверждения = %s", email)
+	}
+	if verified, _ := service.IsVerified(userID); !verified {
+		t.Error("IsVerified() = false после подтверждения")
+	}
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"server_new/config"
+	"server_new/utils"
+)
+
+// LoginGuardService считает неудачные попытки входа по аккаунту и по IP
+// и временно блокирует вход с экспоненциально растущей длительностью
+type LoginGuardService struct {
+	db *sql.DB
+}
+
+// NewLoginGuardService создаёт новый экземпляр сервиса
+func NewLoginGuardService() *LoginGuardService {
+	return &LoginGuardService{db: config.DB}
+}
+
+// AccountKey — ключ счётчика для аккаунта
+func AccountKey(email string) string {
+	return "account:" + email
+}
+
+// IPKey — ключ счётчика для IP адреса
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// RegistrationAccountKey — ключ счётчика регистраций на email (учитывается каждая попытка)
+func RegistrationAccountKey(email string) string {
+	return "register:account:" + email
+}
+
+// RegistrationIPKey — ключ счётчика регистраций с IP адреса
+func RegistrationIPKey(ip string) string {
+	return "register:ip:" + ip
+}
+
+// ResetAccountKey — ключ счётчика запросов сброса пароля для email
+func ResetAccountKey(email string) string {
+	return "reset:account:" + email
+}
+
+// ResetIPKey — ключ счётчика запросов сброса пароля и неверных токенов сброса с IP адреса
+func ResetIPKey(ip string) string {
+	return "reset:ip:" + ip
+}
+
+// LockedFor возвращает, сколько ещё длится блокировка (0 — блокировки нет).
+// Если заблокировано несколько ключей, возвращается наибольший срок.
+func (s *LoginGuardService) LockedFor(keys ...string) (time.Duration, error) {
+	now := time.Now()
+	var longest time.Duration
+
+	for _, key := range keys {
+		var lockedUntil sql.NullTime
+		err := s.db.QueryRow("SELECT locked_until FROM auth_failures WHERE key = ?", key).Scan(&lockedUntil)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("ошибка запроса к БД: %v", err)
+		}
+		if lockedUntil.Valid {
+			if wait := lockedUntil.Time.Sub(now); wait > longest {
+				longest = wait
+			}
+		}
+	}
+
+	return longest, nil
+}
+
+// RegisterFailure учитывает неудачную попытку для каждого ключа.
+// Возвращает срок блокировки, если она наступила (0 — ещё можно пробовать).
+func (s *LoginGuardService) RegisterFailure(ip string, keys ...string) (time.Duration, error) {
+	var longest time.Duration
+	for _, key := range keys {
+		lock, err := s.registerFailure(key, ip)
+		if err != nil {
+			return 0, err
+		}
+		if lock > longest {
+			longest = lock
+		}
+	}
+	return longest, nil
+}
+
+// RegisterSuccess сбрасывает счётчики после успешного входа
+func (s *LoginGuardService) RegisterSuccess(keys ...string) error {
+	for _, key := range keys {
+		if _, err := s.db.Exec("DELETE FROM auth_failures WHERE key = ?", key); err != nil {
+			return fmt.Errorf("ошибка сброса счётчика попыток: %v", err)
+		}
+	}
+	return nil
+}
+
+// registerFailure увеличивает счётчик ключа и при превышении лимита блокирует его
+func (s *LoginGuardService) registerFailure(key, ip string) (time.Duration, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+
+	var failures int
+	var lastFailure time.Time
+	err = tx.QueryRow(
+		"SELECT failures, last_failure_at FROM auth_failures WHERE key = ?",
+		key,
+	).Scan(&failures, &lastFailure)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	// Давние неудачи не учитываем
+	if err == sql.ErrNoRows || now.Sub(lastFailure) > config.LoginFailureWindow {
+		failures = 0
+	}
+	failures++
+
+	var lockedUntil interface{}
+	lock := lockoutDuration(failures, maxAttemptsFor(key))
+	if lock > 0 {
+		lockedUntil = formatDBTime(now.Add(lock))
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO auth_failures (key, failures, last_failure_at, locked_until) VALUES (?, ?, ?, ?)
+		 ON CONFLICT(key) DO UPDATE SET failures = excluded.failures,
+		     last_failure_at = excluded.last_failure_at, locked_until = excluded.locked_until`,
+		key, failures, formatDBTime(now), lockedUntil,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка сохранения попытки: %v", err)
+	}
+
+	if lock > 0 {
+		_, err = tx.Exec(
+			"INSERT INTO lockout_events (key, ip, failures, locked_until) VALUES (?, ?, ?, ?)",
+			key, ip, failures, lockedUntil,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("ошибка записи в журнал блокировок: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("ошибка сохранения попытки: %v", err)
+	}
+
+	if lock > 0 {
+		utils.LogWarn("Вход временно заблокирован", "key", key, "ip", ip, "failures", failures, "lockout", lock.String())
+	}
+	return lock, nil
+}
+
+// maxAttemptsFor возвращает лимит попыток для ключа: для IP он выше, так как
+// за одним адресом может быть много пользователей
+func maxAttemptsFor(key string) int {
+	for _, prefix := range []string{"ip:", "register:ip:", "reset:ip:"} {
+		if strings.HasPrefix(key, prefix) {
+			return config.LoginMaxAttemptsPerIP
+		}
+	}
+	return config.LoginMaxAttempts
+}
+
+// lockoutDuration вычисляет срок блокировки: после limit неудач — LockoutBase,
+// и далее удваивается с каждой следующей неудачей, но не больше LockoutMax
+func lockoutDuration(failures, limit int) time.Duration {
+	if limit <= 0 || failures < limit {
+		return 0
+	}
+
+	lock := config.LockoutBase
+	for i := limit; i < failures && lock < config.LockoutMax; i++ {
+		lock *= 2
+	}
+	if lock > config.LockoutMax {
+		lock = config.LockoutMax
+	}
+	return lock
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"server_new/config"
+)
+
+func setupLoginGuard(t *testing.T) {
+	t.Helper()
+	setupServiceDB(t)
+	config.LoginMaxAttempts = 3
+	config.LoginMaxAttemptsPerIP = 10
+	config.LoginFailureWindow = 15 * time.Minute
+	config.LockoutBase = time.Minute
+	config.LockoutMax = 4 * time.Minute
+}
+
+func TestLockoutDuration(t *testing.T) {
+	setupLoginGuard(t)
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, 0},
+		{2, 0},
+		{3, time.Minute},
+		{4, 2 * time.Minute},
+		{5, 4 * time.Minute},
+		{10, 4 * time.Minute}, // не больше LockoutMax
+	}
+
+	for _, tt := range tests {
+		if got := lockoutDuration(tt.failures, 3); got != tt.want {
+			t.Errorf("lockoutDuration(%d) = %v, ожидалось %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLoginGuard_LocksAccountAfterLimit(t *testing.T) {
+	setupLoginGuard(t)
+	service := NewLoginGuardService()
+	account := AccountKey("alice@example.com")
+	ip := IPKey("10.0.0.1")
+
+	for i := 1; i <= 2; i++ {
+		lock, err := service.RegisterFailure("10.0.0.1", account, ip)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lock != 0 {
+			t.Fatalf("попытка %d: блокировка раньше лимита", i)
+		}
+	}
+
+	lock, err := service.RegisterFailure("10.0.0.1", account, ip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lock != time.Minute {
+		t.Fatalf("Ожидалась блокировка на минуту, получено %v", lock)
+	}
+
+	wait, err := service.LockedFor(account, ip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wait <= 0 || wait > time.Minute {
+		t.Errorf("LockedFor = %v, ожидалось (0, 1m]", wait)
+	}
+
+	// Лимит для IP выше, поэтому сам IP ещё не заблокирован
+	if wait, _ := service.LockedFor(ip); wait != 0 {
+		t.Errorf("IP не должен быть заблокирован, LockedFor = %v", wait)
+	}
+
+	var events int
+	config.DB.QueryRow("SELECT COUNT(*) FROM lockout_events WHERE key = ?", account).Scan(&events)
+	if events != 1 {
+		t.Errorf("Ожидалась 1 запись в журнале блокировок, получено %d", events)
+	}
+}
+
+func TestLoginGuard_SuccessResetsCounter(t *testing.T) {
+	setupLoginGuard(t)
+	service := NewLoginGuardService()
+	account := AccountKey("bob@example.com")
+
+	for i := 0; i < 2; i++ {
+		if _, err := service.RegisterFailure("10.0.0.2", account); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := service.RegisterSuccess(account); err != nil {
+		t.Fatal(err)
+	}
+
+	// После сброса счёт начинается заново: третья неудача ещё не блокирует
+	lock, err := service.RegisterFailure("10.0.0.2", account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lock != 0 {
+		t.Errorf("Ожидалось отсутствие блокировки после успешного входа, получено %v", lock)
+	}
+}
+
+func TestLoginGuard_RegistrationAndResetKeys(t *testing.T) {
+	setupLoginGuard(t)
+	service := NewLoginGuardService()
+
+	// Ключи регистрации и сброса не пересекаются со счётчиками входа
+	for i := 0; i < 3; i++ {
+		if _, err := service.RegisterFailure("10.0.0.3", ResetAccountKey("carol@example.com")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if wait, _ := service.LockedFor(ResetAccountKey("carol@example.com")); wait == 0 {
+		t.Error("Ожидалась блокировка запросов сброса для адреса")
+	}
+	if wait, _ := service.LockedFor(AccountKey("carol@example.com"), RegistrationAccountKey("carol@example.com")); wait != 0 {
+		t.Errorf("Вход и регистрация не должны блокироваться, LockedFor = %v", wait)
+	}
+
+	// Для IP ключей регистрации и сброса действует лимит per-IP
+	for _, key := range []string{RegistrationIPKey("10.0.0.3"), ResetIPKey("10.0.0.3")} {
+		if got := maxAttemptsFor(key); got != config.LoginMaxAttemptsPerIP {
+			t.Errorf("maxAttemptsFor(%s) = %d, ожидалось %d", key, got, config.LoginMaxAttemptsPerIP)
+		}
+	}
+	if got := maxAttemptsFor(RegistrationAccountKey("carol@example.com")); got != config.LoginMaxAttempts {
+		t.Errorf("maxAttemptsFor(аккаунт) = %d, ожидалось %d", got, config.LoginMaxAttempts)
+	}
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"server_new/config"
+	"server_new/models"
+)
+
+// ErrNotificationNotFound — уведомление не найдено или адресовано другому пользователю
+var ErrNotificationNotFound = errors.New("уведомление не найдено")
+
+// NotificationsService содержит методы для работы с уведомлениями пользователя
+type NotificationsService struct {
+	db *sql.DB
+}
+
+// NewNotificationsService создаёт новый экземпляр сервиса
+func NewNotificationsService() *NotificationsService {
+	return &NotificationsService{db: config.DB}
+}
+
+// createNotification добавляет уведомление для userID (taskID и commentID — 0, если не относятся)
+func createNotification(e execer, userID int, notificationType string, actorID, taskID, commentID int) error {
+	_, err := e.Exec(
+		`INSERT INTO notifications (user_id, type, actor_id, task_id, comment_id, created_at)
+		 VALUES (?, ?, ?, NULLIF(?, 0), NULLIF(?, 0), ?)`,
+		userID, notificationType, actorID, taskID, commentID, formatDBTime(time.Now()),
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка создания уведомления: %v", err)
+	}
+	return nil
+}
+
+// List возвращает уведомления пользователя, новые первыми; unreadOnly — только непрочитанные
+func (s *NotificationsService) List(userID int, unreadOnly bool, page, limit int) ([]models.Notification, int, error) {
+	where := "n.user_id = ?"
+	if unreadOnly {
+		where += " AND n.read_at IS NULL"
+	}
+
+	rows, err := s.db.Query(
+		`SELECT n.id, n.type, n.actor_id, COALESCE(u.username, ''), n.task_id, n.comment_id, n.read_at, n.created_at
+		 FROM notifications n LEFT JOIN users u ON u.id = n.actor_id
+		 WHERE `+where+` ORDER BY n.id DESC LIMIT ? OFFSET ?`,
+		userID, limit, (page-1)*limit,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		var n models.Notification
+		var actorID, taskID, commentID sql.NullInt64
+		var readAt sql.NullTime
+		if err := rows.Scan(&n.ID, &n.Type, &actorID, &n.ActorName, &taskID, &commentID, &readAt, &n.CreatedAt); err != nil {
+			return nil, 0, fmt.Errorf("ошибка чтения уведомления: %v", err)
+		}
+		n.ActorID = nullIntPtr(actorID)
+		n.TaskID = nullIntPtr(taskID)
+		n.CommentID = nullIntPtr(commentID)
+		if readAt.Valid {
+			n.ReadAt = &readAt.Time
+		}
+		notifications = append(notifications, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("ошибка при итерации: %v", err)
+	}
+
+	var total int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM notifications n WHERE "+where, userID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("ошибка получения количества: %v", err)
+	}
+
+	return notifications, total, nil
+}
+
+// MarkRead отмечает уведомление прочитанным
+func (s *NotificationsService) MarkRead(userID, notificationID int) error {
+	result, err := s.db.Exec(
+		"UPDATE notifications SET read_at = COALESCE(read_at, ?) WHERE id = ? AND user_id = ?",
+		formatDBTime(time.Now()), notificationID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления уведомления: %v", err)
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return ErrNotificationNotFound
+	}
+
+	return nil
+}
+
+// MarkAllRead отмечает прочитанными все уведомления пользователя и возвращает их количество
+func (s *NotificationsService) MarkAllRead(userID int) (int, error) {
+	result, err := s.db.Exec(
+		"UPDATE notifications SET read_at = ? WHERE user_id = ? AND read_at IS NULL",
+		formatDBTime(time.Now()), userID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка обновления уведомлений: %v", err)
+	}
+
+	count, _ := result.RowsAffected()
+	return int(count), nil
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"server_new/config"
+	"server_new/mailer"
+	"server_new/utils"
+)
+
+// ErrInvalidResetToken — токен не найден, уже использован или истёк
+var ErrInvalidResetToken = errors.New("ссылка для сброса пароля недействительна или устарела")
+
+// PasswordResetService реализует сброс пароля через одноразовый токен
+type PasswordResetService struct {
+	db     *sql.DB
+	mailer mailer.Mailer
+}
+
+// NewPasswordResetService создаёт новый экземпляр сервиса
+func NewPasswordResetService() *PasswordResetService {
+	return &PasswordResetService{db: config.DB, mailer: mailer.Default}
+}
+
+// RequestReset создаёт токен сброса и отправляет его на почту.
+// Если пользователя с таким email нет, молча ничего не делает,
+// чтобы по ответу нельзя было узнать, зарегистрирован ли адрес.
+func (s *PasswordResetService) RequestReset(email string) error {
+	var userID int
+	err := s.db.QueryRow("SELECT id FROM users WHERE email = ?", email).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	return s.SendResetLink(userID, email)
+}
+
+// SendResetLink создаёт токен сброса для пользователя и отправляет ссылку на email
+func (s *PasswordResetService) SendResetLink(userID int, email string) error {
+	token, err := utils.GenerateSecureToken()
+	if err != nil {
+		return fmt.Errorf("ошибка генерации токена: %v", err)
+	}
+
+	now := time.Now()
+
+	// Старые неиспользованные токены больше не нужны: действует только последний
+	_, err = s.db.Exec(
+		"UPDATE password_resets SET used_at = ? WHERE user_id = ? AND used_at IS NULL",
+		formatDBTime(now), userID,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления старых токенов: %v", err)
+	}
+
+	_, err = s.db.Exec(
+		"INSERT INTO password_resets (user_id, token_hash, expires_at) VALUES (?, ?, ?)",
+		userID, utils.HashToken(token), formatDBTime(now.Add(config.PasswordResetTTL)),
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения токена: %v", err)
+	}
+
+	link := fmt.Sprintf("%s/reset-password.html?token=%s", config.AppBaseURL, token)
+	body := fmt.Sprintf(
+		"Вы запросили сброс пароля.\n\nЧтобы задать новый пароль, перейдите по ссылке:\n%s\n\n"+
+			"Или используйте токен: %s\n\nСсылка действует %s. Если вы не запрашивали сброс, просто проигнорируйте это письмо.",
+		link, token, config.PasswordResetTTL,
+	)
+
+	if err := s.mailer.Send(mailer.Message{To: email, Subject: "Сброс пароля", Body: body}); err != nil {
+		return fmt.Errorf("ошибка отправки письма: %v", err)
+	}
+
+	return nil
+}
+
+// ConfirmReset проверяет токен и устанавливает новый пароль
+func (s *PasswordResetService) ConfirmReset(token, newPassword string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	now := formatDBTime(time.Now())
+
+	var resetID, userID int
+	err = tx.QueryRow(
+		"SELECT id, user_id FROM password_resets WHERE token_hash = ? AND used_at IS NULL AND expires_at > ?",
+		utils.HashToken(token), now,
+	).Scan(&resetID, &userID)
+	if err == sql.ErrNoRows {
+		return ErrInvalidResetToken
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	hashedPassword, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return fmt.Errorf("ошибка хэширования пароля: %v", err)
+	}
+
+	// Переход по ссылке из письма заодно подтверждает владение адресом
+	_, err = tx.Exec(
+		"UPDATE users SET password = ?, email_verified_at = COALESCE(email_verified_at, ?) WHERE id = ?",
+		hashedPassword, now, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления пароля: %v", err)
+	}
+
+	// После смены пароля завершаем все сессии пользователя
+	if err := revokeUserSessions(tx, userID, time.Now()); err != nil {
+		return err
+	}
+
+	// Помечаем токен использованным (условие used_at IS NULL защищает от гонки)
+	result, err := tx.Exec(
+		"UPDATE password_resets SET used_at = ? WHERE id = ? AND used_at IS NULL",
+		now, resetID,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления токена: %v", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrInvalidResetToken
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"server_new/config"
+	"server_new/utils"
+)
+
+func TestPasswordResetService_SingleUse(t *testing.T) {
+	setupServiceDB(t)
+	config.PasswordResetTTL = time.Hour
+
+	userID := createTestUser(t, "grace")
+	fake := &fakeMailer{}
+	service := &PasswordResetService{db: config.DB, mailer: fake}
+
+	if err := service.RequestReset("grace@example.com"); err != nil {
+		t.Fatal("RequestReset():", err)
+	}
+	if fake.sent[0].To != "grace@example.com" {
+		t.Errorf("письмо отправлено на %s, want grace@example.com", fake.sent[0].To)
+	}
+	token := fake.lastToken(t)
+
+	if err := service.ConfirmReset(token, "new-password-1"); err != nil {
+		t.Fatal("ConfirmReset():", err)
+	}
+
+	var hash string
+	if err := config.DB.QueryRow("SELECT password FROM users WHERE id = ?", userID).Scan(&hash); err != nil {
+		t.Fatal(err)
+	}
+	if !utils.CheckPassword("new-password-1", hash) {
+		t.Error("пароль не изменился")
+	}
+
+	// Тот же токен второй раз не срабатывает
+	if err := service.ConfirmReset(token, "new-password-2"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Errorf("ConfirmReset(повтор) error = %v, want %v", err, ErrInvalidResetToken)
+	}
+}
+
+func TestPasswordResetService_ExpiredToken(t *testing.T) {
+	setupServiceDB(t)
+	config.PasswordResetTTL = time.Hour
+
+	userID := createTestUser(t, "heidi")
+	fake := &fakeMailer{}
+	service := &PasswordResetService{db: config.DB, mailer: fake}
+
+	if err := service.RequestReset("heidi@example.com"); err != nil {
+		t.Fatal("RequestReset():", err)
+	}
+	token := fake.lastToken(t)
+
+	_, err := config.DB.Exec(
+		"UPDATE password_resets SET expires_at = ? WHERE user_id = ?",
+		formatDBTime(time.Now().Add(-time.Minute)), userID,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.ConfirmReset(token, "new-password-1"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Errorf("ConfirmReset(истёкший) error = %v, want %v", err, ErrInvalidResetToken)
+	}
+}
+
+func TestPasswordResetService_UnknownEmail(t *testing.T) {
+	setupServiceDB(t)
+	config.PasswordResetTTL = time.Hour
+
+	fake := &fakeMailer{}
+	service := &PasswordResetService{db: config.DB, mailer: fake}
+
+	// Ответ не должен выдавать, зарегистрирован ли адрес
+	if err := service.RequestReset("nobody@example.com"); err != nil {
+		t.Errorf("RequestReset(неизвестный email) error = %v, want nil", err)
+	}
+	if len(fake.sent) != 0 {
+		t.Errorf("отправлено писем: %d, want 0", len(fake.sent))
+	}
+}
+
+func TestPasswordResetService_RevokesSessions(t *testing.T) {
+	setupServiceDB(t)
+	config.PasswordResetTTL = time.Hour
+
+	userID := createTestUser(t, "ivan")
+	auth := NewAuthService()
+	pair, err := auth.IssueTokens(userID, "test-agent", "127.0.0.1")
+	if err != nil {
+		t.Fatal("IssueTokens():", err)
+	}
+
+	fake := &fakeMailer{}
+	service := &PasswordResetService{db: config.DB, mailer: fake}
+	if err := service.RequestReset("ivan@example.com"); err != nil {
+		t.Fatal("RequestReset():", err)
+	}
+	if err := service.ConfirmReset(fake.lastToken(t), "new-password-1"); err != nil {
+		t.Fatal("ConfirmReset():", err)
+	}
+
+	if _, err := auth.Refresh(pair.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("Refresh(после сброса) error = %v, want %v", err, ErrInvalidRefreshToken)
+	}
+
+	var active int
+	if err := config.DB.QueryRow(
+		"SELECT COUNT(*) FROM sessions WHERE user_id = ? AND revoked_at IS NULL", userID,
+	).Scan(&active); err != nil {
+		t.Fatal(err)
+	}
+	if active != 0 {
+		t.Errorf("активных сессий: %d, want 0", active)
+	}
+}
@@ -0,0 +1,327 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"server_new/config"
+	"server_new/models"
+)
+
+var (
+	// ErrProjectForbidden — роли пользователя в проекте не хватает для действия
+	ErrProjectForbidden = errors.New("недостаточно прав в проекте")
+	// ErrInvalidProjectRole — роль участника должна быть viewer, editor или admin
+	ErrInvalidProjectRole = errors.New("роль должна быть: viewer, editor или admin")
+	// ErrMemberExists — пользователь уже участник проекта, приглашён или владеет им
+	ErrMemberExists = errors.New("пользователь уже участник проекта или приглашён")
+	// ErrMemberNotFound — участник не найден
+	ErrMemberNotFound = errors.New("участник не найден")
+	// ErrInvitationNotFound — приглашение не найдено или уже принято
+	ErrInvitationNotFound = errors.New("приглашение не найдено")
+)
+
+// accessibleProjectsSQL — подзапрос с ID проектов, доступных пользователю:
+// своих и тех, где он принятый участник. Параметры: userID, userID.
+const accessibleProjectsSQL = `SELECT id FROM projects WHERE user_id = ?
+	UNION SELECT project_id FROM project_members WHERE user_id = ? AND status = 'accepted'`
+
+// projectRoleSQL — роль пользователя в проекте p (NULL, если доступа нет). Параметры: userID, userID.
+const projectRoleSQL = `CASE WHEN p.user_id = ? THEN 'owner'
+	ELSE (SELECT m.role FROM project_members m WHERE m.project_id = p.id AND m.user_id = ? AND m.status = 'accepted') END`
+
+// projectRole возвращает роль пользователя в проекте. Если доступа нет — ErrProjectNotFound,
+// чтобы не раскрывать существование чужих проектов.
+func projectRole(q queryRower, userID, projectID int) (string, error) {
+	var role sql.NullString
+	err := q.QueryRow("SELECT "+projectRoleSQL+" FROM projects p WHERE p.id = ?", userID, userID, projectID).Scan(&role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", ErrProjectNotFound
+		}
+		return "", fmt.Errorf("ошибка проверки доступа к проекту: %v", err)
+	}
+	if !role.Valid {
+		return "", ErrProjectNotFound
+	}
+	return role.String, nil
+}
+
+// requireProjectRole проверяет, что роль пользователя в проекте не ниже minRole
+func requireProjectRole(q queryRower, userID, projectID int, minRole string) (string, error) {
+	role, err := projectRole(q, userID, projectID)
+	if err != nil {
+		return "", err
+	}
+	if !roleAtLeast(role, minRole) {
+		return role, ErrProjectForbidden
+	}
+	return role, nil
+}
+
+// roleAtLeast сравнивает роли в проекте
+func roleAtLeast(role, minRole string) bool {
+	rank, _ := models.ProjectRoleRank(role)
+	minRank, _ := models.ProjectRoleRank(minRole)
+	return rank >= minRank
+}
+
+// ProjectMembersService управляет участниками общих проектов и приглашениями
+type ProjectMembersService struct {
+	db *sql.DB
+}
+
+// NewProjectMembersService создаёт новый экземпляр сервиса
+func NewProjectMembersService() *ProjectMembersService {
+	return &ProjectMembersService{db: config.DB}
+}
+
+// List возвращает участников проекта и приглашённых (доступно любому участнику)
+func (s *ProjectMembersService) List(userID, projectID int) ([]models.ProjectMember, error) {
+	if _, err := projectRole(s.db, userID, projectID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(
+		`SELECT m.user_id, u.username, u.email, m.role, m.status, m.invited_by, m.created_at, m.accepted_at
+		 FROM project_members m JOIN users u ON u.id = m.user_id
+		 WHERE m.project_id = ?
+		 ORDER BY m.created_at, m.user_id`,
+		projectID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	members := []models.ProjectMember{}
+	for rows.Next() {
+		member, err := scanMember(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения участника: %v", err)
+		}
+		members = append(members, *member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации: %v", err)
+	}
+
+	return members, nil
+}
+
+// Invite приглашает зарегистрированного пользователя (по email или имени) в проект.
+// Приглашать может admin проекта, назначать роль admin — только владелец.
+func (s *ProjectMembersService) Invite(actorID, projectID int, login, role string) (*models.ProjectMember, error) {
+	if !isMemberRole(role) {
+		return nil, ErrInvalidProjectRole
+	}
+
+	actorRole, err := requireProjectRole(s.db, actorID, projectID, models.ProjectRoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if role == models.ProjectRoleAdmin && actorRole != models.ProjectRoleOwner {
+		return nil, ErrProjectForbidden
+	}
+
+	var inviteeID int
+	err = s.db.QueryRow(
+		"SELECT id FROM users WHERE email = ? OR username = ?",
+		strings.ToLower(strings.TrimSpace(login)), strings.TrimSpace(login),
+	).Scan(&inviteeID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("ошибка поиска пользователя: %v", err)
+	}
+
+	// Владельцу проекта приглашение не нужно
+	var ownerID int
+	if err := s.db.QueryRow("SELECT user_id FROM projects WHERE id = ?", projectID).Scan(&ownerID); err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	if inviteeID == ownerID {
+		return nil, ErrMemberExists
+	}
+
+	_, err = s.db.Exec(
+		"INSERT INTO project_members (project_id, user_id, role, status, invited_by, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		projectID, inviteeID, role, models.MemberStatusPending, actorID, formatDBTime(time.Now()),
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint") {
+			return nil, ErrMemberExists
+		}
+		return nil, fmt.Errorf("ошибка создания приглашения: %v", err)
+	}
+
+	return s.getMember(projectID, inviteeID)
+}
+
+// UpdateRole меняет роль участника. Выдавать и снимать роль admin может только владелец.
+func (s *ProjectMembersService) UpdateRole(actorID, projectID, memberID int, role string) (*models.ProjectMember, error) {
+	if !isMemberRole(role) {
+		return nil, ErrInvalidProjectRole
+	}
+
+	actorRole, err := requireProjectRole(s.db, actorID, projectID, models.ProjectRoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	member, err := s.getMember(projectID, memberID)
+	if err != nil {
+		return nil, err
+	}
+	if (role == models.ProjectRoleAdmin || member.Role == models.ProjectRoleAdmin) && actorRole != models.ProjectRoleOwner {
+		return nil, ErrProjectForbidden
+	}
+
+	_, err = s.db.Exec(
+		"UPDATE project_members SET role = ? WHERE project_id = ? AND user_id = ?",
+		role, projectID, memberID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка изменения роли: %v", err)
+	}
+
+	return s.getMember(projectID, memberID)
+}
+
+// Remove исключает участника или отзывает приглашение. Участник может выйти из проекта сам,
+// admin исключает остальных, других admin — только владелец.
+func (s *ProjectMembersService) Remove(actorID, projectID, memberID int) error {
+	member, err := s.getMember(projectID, memberID)
+	if err != nil && !errors.Is(err, ErrMemberNotFound) {
+		return err
+	}
+
+	if actorID != memberID {
+		actorRole, err := requireProjectRole(s.db, actorID, projectID, models.ProjectRoleAdmin)
+		if err != nil {
+			return err
+		}
+		if member != nil && member.Role == models.ProjectRoleAdmin && actorRole != models.ProjectRoleOwner {
+			return ErrProjectForbidden
+		}
+	}
+	if member == nil {
+		return ErrMemberNotFound
+	}
+
+	_, err = s.db.Exec("DELETE FROM project_members WHERE project_id = ? AND user_id = ?", projectID, memberID)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления участника: %v", err)
+	}
+
+	return nil
+}
+
+// ListInvitations возвращает приглашения пользователя, которые он ещё не принял
+func (s *ProjectMembersService) ListInvitations(userID int) ([]models.ProjectInvitation, error) {
+	rows, err := s.db.Query(
+		`SELECT p.id, p.name, m.role, COALESCE(u.username, ''), m.created_at
+		 FROM project_members m
+		 JOIN projects p ON p.id = m.project_id
+		 LEFT JOIN users u ON u.id = m.invited_by
+		 WHERE m.user_id = ? AND m.status = ?
+		 ORDER BY m.created_at DESC`,
+		userID, models.MemberStatusPending,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	invitations := []models.ProjectInvitation{}
+	for rows.Next() {
+		var inv models.ProjectInvitation
+		if err := rows.Scan(&inv.ProjectID, &inv.ProjectName, &inv.Role, &inv.InvitedBy, &inv.CreatedAt); err != nil {
+			return nil, fmt.Errorf("ошибка чтения приглашения: %v", err)
+		}
+		invitations = append(invitations, inv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации: %v", err)
+	}
+
+	return invitations, nil
+}
+
+// AcceptInvitation принимает приглашение: пользователь становится участником проекта
+func (s *ProjectMembersService) AcceptInvitation(userID, projectID int) error {
+	result, err := s.db.Exec(
+		"UPDATE project_members SET status = ?, accepted_at = ? WHERE project_id = ? AND user_id = ? AND status = ?",
+		models.MemberStatusAccepted, formatDBTime(time.Now()), projectID, userID, models.MemberStatusPending,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка принятия приглашения: %v", err)
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return ErrInvitationNotFound
+	}
+	return nil
+}
+
+// DeclineInvitation отклоняет приглашение
+func (s *ProjectMembersService) DeclineInvitation(userID, projectID int) error {
+	result, err := s.db.Exec(
+		"DELETE FROM project_members WHERE project_id = ? AND user_id = ? AND status = ?",
+		projectID, userID, models.MemberStatusPending,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка отклонения приглашения: %v", err)
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return ErrInvitationNotFound
+	}
+	return nil
+}
+
+// getMember возвращает участника проекта (в том числе приглашённого)
+func (s *ProjectMembersService) getMember(projectID, userID int) (*models.ProjectMember, error) {
+	member, err := scanMember(s.db.QueryRow(
+		`SELECT m.user_id, u.username, u.email, m.role, m.status, m.invited_by, m.created_at, m.accepted_at
+		 FROM project_members m JOIN users u ON u.id = m.user_id
+		 WHERE m.project_id = ? AND m.user_id = ?`,
+		projectID, userID,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrMemberNotFound
+		}
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	return member, nil
+}
+
+// scanMember читает участника проекта
+func scanMember(row rowScanner) (*models.ProjectMember, error) {
+	var member models.ProjectMember
+	var invitedBy sql.NullInt64
+	var acceptedAt sql.NullTime
+	err := row.Scan(&member.UserID, &member.Username, &member.Email, &member.Role, &member.Status,
+		&invitedBy, &member.CreatedAt, &acceptedAt)
+	if err != nil {
+		return nil, err
+	}
+	if invitedBy.Valid {
+		id := int(invitedBy.Int64)
+		member.InvitedBy = &id
+	}
+	if acceptedAt.Valid {
+		member.AcceptedAt = &acceptedAt.Time
+	}
+	return &member, nil
+}
+
+// isMemberRole проверяет роль, которую можно выдать участнику (владелец у проекта один)
+func isMemberRole(role string) bool {
+	return role == models.ProjectRoleViewer || role == models.ProjectRoleEditor || role == models.ProjectRoleAdmin
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"server_new/models"
+)
+
+func TestProjectMembers_InvitationAndRoles(t *testing.T) {
+	setupServiceDB(t)
+	ownerID := createTestUser(t, "owner")
+	viewerID := createTestUser(t, "viewer")
+	editorID := createTestUser(t, "editor")
+	projects := NewProjectsService()
+	members := NewProjectMembersService()
+	tasks := NewTasksService()
+
+	project, err := projects.Create(ownerID, "Общий", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := tasks.CreateTask(ownerID, NewTask{Title: "Общая задача", Status: models.TaskStatusPending, Priority: models.PriorityNormal, ProjectID: &project.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := members.Invite(ownerID, project.ID, "viewer@example.com", models.ProjectRoleViewer); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := members.Invite(ownerID, project.ID, "editor", models.ProjectRoleEditor); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := members.Invite(ownerID, project.ID, "viewer", models.ProjectRoleEditor); !errors.Is(err, ErrMemberExists) {
+		t.Errorf("Ожидалась ErrMemberExists, получено %v", err)
+	}
+
+	// До принятия приглашения доступа нет
+	if _, err := tasks.GetTaskByID(task.ID, viewerID); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("До принятия приглашения задача недоступна, получено %v", err)
+	}
+
+	if err := members.AcceptInvitation(viewerID, project.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := members.AcceptInvitation(editorID, project.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// viewer видит задачу, но не может её менять и удалять
+	if _, err := tasks.GetTaskByID(task.ID, viewerID); err != nil {
+		t.Errorf("viewer должен видеть задачу: %v", err)
+	}
+	title := "Изменено"
+	if _, err := tasks.UpdateTask(task.ID, viewerID, TaskUpdate{Title: &title}); !errors.Is(err, ErrProjectForbidden) {
+		t.Errorf("viewer не может менять задачу, получено %v", err)
+	}
+	if err := tasks.DeleteTask(task.ID, viewerID); !errors.Is(err, ErrProjectForbidden) {
+		t.Errorf("viewer не может удалять задачу, получено %v", err)
+	}
+	if _, err := tasks.CreateTask(viewerID, NewTask{Title: "Своя", Status: models.TaskStatusPending, Priority: models.PriorityNormal, ProjectID: &project.ID}); !errors.Is(err, ErrProjectForbidden) {
+		t.Errorf("viewer не может добавлять задачи в проект, получено %v", err)
+	}
+
+	// editor меняет задачу, и она видна всем участникам в списке проекта
+	if _, err := tasks.UpdateTask(task.ID, editorID, TaskUpdate{Title: &title}); err != nil {
+		t.Fatal(err)
+	}
+	list, total, err := tasks.GetTasksByUserID(viewerID, 1, 10, TaskFilter{ProjectID: project.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || list[0].Title != title {
+		t.Errorf("viewer должен видеть изменённую задачу в проекте: %+v", list)
+	}
+
+	// editor не управляет участниками; после исключения доступ пропадает
+	if err := members.Remove(editorID, project.ID, viewerID); !errors.Is(err, ErrProjectForbidden) {
+		t.Errorf("editor не может исключать участников, получено %v", err)
+	}
+	if err := members.Remove(ownerID, project.ID, viewerID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := projects.GetByID(viewerID, project.ID); !errors.Is(err, ErrProjectNotFound) {
+		t.Errorf("После исключения проект недоступен, получено %v", err)
+	}
+
+	// Удалить проект может только владелец
+	if err := projects.Delete(editorID, project.ID, true); !errors.Is(err, ErrProjectForbidden) {
+		t.Errorf("editor не может удалить проект, получено %v", err)
+	}
+}
+
+func TestProjectMembers_AdminRole(t *testing.T) {
+	setupServiceDB(t)
+	ownerID := createTestUser(t, "owner")
+	adminID := createTestUser(t, "admin")
+	otherID := createTestUser(t, "other")
+	projects := NewProjectsService()
+	members := NewProjectMembersService()
+
+	project, err := projects.Create(ownerID, "Общий", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := members.Invite(ownerID, project.ID, "admin", models.ProjectRoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+
+	invitations, err := members.ListInvitations(adminID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(invitations) != 1 || invitations[0].InvitedBy != "owner" {
+		t.Fatalf("Ожидалось одно приглашение от owner: %+v", invitations)
+	}
+	if err := members.AcceptInvitation(adminID, project.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// admin приглашает участников, но выдать роль admin может только владелец
+	if _, err := members.Invite(adminID, project.ID, "other", models.ProjectRoleAdmin); !errors.Is(err, ErrProjectForbidden) {
+		t.Errorf("Ожидалась ErrProjectForbidden, получено %v", err)
+	}
+	if _, err := members.Invite(adminID, project.ID, "other", models.ProjectRoleEditor); err != nil {
+		t.Fatal(err)
+	}
+	if err := members.DeclineInvitation(otherID, project.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := members.AcceptInvitation(otherID, project.ID); !errors.Is(err, ErrInvitationNotFound) {
+		t.Errorf("Отклонённое приглашение нельзя принять, получено %v", err)
+	}
+
+	name := "Переименован"
+	if _, err := projects.Update(adminID, project.ID, ProjectUpdate{Name: &name}); err != nil {
+		t.Errorf("admin может менять проект: %v", err)
+	}
+
+	// Участник может выйти из проекта сам
+	if err := members.Remove(adminID, project.ID, adminID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProjectMembers_AuthorLosesAccessWithMembership(t *testing.T) {
+	setupServiceDB(t)
+	ownerID := createTestUser(t, "owner")
+	memberID := createTestUser(t, "member")
+	projects := NewProjectsService()
+	members := NewProjectMembersService()
+	tasks := NewTasksService()
+
+	project, err := projects.Create(ownerID, "Общий", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := members.Invite(ownerID, project.ID, "member", models.ProjectRoleEditor); err != nil {
+		t.Fatal(err)
+	}
+	if err := members.AcceptInvitation(memberID, project.ID); err != nil {
+		t.Fatal(err)
+	}
+	task, err := tasks.CreateTask(memberID, NewTask{Title: "Своя в проекте", Status: models.TaskStatusPending, Priority: models.PriorityNormal, ProjectID: &project.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Понижение до viewer: автор видит задачу, но уже не меняет и не выносит её из проекта
+	if _, err := members.UpdateRole(ownerID, project.ID, memberID, models.ProjectRoleViewer); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tasks.GetTaskByID(task.ID, memberID); err != nil {
+		t.Errorf("viewer должен видеть свою задачу: %v", err)
+	}
+	if _, err := tasks.UpdateTask(task.ID, memberID, TaskUpdate{ClearProject: true}); !errors.Is(err, ErrProjectForbidden) {
+		t.Errorf("viewer не может вынести задачу из проекта, получено %v", err)
+	}
+	if err := tasks.DeleteTask(task.ID, memberID); !errors.Is(err, ErrProjectForbidden) {
+		t.Errorf("viewer не может удалить свою задачу в проекте, получено %v", err)
+	}
+
+	// После исключения из проекта авторство доступа не даёт
+	if err := members.Remove(ownerID, project.ID, memberID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tasks.GetTaskByID(task.ID, memberID); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Исключённый автор не должен видеть задачу, получено %v", err)
+	}
+	if _, err := tasks.UpdateTask(task.ID, memberID, TaskUpdate{ClearProject: true}); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Исключённый автор не может вынести задачу из проекта, получено %v", err)
+	}
+	if err := tasks.DeleteTask(task.ID, memberID); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Исключённый автор не может удалить задачу, получено %v", err)
+	}
+	if _, total, err := tasks.GetTasksByUserID(memberID, 1, 10, TaskFilter{}); err != nil || total != 0 {
+		t.Errorf("В списке исключённого автора не должно быть задач проекта: total=%d, %v", total, err)
+	}
+	if _, err := tasks.GetTaskByID(task.ID, ownerID); err != nil {
+		t.Errorf("Задача остаётся в проекте: %v", err)
+	}
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"server_new/config"
+	"server_new/models"
+)
+
+var (
+	// ErrProjectNotFound — проект не найден или у пользователя нет к нему доступа
+	ErrProjectNotFound = errors.New("проект не найден")
+	// ErrInboxProject — проект «Входящие» нельзя удалить: в него переносятся задачи удалённых проектов
+	ErrInboxProject = errors.New("проект «Входящие» нельзя удалить")
+)
+
+// inboxProjectName — название проекта «Входящие», который создаётся при первой необходимости
+const inboxProjectName = "Входящие"
+
+// ProjectUpdate — изменяемые поля проекта (nil — поле не меняется)
+type ProjectUpdate struct {
+	Name     *string
+	Color    *string
+	Archived *bool
+	Position *int
+}
+
+// queryRower — общее у *sql.DB и *sql.Tx для запросов одной строки
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// ProjectsService содержит методы для работы с проектами
+type ProjectsService struct {
+	db *sql.DB
+}
+
+// NewProjectsService создаёт новый экземпляр сервиса
+func NewProjectsService() *ProjectsService {
+	return &ProjectsService{db: config.DB}
+}
+
+// projectColumns — колонки, которые читает scanProject. Роль считается для текущего
+// пользователя, поэтому первыми параметрами запроса идут userID, userID.
+const projectColumns = `p.id, p.user_id, ` + projectRoleSQL + `, p.name, p.color, p.archived, p.position, p.is_inbox,
+	p.created_at, p.updated_at, (SELECT COUNT(*) FROM tasks t WHERE t.project_id = p.id)`
+
+// scanProject читает проект из строки с колонками projectColumns
+func scanProject(row rowScanner) (*models.Project, error) {
+	var project models.Project
+	err := row.Scan(&project.ID, &project.OwnerID, &project.Role, &project.Name, &project.Color, &project.Archived,
+		&project.Position, &project.Inbox, &project.CreatedAt, &project.UpdatedAt, &project.TaskCount)
+	if err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// ListByUserID возвращает проекты пользователя и общие проекты, где он участник, в порядке position.
+// Архивные проекты возвращаются, только если includeArchived.
+func (s *ProjectsService) ListByUserID(userID int, includeArchived bool) ([]models.Project, error) {
+	query := "SELECT " + projectColumns + " FROM projects p WHERE p.id IN (" + accessibleProjectsSQL + ")"
+	if !includeArchived {
+		query += " AND p.archived = 0"
+	}
+	query += " ORDER BY p.position, p.id"
+
+	rows, err := s.db.Query(query, userID, userID, userID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	projects := []models.Project{}
+	for rows.Next() {
+		project, err := scanProject(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения проекта: %v", err)
+		}
+		projects = append(projects, *project)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации: %v", err)
+	}
+
+	return projects, nil
+}
+
+// GetByID возвращает проект, доступный пользователю
+func (s *ProjectsService) GetByID(userID, projectID int) (*models.Project, error) {
+	project, err := scanProject(s.db.QueryRow(
+		"SELECT "+projectColumns+" FROM projects p WHERE p.id = ? AND p.id IN ("+accessibleProjectsSQL+")",
+		userID, userID, projectID, userID, userID,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrProjectNotFound
+		}
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	return project, nil
+}
+
+// Create создаёт проект в конце списка
+func (s *ProjectsService) Create(userID int, name, color string) (*models.Project, error) {
+	now := formatDBTime(time.Now())
+
+	result, err := s.db.Exec(
+		`INSERT INTO projects (user_id, name, color, position, created_at, updated_at)
+		 SELECT ?, ?, ?, COALESCE(MAX(position), -1) + 1, ?, ? FROM projects WHERE user_id = ?`,
+		userID, name, color, now, now, userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания проекта: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения ID проекта: %v", err)
+	}
+
+	return s.GetByID(userID, int(id))
+}
+
+// Update изменяет проект: название, цвет, архивность и позицию (владелец или admin проекта)
+func (s *ProjectsService) Update(userID, projectID int, update ProjectUpdate) (*models.Project, error) {
+	if _, err := requireProjectRole(s.db, userID, projectID, models.ProjectRoleAdmin); err != nil {
+		return nil, err
+	}
+
+	updates := []string{}
+	params := []interface{}{}
+
+	if update.Name != nil {
+		updates = append(updates, "name = ?")
+		params = append(params, *update.Name)
+	}
+	if update.Color != nil {
+		updates = append(updates, "color = ?")
+		params = append(params, *update.Color)
+	}
+	if update.Archived != nil {
+		updates = append(updates, "archived = ?")
+		params = append(params, *update.Archived)
+	}
+	if update.Position != nil {
+		updates = append(updates, "position = ?")
+		params = append(params, *update.Position)
+	}
+	if len(updates) == 0 {
+		return s.GetByID(userID, projectID)
+	}
+
+	updates = append(updates, "updated_at = ?")
+	params = append(params, formatDBTime(time.Now()), projectID)
+
+	result, err := s.db.Exec(
+		"UPDATE projects SET "+strings.Join(updates, ", ")+" WHERE id = ?",
+		params...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка обновления проекта: %v", err)
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return nil, ErrProjectNotFound
+	}
+
+	return s.GetByID(userID, projectID)
+}
+
+// Delete удаляет проект (только владелец). Если deleteTasks, задачи проекта удаляются вместе с ним,
+// иначе переносятся в проект «Входящие» владельца (он создаётся, если его ещё нет).
+func (s *ProjectsService) Delete(userID, projectID int, deleteTasks bool) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := requireProjectRole(tx, userID, projectID, models.ProjectRoleOwner); err != nil {
+		return err
+	}
+
+	var inbox bool
+	if err := tx.QueryRow("SELECT is_inbox FROM projects WHERE id = ?", projectID).Scan(&inbox); err != nil {
+		return fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	if inbox {
+		return ErrInboxProject
+	}
+
+	// Удаление и перенос задач попадают в их историю
+	ids, err := taskIDs(tx, "SELECT id FROM tasks WHERE project_id = ?", projectID)
+	if err != nil {
+		return err
+	}
+	snapshot, err := snapshotTasks(tx, userID, ids)
+	if err != nil {
+		return err
+	}
+
+	if deleteTasks {
+		if _, err := tx.Exec("DELETE FROM tasks WHERE project_id = ?", projectID); err != nil {
+			return fmt.Errorf("ошибка удаления задач проекта: %v", err)
+		}
+	} else {
+		inboxID, err := ensureInbox(tx, userID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(
+			"UPDATE tasks SET project_id = ?, updated_at = ? WHERE project_id = ?",
+			inboxID, formatDBTime(time.Now()), projectID,
+		)
+		if err != nil {
+			return fmt.Errorf("ошибка переноса задач во «Входящие»: %v", err)
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM projects WHERE id = ?", projectID); err != nil {
+		return fmt.Errorf("ошибка удаления проекта: %v", err)
+	}
+
+	if err := snapshot.record(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка сохранения: %v", err)
+	}
+
+	return nil
+}
+
+// ensureInbox возвращает ID проекта «Входящие», создавая его при необходимости
+func ensureInbox(tx *sql.Tx, userID int) (int, error) {
+	now := formatDBTime(time.Now())
+	_, err := tx.Exec(
+		`INSERT OR IGNORE INTO projects (user_id, name, is_inbox, position, created_at, updated_at)
+		 VALUES (?, ?, 1, -1, ?, ?)`,
+		userID, inboxProjectName, now, now,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка создания проекта «Входящие»: %v", err)
+	}
+
+	var id int
+	err = tx.QueryRow("SELECT id FROM projects WHERE user_id = ? AND is_inbox = 1", userID).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка получения проекта «Входящие»: %v", err)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"server_new/models"
+)
+
+func TestProjectsService_DeleteMovesTasksToInbox(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "alice")
+	projects := NewProjectsService()
+	tasks := NewTasksService()
+
+	work, err := projects.Create(userID, "Работа", "#ff0000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	home, err := projects.Create(userID, "Дом", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if home.Position <= work.Position {
+		t.Errorf("Новый проект должен вставать в конец: %d <= %d", home.Position, work.Position)
+	}
+
+	task, err := tasks.CreateTask(userID, NewTask{Title: "Отчёт", Status: models.TaskStatusPending, Priority: models.PriorityNormal, ProjectID: &work.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, total, err := tasks.GetTasksByUserID(userID, 1, 10, TaskFilter{ProjectID: work.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 {
+		t.Errorf("В проекте должна быть 1 задача, получено %d", total)
+	}
+
+	if err := projects.Delete(userID, work.ID, false); err != nil {
+		t.Fatal(err)
+	}
+
+	task, err = tasks.GetTaskByID(task.ID, userID)
+	if err != nil {
+		t.Fatal("Задача не должна удаляться вместе с проектом:", err)
+	}
+	if task.ProjectID == nil {
+		t.Fatal("Задача должна попасть во «Входящие»")
+	}
+	inbox, err := projects.GetByID(userID, *task.ProjectID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !inbox.Inbox || inbox.TaskCount != 1 {
+		t.Errorf("Ожидался проект «Входящие» с одной задачей, получено %+v", inbox)
+	}
+
+	if err := projects.Delete(userID, inbox.ID, false); !errors.Is(err, ErrInboxProject) {
+		t.Errorf("Ожидалась ErrInboxProject, получено %v", err)
+	}
+}
+
+func TestProjectsService_DeleteCascade(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "alice")
+	otherID := createTestUser(t, "bob")
+	projects := NewProjectsService()
+	tasks := NewTasksService()
+
+	project, err := projects.Create(userID, "Черновики", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// В чужой проект задачу не положить
+	_, err = tasks.CreateTask(otherID, NewTask{Title: "Чужая", Status: models.TaskStatusPending, Priority: models.PriorityNormal, ProjectID: &project.ID})
+	if !errors.Is(err, ErrProjectNotFound) {
+		t.Errorf("Ожидалась ErrProjectNotFound, получено %v", err)
+	}
+
+	task, err := tasks.CreateTask(userID, NewTask{Title: "Черновик", Status: models.TaskStatusPending, Priority: models.PriorityNormal, ProjectID: &project.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archived := true
+	project, err = projects.Update(userID, project.ID, ProjectUpdate{Archived: &archived})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !project.Archived {
+		t.Error("Проект должен быть в архиве")
+	}
+	list, err := projects.ListByUserID(userID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 0 {
+		t.Errorf("Архивные проекты не должны попадать в список по умолчанию: %+v", list)
+	}
+
+	if err := projects.Delete(userID, project.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tasks.GetTaskByID(task.ID, userID); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Задача должна удалиться вместе с проектом, получено %v", err)
+	}
+}
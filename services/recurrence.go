@@ -0,0 +1,196 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"server_new/models"
+	"server_new/utils"
+)
+
+// maxRecurrencePreview — сколько будущих повторений можно запросить за раз
+const maxRecurrencePreview = 50
+
+var (
+	// ErrRecurrenceNeedsDue — у повторяющейся задачи должен быть срок: от него считаются повторения
+	ErrRecurrenceNeedsDue = errors.New("у повторяющейся задачи должен быть срок (due_at)")
+	// ErrTaskNotRecurring — задача не повторяется
+	ErrTaskNotRecurring = errors.New("задача не повторяется")
+)
+
+// setRecurrence задаёт правило повторения: серия начинается со срока задачи dueAt.
+// Правило и часовой пояс должны быть проверены заранее (utils.ParseRRule, time.LoadLocation).
+func setRecurrence(tx *sql.Tx, taskID int, recurrence models.TaskRecurrence, dueAt time.Time) error {
+	timezone := recurrence.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
+	_, err := tx.Exec(
+		`UPDATE tasks SET recurrence_rule = ?, recurrence_tz = ?, recurrence_start = ?,
+		        series_id = COALESCE(series_id, id)
+		 WHERE id = ?`,
+		recurrence.RRule, timezone, formatDBTime(dueAt), taskID,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения правила повторения: %v", err)
+	}
+	return nil
+}
+
+// nextOccurrences возвращает до n повторений серии после after
+func nextOccurrences(recurrence *models.TaskRecurrence, after time.Time, n int) ([]time.Time, error) {
+	rule, err := utils.ParseRRule(recurrence.RRule)
+	if err != nil {
+		return nil, fmt.Errorf("некорректное правило повторения %q: %v", recurrence.RRule, err)
+	}
+	loc, err := time.LoadLocation(recurrence.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("неизвестный часовой пояс %q: %v", recurrence.Timezone, err)
+	}
+
+	occurrences := rule.Occurrences(recurrence.Start.In(loc), after.In(loc), n)
+	for i := range occurrences {
+		occurrences[i] = occurrences[i].UTC()
+	}
+	return occurrences, nil
+}
+
+// spawnNextOccurrence создаёт следующее повторение только что завершённой задачи.
+// Срок следующего повторения — первый по правилу после срока завершённой задачи, а если она
+// завершена с опозданием — после текущего момента, чтобы не плодить просроченные копии.
+// Копируются описание, приоритет, проект, исполнитель, метки и чек-лист (без отметок);
+// правило переходит к новой задаче. Если серия закончилась (COUNT, UNTIL), ничего не создаётся.
+func spawnNextOccurrence(tx *sql.Tx, actorID, taskID int, now time.Time) error {
+	var rule sql.NullString
+	var timezone string
+	var start, dueAt sql.NullTime
+	err := tx.QueryRow(
+		"SELECT recurrence_rule, recurrence_tz, recurrence_start, due_at FROM tasks WHERE id = ?",
+		taskID,
+	).Scan(&rule, &timezone, &start, &dueAt)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения правила повторения: %v", err)
+	}
+	if !rule.Valid || !start.Valid || !dueAt.Valid {
+		return nil
+	}
+
+	after := dueAt.Time
+	if now.After(after) {
+		after = now
+	}
+	next, err := nextOccurrences(&models.TaskRecurrence{RRule: rule.String, Timezone: timezone, Start: start.Time}, after, 1)
+	if err != nil {
+		return err
+	}
+	if len(next) == 0 {
+		return nil
+	}
+
+	dbNow := formatDBTime(now)
+	result, err := tx.Exec(
+		`INSERT INTO tasks (title, description, status, userid, project_id, parent_id, assignee_id, assigned_by, assigned_at,
+		                    priority, due_at, created_at, updated_at, series_id, recurrence_rule, recurrence_tz, recurrence_start)
+		 SELECT title, description, ?, userid, project_id, parent_id, assignee_id, assigned_by, assigned_at,
+		        priority, ?, ?, ?, series_id, recurrence_rule, recurrence_tz, recurrence_start
+		 FROM tasks WHERE id = ?`,
+		models.TaskStatusPending, formatDBTime(next[0]), dbNow, dbNow, taskID,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка создания следующего повторения: %v", err)
+	}
+	nextID, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("ошибка получения ID повторения: %v", err)
+	}
+
+	if _, err := tx.Exec("INSERT INTO task_tags (task_id, tag_id) SELECT ?, tag_id FROM task_tags WHERE task_id = ?", nextID, taskID); err != nil {
+		return fmt.Errorf("ошибка копирования меток: %v", err)
+	}
+	_, err = tx.Exec(
+		`INSERT INTO task_checklist_items (task_id, title, done, position, created_at, updated_at)
+		 SELECT ?, title, 0, position, ?, ? FROM task_checklist_items WHERE task_id = ?`,
+		nextID, dbNow, dbNow, taskID,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка копирования чек-листа: %v", err)
+	}
+
+	// Правило остаётся только у нового повторения: повторное завершение этой задачи не создаст дубль
+	if _, err := tx.Exec("UPDATE tasks SET recurrence_rule = NULL WHERE id = ?", taskID); err != nil {
+		return fmt.Errorf("ошибка обновления повторения: %v", err)
+	}
+
+	return recordTaskCreated(tx, actorID, int(nextID))
+}
+
+// PreviewRecurrence возвращает правило повторения задачи и до n следующих повторений после её срока
+func (s *TasksService) PreviewRecurrence(userID, taskID, n int) (*models.TaskRecurrence, []time.Time, error) {
+	task, err := s.GetTaskByID(taskID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if task.Recurrence == nil || task.DueAt == nil {
+		return nil, nil, ErrTaskNotRecurring
+	}
+
+	if n > maxRecurrencePreview {
+		n = maxRecurrencePreview
+	}
+	occurrences, err := nextOccurrences(task.Recurrence, *task.DueAt, n)
+	if err != nil {
+		return nil, nil, err
+	}
+	return task.Recurrence, occurrences, nil
+}
+
+// StopRecurrence останавливает серию: новые повторения больше не создаются,
+// уже созданные задачи не меняются (права editor на задачу)
+func (s *TasksService) StopRecurrence(userID, taskID int) (*models.Task, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := requireTaskRole(tx, userID, taskID, models.ProjectRoleEditor); err != nil {
+		return nil, err
+	}
+
+	// Правило хранится у последнего повторения серии — оно может быть не этой задачей
+	ids, err := taskIDs(tx,
+		"SELECT id FROM tasks WHERE recurrence_rule IS NOT NULL AND series_id = (SELECT series_id FROM tasks WHERE id = ?)",
+		taskID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, ErrTaskNotRecurring
+	}
+	snapshot, err := snapshotTasks(tx, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(
+		"UPDATE tasks SET recurrence_rule = NULL, updated_at = ? WHERE id IN ("+placeholders(len(ids))+")",
+		append([]interface{}{formatDBTime(time.Now())}, intArgs(ids)...)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка остановки серии: %v", err)
+	}
+
+	if err := snapshot.record(tx); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка сохранения задачи: %v", err)
+	}
+
+	return s.GetTaskByID(taskID, userID)
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"server_new/models"
+)
+
+func TestRecurrence_NextOccurrence(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "alice")
+	tasks := NewTasksService()
+	checklist := NewChecklistService()
+
+	// Без срока повторение не задать
+	_, err := tasks.CreateTask(userID, NewTask{Title: "Без срока", Status: models.TaskStatusPending, Priority: models.PriorityNormal,
+		Recurrence: &models.TaskRecurrence{RRule: "FREQ=DAILY", Timezone: "UTC"}})
+	if !errors.Is(err, ErrRecurrenceNeedsDue) {
+		t.Errorf("Ожидалась ErrRecurrenceNeedsDue, получено %v", err)
+	}
+
+	// Серия из трёх повторений по понедельникам и средам, начиная с будущего понедельника
+	monday := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 7)
+	for monday.Weekday() != time.Monday {
+		monday = monday.AddDate(0, 0, 1)
+	}
+	due := monday.Add(9 * time.Hour)
+
+	first, err := tasks.CreateTask(userID, NewTask{Title: "Вынести мусор", Status: models.TaskStatusPending, Priority: models.PriorityHigh,
+		DueAt: &due, Tags: []string{"дом"}, Recurrence: &models.TaskRecurrence{RRule: "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=3", Timezone: "UTC"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Recurrence == nil || first.SeriesID == nil || *first.SeriesID != first.ID {
+		t.Fatalf("Задача должна открыть серию: %+v, %v", first.Recurrence, first.SeriesID)
+	}
+	if _, err := checklist.Create(userID, first.ID, "Пакеты"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, preview, err := tasks.PreviewRecurrence(userID, first.ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preview) != 2 || !preview[0].Equal(due.AddDate(0, 0, 2)) || !preview[1].Equal(due.AddDate(0, 0, 7)) {
+		t.Errorf("Ожидались среда и следующий понедельник, получено %v", preview)
+	}
+
+	completed := models.TaskStatusCompleted
+	done, err := tasks.UpdateTask(first.ID, userID, TaskUpdate{Status: &completed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done.Recurrence != nil {
+		t.Error("Правило должно перейти к следующему повторению")
+	}
+
+	series, total, err := tasks.GetTasksByUserID(userID, 1, 10, TaskFilter{SeriesID: first.ID, Status: models.TaskStatusPending})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 {
+		t.Fatalf("Ожидалось одно открытое повторение, получено %d", total)
+	}
+	next := series[0]
+	if next.DueAt == nil || !next.DueAt.Equal(due.AddDate(0, 0, 2)) {
+		t.Errorf("Срок следующего повторения = %v, ожидалась среда", next.DueAt)
+	}
+	if next.Priority != models.PriorityHigh || len(next.Tags) != 1 || next.Checklist != (models.TaskProgress{Done: 0, Total: 1}) {
+		t.Errorf("Повторение должно унаследовать приоритет, метки и чек-лист: %+v", next)
+	}
+
+	// Третье повторение — последнее (COUNT=3)
+	if _, err := tasks.UpdateTask(next.ID, userID, TaskUpdate{Status: &completed}); err != nil {
+		t.Fatal(err)
+	}
+	series, _, err = tasks.GetTasksByUserID(userID, 1, 10, TaskFilter{SeriesID: first.ID, Status: models.TaskStatusPending})
+	if err != nil || len(series) != 1 {
+		t.Fatalf("Ожидалось третье повторение: %v, %v", series, err)
+	}
+	if _, err := tasks.UpdateTask(series[0].ID, userID, TaskUpdate{Status: &completed}); err != nil {
+		t.Fatal(err)
+	}
+	if _, total, _ := tasks.GetTasksByUserID(userID, 1, 10, TaskFilter{SeriesID: first.ID}); total != 3 {
+		t.Errorf("После COUNT=3 новых повторений быть не должно, в серии %d задач", total)
+	}
+}
+
+func TestRecurrence_Stop(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "alice")
+	tasks := NewTasksService()
+
+	due := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	task, err := tasks.CreateTask(userID, NewTask{Title: "Зарядка", Status: models.TaskStatusPending, Priority: models.PriorityNormal,
+		DueAt: &due, Recurrence: &models.TaskRecurrence{RRule: "FREQ=DAILY", Timezone: "Europe/Moscow"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Нельзя убрать срок, пока задача повторяется
+	if _, err := tasks.UpdateTask(task.ID, userID, TaskUpdate{ClearDueAt: true}); !errors.Is(err, ErrRecurrenceNeedsDue) {
+		t.Errorf("Ожидалась ErrRecurrenceNeedsDue, получено %v", err)
+	}
+
+	stopped, err := tasks.StopRecurrence(userID, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stopped.Recurrence != nil {
+		t.Error("Серия должна быть остановлена")
+	}
+	if _, err := tasks.StopRecurrence(userID, task.ID); !errors.Is(err, ErrTaskNotRecurring) {
+		t.Errorf("Ожидалась ErrTaskNotRecurring, получено %v", err)
+	}
+
+	completed := models.TaskStatusCompleted
+	if _, err := tasks.UpdateTask(task.ID, userID, TaskUpdate{Status: &completed}); err != nil {
+		t.Fatal(err)
+	}
+	if _, total, _ := tasks.GetTasksByUserID(userID, 1, 10, TaskFilter{}); total != 1 {
+		t.Errorf("После остановки серии новые повторения не создаются, задач: %d", total)
+	}
+}
+
+func TestRecurrence_CompleteSubtasksSpawnsNext(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "alice")
+	tasks := NewTasksService()
+
+	parent := createSubtask(t, tasks, userID, 0, "Родитель")
+	due := time.Now().UTC().Truncate(time.Hour).Add(48 * time.Hour)
+	sub, err := tasks.CreateTask(userID, NewTask{Title: "Полить цветы", Status: models.TaskStatusPending, Priority: models.PriorityNormal,
+		ParentID: &parent.ID, DueAt: &due, Recurrence: &models.TaskRecurrence{RRule: "FREQ=DAILY", Timezone: "UTC"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Завершение через complete_subtasks продолжает серию так же, как прямое завершение подзадачи
+	completed := models.TaskStatusCompleted
+	if _, err := tasks.UpdateTask(parent.ID, userID, TaskUpdate{Status: &completed, CompleteSubtasks: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	series, total, err := tasks.GetTasksByUserID(userID, 1, 10, TaskFilter{SeriesID: sub.ID, Status: models.TaskStatusPending})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || series[0].Recurrence == nil || series[0].DueAt == nil || !series[0].DueAt.Equal(due.AddDate(0, 0, 1)) {
+		t.Fatalf("Ожидалось следующее повторение на %v, получено %d: %+v", due.AddDate(0, 0, 1), total, series)
+	}
+
+	done, err := tasks.GetTaskByID(sub.ID, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done.Status != models.TaskStatusCompleted || done.Recurrence != nil {
+		t.Errorf("Подзадача должна быть завершена и передать правило дальше: %s, %+v", done.Status, done.Recurrence)
+	}
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"server_new/config"
+	"server_new/models"
+)
+
+var (
+	// ErrSavedViewNotFound — вид не найден или принадлежит другому пользователю
+	ErrSavedViewNotFound = errors.New("вид не найден")
+	// ErrSavedViewExists — у пользователя уже есть вид с таким названием
+	ErrSavedViewExists = errors.New("вид с таким названием уже есть")
+	// ErrInvalidSavedView — фильтры или сортировка вида не проходят проверку списка задач
+	ErrInvalidSavedView = errors.New("некорректные параметры вида")
+)
+
+// SavedViewInput — поля вида при создании и изменении (nil — поле не меняется)
+type SavedViewInput struct {
+	Name      *string
+	Filters   map[string]string
+	Sort      *string
+	PageSize  *int
+	IsDefault *bool
+}
+
+// SavedViewsService содержит методы для работы с сохранёнными видами списка задач
+type SavedViewsService struct {
+	db *sql.DB
+}
+
+// NewSavedViewsService создаёт новый экземпляр сервиса
+func NewSavedViewsService() *SavedViewsService {
+	return &SavedViewsService{db: config.DB}
+}
+
+const savedViewColumns = "id, name, filters, sort, page_size, is_default, created_at, updated_at"
+
+// scanSavedView читает строку saved_views в порядке savedViewColumns
+func scanSavedView(row rowScanner) (*models.SavedView, error) {
+	var view models.SavedView
+	var filters string
+	if err := row.Scan(&view.ID, &view.Name, &filters, &view.Sort, &view.PageSize, &view.IsDefault, &view.CreatedAt, &view.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(filters), &view.Filters); err != nil {
+		return nil, fmt.Errorf("ошибка чтения фильтров вида: %v", err)
+	}
+	if view.Filters == nil {
+		view.Filters = map[string]string{}
+	}
+	return &view, nil
+}
+
+// List возвращает виды пользователя по алфавиту
+func (s *SavedViewsService) List(userID int) ([]models.SavedView, error) {
+	rows, err := s.db.Query("SELECT "+savedViewColumns+" FROM saved_views WHERE user_id = ? ORDER BY name, id", userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	views := []models.SavedView{}
+	for rows.Next() {
+		view, err := scanSavedView(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения вида: %v", err)
+		}
+		views = append(views, *view)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации: %v", err)
+	}
+
+	return views, nil
+}
+
+// Get возвращает вид пользователя
+func (s *SavedViewsService) Get(userID, viewID int) (*models.SavedView, error) {
+	return getSavedView(s.db, "id = ? AND user_id = ?", viewID, userID)
+}
+
+// Default возвращает вид пользователя по умолчанию (ErrSavedViewNotFound — не выбран)
+func (s *SavedViewsService) Default(userID int) (*models.SavedView, error) {
+	return getSavedView(s.db, "user_id = ? AND is_default = 1", userID)
+}
+
+func getSavedView(q queryRower, where string, args ...interface{}) (*models.SavedView, error) {
+	view, err := scanSavedView(q.QueryRow("SELECT "+savedViewColumns+" FROM saved_views WHERE "+where, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSavedViewNotFound
+		}
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	return view, nil
+}
+
+// Create сохраняет вид; без PageSize — 10 задач на странице. Новый вид по умолчанию
+// снимает этот признак с прежнего.
+func (s *SavedViewsService) Create(userID int, input SavedViewInput) (*models.SavedView, error) {
+	view := models.SavedView{Filters: map[string]string{}, PageSize: 10}
+	applySavedViewInput(&view, input)
+	if _, err := SavedViewTaskFilter(&view); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	if view.IsDefault {
+		if err := clearDefaultView(tx, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	filters, _ := json.Marshal(view.Filters)
+	now := formatDBTime(time.Now())
+	result, err := tx.Exec(
+		`INSERT INTO saved_views (user_id, name, filters, sort, page_size, is_default, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		userID, view.Name, string(filters), view.Sort, view.PageSize, view.IsDefault, now, now,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint") {
+			return nil, ErrSavedViewExists
+		}
+		return nil, fmt.Errorf("ошибка создания вида: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения ID вида: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка фиксации транзакции: %v", err)
+	}
+
+	return s.Get(userID, int(id))
+}
+
+// Update меняет переданные поля вида; фильтры заменяются целиком
+func (s *SavedViewsService) Update(userID, viewID int, input SavedViewInput) (*models.SavedView, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	view, err := getSavedView(tx, "id = ? AND user_id = ?", viewID, userID)
+	if err != nil {
+		return nil, err
+	}
+	applySavedViewInput(view, input)
+	if _, err := SavedViewTaskFilter(view); err != nil {
+		return nil, err
+	}
+
+	if view.IsDefault {
+		if err := clearDefaultView(tx, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	filters, _ := json.Marshal(view.Filters)
+	_, err = tx.Exec(
+		`UPDATE saved_views SET name = ?, filters = ?, sort = ?, page_size = ?, is_default = ?, updated_at = ?
+		 WHERE id = ? AND user_id = ?`,
+		view.Name, string(filters), view.Sort, view.PageSize, view.IsDefault, formatDBTime(time.Now()), viewID, userID,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint") {
+			return nil, ErrSavedViewExists
+		}
+		return nil, fmt.Errorf("ошибка обновления вида: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка фиксации транзакции: %v", err)
+	}
+
+	return s.Get(userID, viewID)
+}
+
+// Delete удаляет вид; если он был видом по умолчанию, GET /tasks снова показывает все задачи
+func (s *SavedViewsService) Delete(userID, viewID int) error {
+	result, err := s.db.Exec("DELETE FROM saved_views WHERE id = ? AND user_id = ?", viewID, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления вида: %v", err)
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return ErrSavedViewNotFound
+	}
+
+	return nil
+}
+
+// clearDefaultView снимает признак вида по умолчанию со всех видов пользователя
+func clearDefaultView(tx *sql.Tx, userID int) error {
+	if _, err := tx.Exec("UPDATE saved_views SET is_default = 0 WHERE user_id = ? AND is_default = 1", userID); err != nil {
+		return fmt.Errorf("ошибка снятия вида по умолчанию: %v", err)
+	}
+	return nil
+}
+
+// applySavedViewInput переносит переданные поля в вид; пустые значения фильтров отбрасываются
+func applySavedViewInput(view *models.SavedView, input SavedViewInput) {
+	if input.Name != nil {
+		view.Name = *input.Name
+	}
+	if input.Filters != nil {
+		view.Filters = map[string]string{}
+		for key, value := range input.Filters {
+			if value != "" {
+				view.Filters[key] = value
+			}
+		}
+	}
+	if input.Sort != nil {
+		view.Sort = *input.Sort
+	}
+	if input.PageSize != nil {
+		view.PageSize = *input.PageSize
+	}
+	if input.IsDefault != nil {
+		view.IsDefault = *input.IsDefault
+	}
+}
+
+// SavedViewTaskFilter собирает из вида фильтр списка задач тем же разбором, что и у GET /tasks.
+// Ошибка оборачивает ErrInvalidSavedView.
+func SavedViewTaskFilter(view *models.SavedView) (TaskFilter, error) {
+	values := url.Values{}
+	for key, value := range view.Filters {
+		if key == "sort" || !isTaskFilterParam(key) {
+			return TaskFilter{}, fmt.Errorf("%w: неизвестный параметр фильтра %q", ErrInvalidSavedView, key)
+		}
+		values.Set(key, value)
+	}
+	if view.Sort != "" {
+		values.Set("sort", view.Sort)
+	}
+
+	filter, err := ParseTaskFilter(values)
+	if err != nil {
+		return TaskFilter{}, fmt.Errorf("%w: %w", ErrInvalidSavedView, err)
+	}
+	return filter, nil
+}
+
+func isTaskFilterParam(name string) bool {
+	for _, param := range TaskFilterParams {
+		if param == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"server_new/models"
+)
+
+func TestSavedViewsService_CRUD(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "alice")
+	otherID := createTestUser(t, "bob")
+	service := NewSavedViewsService()
+
+	name, sort, pageSize, isDefault := "Срочное", "-priority", 5, true
+	urgent, err := service.Create(userID, SavedViewInput{
+		Name:      &name,
+		Filters:   map[string]string{"status": "pending", "filter": "priority>=high", "tags_any": ""},
+		Sort:      &sort,
+		PageSize:  &pageSize,
+		IsDefault: &isDefault,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urgent.Filters) != 2 || urgent.Filters["status"] != "pending" || urgent.Sort != "-priority" || urgent.PageSize != 5 || !urgent.IsDefault {
+		t.Errorf("Вид сохранён неверно: %+v", urgent)
+	}
+
+	// Название уникально у пользователя, у другого может быть такое же
+	if _, err := service.Create(userID, SavedViewInput{Name: &name}); !errors.Is(err, ErrSavedViewExists) {
+		t.Errorf("Ожидалась ErrSavedViewExists, получено %v", err)
+	}
+	if _, err := service.Create(otherID, SavedViewInput{Name: &name}); err != nil {
+		t.Errorf("У другого пользователя название свободно: %v", err)
+	}
+
+	// Фильтры проверяются тем же кодом, что и у GET /tasks
+	bad := "Плохой"
+	for _, filters := range []map[string]string{
+		{"status": "done"},
+		{"colour": "red"},
+		{"sort": "title"},
+		{"filter": "priority>=critical"},
+	} {
+		if _, err := service.Create(userID, SavedViewInput{Name: &bad, Filters: filters}); !errors.Is(err, ErrInvalidSavedView) {
+			t.Errorf("Фильтры %v: ожидалась ErrInvalidSavedView, получено %v", filters, err)
+		}
+	}
+	badSort := "title,colour"
+	if _, err := service.Create(userID, SavedViewInput{Name: &bad, Sort: &badSort}); !errors.Is(err, ErrInvalidSavedView) {
+		t.Errorf("Сортировка title,colour: ожидалась ErrInvalidSavedView, получено %v", err)
+	}
+
+	// Новый вид по умолчанию снимает признак с прежнего
+	mineName := "Мои"
+	mine, err := service.Create(userID, SavedViewInput{Name: &mineName, IsDefault: &isDefault})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mine.PageSize != 10 {
+		t.Errorf("Размер страницы по умолчанию = %d", mine.PageSize)
+	}
+	def, err := service.Default(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if def.ID != mine.ID {
+		t.Errorf("Вид по умолчанию = %d, ожидался %d", def.ID, mine.ID)
+	}
+	if urgent, err = service.Get(userID, urgent.ID); err != nil || urgent.IsDefault {
+		t.Errorf("Прежний вид остался видом по умолчанию: %+v, %v", urgent, err)
+	}
+
+	// Частичное изменение не трогает остальные поля
+	notDefault := false
+	mine, err = service.Update(userID, mine.ID, SavedViewInput{IsDefault: &notDefault})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mine.Name != "Мои" || mine.IsDefault {
+		t.Errorf("Вид после изменения: %+v", mine)
+	}
+	if _, err := service.Default(userID); !errors.Is(err, ErrSavedViewNotFound) {
+		t.Errorf("Вида по умолчанию быть не должно, получено %v", err)
+	}
+	if _, err := service.Update(userID, mine.ID, SavedViewInput{Name: &name}); !errors.Is(err, ErrSavedViewExists) {
+		t.Errorf("Переименование в занятое название: ожидалась ErrSavedViewExists, получено %v", err)
+	}
+
+	// Чужие виды не видны
+	if _, err := service.Get(otherID, mine.ID); !errors.Is(err, ErrSavedViewNotFound) {
+		t.Errorf("Ожидалась ErrSavedViewNotFound, получено %v", err)
+	}
+	if err := service.Delete(otherID, mine.ID); !errors.Is(err, ErrSavedViewNotFound) {
+		t.Errorf("Ожидалась ErrSavedViewNotFound, получено %v", err)
+	}
+
+	if err := service.Delete(userID, mine.ID); err != nil {
+		t.Fatal(err)
+	}
+	list, err := service.List(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].ID != urgent.ID {
+		t.Errorf("Ожидался один вид %d, получено %+v", urgent.ID, list)
+	}
+}
+
+func TestSavedViewTaskFilter(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "alice")
+	tasks := NewTasksService()
+
+	for _, input := range []NewTask{
+		{Title: "Срочная", Status: models.TaskStatusPending, Priority: models.PriorityUrgent},
+		{Title: "Обычная", Status: models.TaskStatusPending, Priority: models.PriorityNormal},
+		{Title: "Готовая", Status: models.TaskStatusCompleted, Priority: models.PriorityHigh},
+	} {
+		if _, err := tasks.CreateTask(userID, input); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	filter, err := SavedViewTaskFilter(&models.SavedView{
+		Filters: map[string]string{"status": "pending", "filter": "priority>=high"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, total, err := tasks.GetTasksByUserID(userID, 1, 10, filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || found[0].Title != "Срочная" {
+		t.Errorf("Ожидалась одна задача «Срочная», получено %d: %+v", total, found)
+	}
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"server_new/models"
+	"server_new/utils"
+)
+
+var (
+	// ErrInvalidSearchQuery — пустой или слишком длинный поисковый запрос
+	ErrInvalidSearchQuery = errors.New("некорректный поисковый запрос")
+	// ErrSearchUnavailable — SQLite собран без FTS5 (нужен тег сборки sqlite_fts5)
+	ErrSearchUnavailable = errors.New("полнотекстовый поиск недоступен")
+)
+
+// Границы совпадений, которые SQLite вставляет в подсветку; в ответе они становятся <mark>
+const (
+	searchMarkStart = "\x02"
+	searchMarkEnd   = "\x03"
+)
+
+// taskMatchesSQL — задачи, подходящие под запрос FTS5, с релевантностью и подсветкой.
+// Совпадение в названии весит больше, чем в описании. Параметр: выражение MATCH.
+const taskMatchesSQL = `WITH matches AS (
+		SELECT rowid AS match_id,
+		       bm25(tasks_fts, 5.0, 1.0) AS match_rank,
+		       highlight(tasks_fts, 0, char(2), char(3)) AS match_title,
+		       snippet(tasks_fts, 1, char(2), char(3), '…', 24) AS match_description
+		FROM tasks_fts WHERE tasks_fts MATCH ?
+	)`
+
+// SearchTasks ищет доступные пользователю задачи по названию и описанию. Фильтры и пагинация —
+// как у GetTasksByUserID; без сортировки в фильтре сначала идут самые релевантные задачи.
+func (s *TasksService) SearchTasks(userID int, query string, page, limit int, filter TaskFilter) ([]models.TaskSearchResult, int, error) {
+	match, err := utils.BuildFTSQuery(query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrInvalidSearchQuery, err)
+	}
+
+	var available bool
+	err = s.db.QueryRow("SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'tasks_fts')").Scan(&available)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	if !available {
+		return nil, 0, ErrSearchUnavailable
+	}
+
+	where, args, err := filter.where(userID, time.Now())
+	if err != nil {
+		return nil, 0, err
+	}
+	where = taskAccessSQL + where
+	args = append([]interface{}{userID, userID, userID}, args...)
+
+	orderBy := "match_rank, id DESC"
+	if filter.Sort != "" {
+		if orderBy, err = filter.orderBy(); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	rows, err := s.db.Query(
+		taskMatchesSQL+" SELECT "+taskColumns+", match_title, match_description FROM tasks JOIN matches ON match_id = tasks.id"+
+			" WHERE "+where+" ORDER BY "+orderBy+" LIMIT ? OFFSET ?",
+		append(append([]interface{}{match}, args...), limit, (page-1)*limit)...,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка поиска: %v", err)
+	}
+	defer rows.Close()
+
+	tasks := []models.Task{}
+	highlights := []models.TaskHighlights{}
+	for rows.Next() {
+		var h models.TaskHighlights
+		task, err := scanTask(extraColumns{row: rows, dest: []interface{}{&h.Title, &h.Description}})
+		if err != nil {
+			return nil, 0, fmt.Errorf("ошибка чтения задачи: %v", err)
+		}
+		tasks = append(tasks, *task)
+		highlights = append(highlights, models.TaskHighlights{
+			Title:       markHighlights(h.Title),
+			Description: markHighlights(h.Description),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("ошибка при итерации: %v", err)
+	}
+	rows.Close()
+
+	if err := loadTaskTags(s.db, userID, tasks); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	err = s.db.QueryRow(
+		"SELECT COUNT(*) FROM tasks WHERE "+where+" AND id IN (SELECT rowid FROM tasks_fts WHERE tasks_fts MATCH ?)",
+		append(args, match)...,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка получения количества: %v", err)
+	}
+
+	results := make([]models.TaskSearchResult, len(tasks))
+	for i := range tasks {
+		results[i] = models.TaskSearchResult{Task: tasks[i], Highlights: highlights[i]}
+	}
+	return results, total, nil
+}
+
+// extraColumns дочитывает колонки, которые идут в строке после taskColumns
+type extraColumns struct {
+	row  rowScanner
+	dest []interface{}
+}
+
+func (e extraColumns) Scan(dest ...interface{}) error {
+	return e.row.Scan(append(dest, e.dest...)...)
+}
+
+// markHighlights экранирует текст для HTML и заменяет границы совпадений на <mark>
+func markHighlights(text string) string {
+	text = html.EscapeString(text)
+	text = strings.ReplaceAll(text, searchMarkStart, "<mark>")
+	return strings.ReplaceAll(text, searchMarkEnd, "</mark>")
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"server_new/config"
+	"server_new/migrations"
+	"server_new/models"
+)
+
+func TestSearchTasks(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "alice")
+	otherID := createTestUser(t, "bob")
+	tasks := NewTasksService()
+
+	if available, err := migrations.FTS5Available(config.DB); err != nil {
+		t.Fatal(err)
+	} else if !available {
+		if _, _, err := tasks.SearchTasks(userID, "отчёт", 1, 10, TaskFilter{}); !errors.Is(err, ErrSearchUnavailable) {
+			t.Errorf("Без FTS5 ожидалась ErrSearchUnavailable, получено %v", err)
+		}
+		t.Skip("SQLite собран без FTS5: запустите тесты с -tags sqlite_fts5")
+	}
+
+	create := func(userID int, title, description string) *models.Task {
+		t.Helper()
+		task, err := tasks.CreateTask(userID, NewTask{Title: title, Description: description, Status: models.TaskStatusPending, Priority: models.PriorityNormal})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return task
+	}
+
+	report := create(userID, "Квартальный отчёт", "Собрать <цифры> по продажам")
+	mention := create(userID, "Созвон", "Обсудить отчёт с командой")
+	english := create(userID, "Write reports", "Annual planning")
+	create(otherID, "Чужой отчёт", "")
+
+	results, total, err := tasks.SearchTasks(userID, "отчёт", 1, 10, TaskFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || len(results) != 2 {
+		t.Fatalf("Ожидалось 2 своих задачи, получено %d", total)
+	}
+	// Совпадение в названии релевантнее, чем в описании
+	if results[0].ID != report.ID || results[1].ID != mention.ID {
+		t.Errorf("Неверный порядок: %d, %d", results[0].ID, results[1].ID)
+	}
+	if results[0].Highlights.Title != "Квартальный <mark>отчёт</mark>" {
+		t.Errorf("Неверная подсветка названия: %q", results[0].Highlights.Title)
+	}
+	if !strings.Contains(results[0].Highlights.Description, "&lt;цифры&gt;") {
+		t.Errorf("Текст должен быть экранирован: %q", results[0].Highlights.Description)
+	}
+
+	// Префикс без учёта регистра
+	if results, _, _ := tasks.SearchTasks(userID, "ОТЧ", 1, 10, TaskFilter{}); len(results) != 2 {
+		t.Errorf("Поиск по префиксу без учёта регистра: найдено %d", len(results))
+	}
+	// Английские слова ищутся по основе
+	if results, _, _ := tasks.SearchTasks(userID, "report", 1, 10, TaskFilter{}); len(results) != 1 || results[0].ID != english.ID {
+		t.Errorf("Поиск report должен найти Write reports: %+v", results)
+	}
+	// Фраза — слова подряд
+	if results, _, _ := tasks.SearchTasks(userID, `"отчёт с командой"`, 1, 10, TaskFilter{}); len(results) != 1 || results[0].ID != mention.ID {
+		t.Errorf("Поиск фразы: %+v", results)
+	}
+	if results, _, _ := tasks.SearchTasks(userID, `"командой отчёт"`, 1, 10, TaskFilter{}); len(results) != 0 {
+		t.Errorf("Слова фразы в другом порядке не должны находиться: %d", len(results))
+	}
+
+	// Вместе с фильтром по статусу и пагинацией
+	status := models.TaskStatusCompleted
+	if _, err := tasks.UpdateTask(mention.ID, userID, TaskUpdate{Status: &status}); err != nil {
+		t.Fatal(err)
+	}
+	results, total, err = tasks.SearchTasks(userID, "отчёт", 1, 10, TaskFilter{Status: models.TaskStatusCompleted})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || results[0].ID != mention.ID {
+		t.Errorf("Фильтр по статусу: %d задач", total)
+	}
+	results, total, _ = tasks.SearchTasks(userID, "отчёт", 2, 1, TaskFilter{})
+	if total != 2 || len(results) != 1 || results[0].ID != mention.ID {
+		t.Errorf("Вторая страница должна содержать вторую задачу: %d, %+v", total, results)
+	}
+
+	// Индекс следует за изменениями и удалением
+	title := "Квартальный план"
+	if _, err := tasks.UpdateTask(report.ID, userID, TaskUpdate{Title: &title}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tasks.DeleteTask(mention.ID, userID); err != nil {
+		t.Fatal(err)
+	}
+	if _, total, _ := tasks.SearchTasks(userID, "отчёт", 1, 10, TaskFilter{}); total != 0 {
+		t.Errorf("После правки и удаления ничего не должно находиться, найдено %d", total)
+	}
+
+	if _, _, err := tasks.SearchTasks(userID, `"" *`, 1, 10, TaskFilter{}); !errors.Is(err, ErrInvalidSearchQuery) {
+		t.Errorf("Ожидалась ErrInvalidSearchQuery, получено %v", err)
+	}
+}
+
+func TestRunMigrations_RefusesFTSIndexWithoutFTS5(t *testing.T) {
+	setupServiceDB(t)
+
+	if available, err := migrations.FTS5Available(config.DB); err != nil {
+		t.Fatal(err)
+	} else if available {
+		t.Skip("SQLite собран с FTS5")
+	}
+
+	// БД, где индекс создала сборка с FTS5 (обычная таблица с тем же именем — для проверки)
+	if _, err := config.DB.Exec("CREATE TABLE tasks_fts (title TEXT, description TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrations.RunMigrations(config.DB); err == nil || !strings.Contains(err.Error(), "sqlite_fts5") {
+		t.Errorf("Ожидался отказ открыть БД с индексом FTS5, получено %v", err)
+	}
+}
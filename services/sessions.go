@@ -0,0 +1,153 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"server_new/config"
+	"server_new/models"
+)
+
+// ErrSessionNotFound — сессия не найдена или принадлежит другому пользователю
+var ErrSessionNotFound = errors.New("сессия не найдена")
+
+// touchInterval — как часто обновлять last_seen_at, если IP и User-Agent не менялись
+const touchInterval = 30 * time.Second
+
+// SessionsService управляет сессиями (входами с устройств) пользователя
+type SessionsService struct {
+	db *sql.DB
+}
+
+// NewSessionsService создаёт новый экземпляр сервиса
+func NewSessionsService() *SessionsService {
+	return &SessionsService{db: config.DB}
+}
+
+// ListByUserID возвращает активные сессии пользователя, последние активные — первыми
+func (s *SessionsService) ListByUserID(userID int) ([]models.Session, error) {
+	rows, err := s.db.Query(
+		`SELECT id, user_agent, ip, created_at, last_seen_at, expires_at FROM sessions
+		 WHERE user_id = ? AND revoked_at IS NULL AND expires_at > ?
+		 ORDER BY last_seen_at DESC`,
+		userID, formatDBTime(time.Now()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		var session models.Session
+		if err := rows.Scan(&session.ID, &session.UserAgent, &session.IP, &session.CreatedAt, &session.LastSeenAt, &session.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("ошибка чтения сессии: %v", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// Revoke завершает одну сессию пользователя
+func (s *SessionsService) Revoke(userID int, sessionID string) error {
+	var exists bool
+	err := s.db.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM sessions WHERE id = ? AND user_id = ? AND revoked_at IS NULL)",
+		sessionID, userID,
+	).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	if !exists {
+		return ErrSessionNotFound
+	}
+
+	return revokeSession(s.db, sessionID, time.Now())
+}
+
+// RevokeAll завершает все сессии пользователя («выйти везде»).
+// Если exceptID не пустой, эта сессия остаётся активной.
+func (s *SessionsService) RevokeAll(userID int, exceptID string) error {
+	if exceptID == "" {
+		return revokeUserSessions(s.db, userID, time.Now())
+	}
+
+	now := formatDBTime(time.Now())
+	_, err := s.db.Exec(
+		"UPDATE sessions SET revoked_at = ? WHERE user_id = ? AND id != ? AND revoked_at IS NULL",
+		now, userID, exceptID,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка завершения сессий: %v", err)
+	}
+	_, err = s.db.Exec(
+		"UPDATE refresh_tokens SET revoked_at = ? WHERE user_id = ? AND family_id != ? AND revoked_at IS NULL",
+		now, userID, exceptID,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка отзыва refresh токенов: %v", err)
+	}
+	return nil
+}
+
+// Touch запоминает время последней активности, IP и User-Agent сессии.
+// Чтобы не писать в БД на каждый запрос, время обновляется не чаще раза в touchInterval.
+func (s *SessionsService) Touch(sessionID, userAgent, ip string) error {
+	now := time.Now()
+	_, err := s.db.Exec(
+		`UPDATE sessions SET last_seen_at = ?, user_agent = ?, ip = ?
+		 WHERE id = ? AND (last_seen_at < ? OR user_agent != ? OR ip != ?)`,
+		formatDBTime(now), truncate(userAgent, 255), ip,
+		sessionID, formatDBTime(now.Add(-touchInterval)), truncate(userAgent, 255), ip,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления сессии: %v", err)
+	}
+	return nil
+}
+
+// revokeSession отзывает сессию и все refresh токены её семейства
+func revokeSession(ex execer, sessionID string, now time.Time) error {
+	if _, err := ex.Exec(
+		"UPDATE sessions SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL",
+		formatDBTime(now), sessionID,
+	); err != nil {
+		return fmt.Errorf("ошибка завершения сессии: %v", err)
+	}
+	if _, err := ex.Exec(
+		"UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL",
+		formatDBTime(now), sessionID,
+	); err != nil {
+		return fmt.Errorf("ошибка отзыва refresh токенов: %v", err)
+	}
+	return nil
+}
+
+// revokeUserSessions отзывает все сессии и refresh токены пользователя
+func revokeUserSessions(ex execer, userID int, now time.Time) error {
+	if _, err := ex.Exec(
+		"UPDATE sessions SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL",
+		formatDBTime(now), userID,
+	); err != nil {
+		return fmt.Errorf("ошибка завершения сессий: %v", err)
+	}
+	if _, err := ex.Exec(
+		"UPDATE refresh_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL",
+		formatDBTime(now), userID,
+	); err != nil {
+		return fmt.Errorf("ошибка отзыва refresh токенов: %v", err)
+	}
+	return nil
+}
+
+// truncate обрезает строку до max байт (User-Agent бывает очень длинным)
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return strings.ToValidUTF8(s[:max], "")
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"server_new/utils"
+)
+
+func TestSessionsService_RevokeRejectsAccessToken(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "carol")
+
+	pair, err := NewAuthService().IssueTokens(userID, "test-agent", "127.0.0.1")
+	if err != nil {
+		t.Fatal("IssueTokens():", err)
+	}
+
+	claims, err := utils.ParseToken(pair.AccessToken)
+	if err != nil {
+		t.Fatal("ParseToken():", err)
+	}
+
+	service := NewSessionsService()
+	sessions, err := service.ListByUserID(userID)
+	if err != nil || len(sessions) != 1 || sessions[0].ID != claims.ID {
+		t.Fatalf("ListByUserID() = %v, %v; want одну сессию %s", sessions, err, claims.ID)
+	}
+
+	if err := service.Revoke(userID+1, claims.ID); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Revoke(чужая сессия) error = %v, want %v", err, ErrSessionNotFound)
+	}
+	if err := service.Revoke(userID, claims.ID); err != nil {
+		t.Fatal("Revoke():", err)
+	}
+
+	// Access токен ещё не истёк, но сессия завершена
+	if _, err := utils.ParseToken(pair.AccessToken); !errors.Is(err, utils.ErrSessionRevoked) {
+		t.Errorf("ParseToken(после отзыва) error = %v, want %v", err, utils.ErrSessionRevoked)
+	}
+	if _, err := NewAuthService().Refresh(pair.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("Refresh(после отзыва) error = %v, want %v", err, ErrInvalidRefreshToken)
+	}
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"server_new/models"
+)
+
+// maxTaskDepth — сколько уровней может быть в дереве задач: задача, подзадача, подзадача подзадачи
+const maxTaskDepth = 3
+
+var (
+	// ErrParentTaskNotFound — родительская задача не найдена или недоступна пользователю
+	ErrParentTaskNotFound = errors.New("родительская задача не найдена")
+	// ErrTaskCycle — задачу нельзя сделать подзадачей её самой или её подзадачи
+	ErrTaskCycle = errors.New("задача не может быть подзадачей самой себя или своей подзадачи")
+	// ErrTaskTooDeep — после изменения дерево задач станет глубже maxTaskDepth
+	ErrTaskTooDeep = fmt.Errorf("подзадачи могут быть вложены не больше чем на %d уровня", maxTaskDepth)
+	// ErrOpenSubtasks — задачу с невыполненными подзадачами нельзя завершить без CompleteSubtasks
+	ErrOpenSubtasks = errors.New("у задачи есть невыполненные подзадачи")
+	// ErrSubtaskProject — подзадача и родительская задача в разных проектах
+	ErrSubtaskProject = errors.New("подзадача должна быть в том же проекте, что и родительская задача")
+)
+
+// taskProgressSQL — прогресс по прямым подзадачам и чек-листу (выполнено, всего) для задачи из FROM tasks
+const taskProgressSQL = `(SELECT COUNT(CASE WHEN s.status = 'completed' THEN 1 END) FROM tasks s WHERE s.parent_id = tasks.id),
+	(SELECT COUNT(*) FROM tasks s WHERE s.parent_id = tasks.id),
+	(SELECT COUNT(CASE WHEN c.done THEN 1 END) FROM task_checklist_items c WHERE c.task_id = tasks.id),
+	(SELECT COUNT(*) FROM task_checklist_items c WHERE c.task_id = tasks.id)`
+
+// descendantsSQL — ID всех подзадач задачи на любой глубине. Параметр: ID задачи.
+const descendantsSQL = `WITH RECURSIVE descendants(id) AS (
+		SELECT id FROM tasks WHERE parent_id = ?
+		UNION
+		SELECT t.id FROM tasks t JOIN descendants d ON t.parent_id = d.id
+	)`
+
+// checkTaskParent проверяет, что задачу taskID (0 — новую) из проекта projectID (nil — вне проектов)
+// можно сделать подзадачей parentID: у пользователя есть права editor на родителя, родитель в том же
+// проекте, не получается цикл и не превышена глубина дерева
+func checkTaskParent(tx *sql.Tx, userID, taskID, parentID int, projectID *int) error {
+	if parentID == taskID {
+		return ErrTaskCycle
+	}
+
+	if err := requireTaskRole(tx, userID, parentID, models.ProjectRoleEditor); err != nil {
+		if errors.Is(err, ErrTaskNotFound) {
+			return ErrParentTaskNotFound
+		}
+		return err
+	}
+
+	// Иначе прогресс и complete_subtasks родителя затрагивали бы задачи, невидимые участникам его проекта
+	var parentProjectID sql.NullInt64
+	if err := tx.QueryRow("SELECT project_id FROM tasks WHERE id = ?", parentID).Scan(&parentProjectID); err != nil {
+		return fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	if parentProjectID.Valid != (projectID != nil) || (projectID != nil && int(parentProjectID.Int64) != *projectID) {
+		return ErrSubtaskProject
+	}
+
+	// Цепочка от родителя до задачи верхнего уровня
+	rows, err := tx.Query(
+		`WITH RECURSIVE ancestors(id, parent_id) AS (
+			SELECT id, parent_id FROM tasks WHERE id = ?
+			UNION
+			SELECT t.id, t.parent_id FROM tasks t JOIN ancestors a ON t.id = a.parent_id
+		)
+		SELECT id FROM ancestors`,
+		parentID,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка получения родительских задач: %v", err)
+	}
+	defer rows.Close()
+
+	parentDepth := 0
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return fmt.Errorf("ошибка чтения родительской задачи: %v", err)
+		}
+		if id == taskID {
+			return ErrTaskCycle
+		}
+		parentDepth++
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("ошибка при итерации: %v", err)
+	}
+	rows.Close()
+
+	// Высота поддерева самой задачи: у новой задачи подзадач нет
+	height := 1
+	if taskID != 0 {
+		err := tx.QueryRow(
+			`WITH RECURSIVE subtree(id, level) AS (
+				SELECT id, 1 FROM tasks WHERE id = ?
+				UNION ALL
+				SELECT t.id, s.level + 1 FROM tasks t JOIN subtree s ON t.parent_id = s.id WHERE s.level <= ?
+			)
+			SELECT MAX(level) FROM subtree`,
+			taskID, maxTaskDepth,
+		).Scan(&height)
+		if err != nil {
+			return fmt.Errorf("ошибка получения подзадач: %v", err)
+		}
+	}
+
+	if parentDepth+height > maxTaskDepth {
+		return ErrTaskTooDeep
+	}
+
+	return nil
+}
+
+// moveSubtasks переносит подзадачи taskID на любой глубине в проект projectID (nil — вне проектов)
+// вслед за самой задачей и снимает исполнителей, которые потеряли к ним доступ
+func moveSubtasks(tx *sql.Tx, taskID int, projectID *int, now string) error {
+	var project interface{}
+	if projectID != nil {
+		project = *projectID
+	}
+	_, err := tx.Exec(
+		descendantsSQL+" UPDATE tasks SET project_id = ?, updated_at = ? WHERE id IN descendants",
+		taskID, project, now,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка переноса подзадач: %v", err)
+	}
+
+	rows, err := tx.Query(
+		descendantsSQL+" SELECT id, assignee_id FROM tasks WHERE id IN descendants AND assignee_id IS NOT NULL",
+		taskID,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка получения исполнителей подзадач: %v", err)
+	}
+	defer rows.Close()
+
+	assignees := map[int]int{}
+	for rows.Next() {
+		var id, assigneeID int
+		if err := rows.Scan(&id, &assigneeID); err != nil {
+			return fmt.Errorf("ошибка чтения подзадачи: %v", err)
+		}
+		assignees[id] = assigneeID
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("ошибка при итерации: %v", err)
+	}
+	rows.Close()
+
+	for id, assigneeID := range assignees {
+		if err := dropInaccessibleAssignee(tx, id, assigneeID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// completeSubtasks вызывается при завершении задачи: если у неё есть невыполненные подзадачи
+// (на любой глубине), они завершаются вместе с ней при complete, иначе возвращается ErrOpenSubtasks.
+// Повторяющиеся подзадачи, как и при обычном завершении, порождают следующие повторения.
+func completeSubtasks(tx *sql.Tx, actorID, taskID int, complete bool, now string) error {
+	var open int
+	err := tx.QueryRow(
+		descendantsSQL+" SELECT COUNT(*) FROM tasks WHERE id IN descendants AND status != ?",
+		taskID, models.TaskStatusCompleted,
+	).Scan(&open)
+	if err != nil {
+		return fmt.Errorf("ошибка проверки подзадач: %v", err)
+	}
+	if open == 0 {
+		return nil
+	}
+	if !complete {
+		return ErrOpenSubtasks
+	}
+
+	// Подзадачи завершаются в обход checkBlockers, поэтому проверяем их блокировки здесь.
+	// Блокеры, которые завершаются вместе с ними (сама задача и её подзадачи), не в счёт.
+	var blocked bool
+	err = tx.QueryRow(
+		descendantsSQL+` SELECT EXISTS (SELECT 1 FROM tasks t
+			JOIN task_dependencies d ON d.task_id = t.id
+			JOIN tasks b ON b.id = d.blocked_by_id
+			WHERE t.id IN descendants AND t.status != ? AND b.status != ?
+				AND b.id != ? AND b.id NOT IN descendants)`,
+		taskID, models.TaskStatusCompleted, models.TaskStatusCompleted, taskID,
+	).Scan(&blocked)
+	if err != nil {
+		return fmt.Errorf("ошибка проверки зависимостей подзадач: %v", err)
+	}
+	if blocked {
+		return ErrTaskBlocked
+	}
+
+	recurring, err := taskIDs(tx,
+		descendantsSQL+" SELECT id FROM tasks WHERE id IN descendants AND status != ? AND recurrence_rule IS NOT NULL",
+		taskID, models.TaskStatusCompleted,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		descendantsSQL+" UPDATE tasks SET status = ?, completed_at = ?, updated_at = ? WHERE id IN descendants AND status != ?",
+		taskID, models.TaskStatusCompleted, now, now, models.TaskStatusCompleted,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка завершения подзадач: %v", err)
+	}
+
+	for _, id := range recurring {
+		if err := spawnNextOccurrence(tx, actorID, id, time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"server_new/config"
+	"server_new/models"
+)
+
+// createSubtask создаёт задачу-подзадачу parentID (0 — задачу верхнего уровня)
+func createSubtask(t *testing.T, tasks *TasksService, userID, parentID int, title string) *models.Task {
+	t.Helper()
+	input := NewTask{Title: title, Status: models.TaskStatusPending, Priority: models.PriorityNormal}
+	if parentID != 0 {
+		input.ParentID = &parentID
+	}
+	task, err := tasks.CreateTask(userID, input)
+	if err != nil {
+		t.Fatalf("Не удалось создать задачу %q: %v", title, err)
+	}
+	return task
+}
+
+func TestSubtasks_DepthAndCycles(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "alice")
+	otherID := createTestUser(t, "bob")
+	tasks := NewTasksService()
+
+	root := createSubtask(t, tasks, userID, 0, "Корень")
+	child := createSubtask(t, tasks, userID, root.ID, "Подзадача")
+	grandchild := createSubtask(t, tasks, userID, child.ID, "Подзадача подзадачи")
+
+	if child.ParentID == nil || *child.ParentID != root.ID {
+		t.Errorf("parent_id = %v, ожидался %d", child.ParentID, root.ID)
+	}
+
+	// Четвёртый уровень не допускается
+	if _, err := tasks.CreateTask(userID, NewTask{Title: "Слишком глубоко", Status: models.TaskStatusPending, Priority: models.PriorityNormal, ParentID: &grandchild.ID}); !errors.Is(err, ErrTaskTooDeep) {
+		t.Errorf("Ожидалась ErrTaskTooDeep, получено %v", err)
+	}
+
+	// Нельзя сделать задачу подзадачей её самой или её потомка
+	if _, err := tasks.UpdateTask(root.ID, userID, TaskUpdate{ParentID: &root.ID}); !errors.Is(err, ErrTaskCycle) {
+		t.Errorf("Ожидалась ErrTaskCycle для самой себя, получено %v", err)
+	}
+	if _, err := tasks.UpdateTask(root.ID, userID, TaskUpdate{ParentID: &grandchild.ID}); !errors.Is(err, ErrTaskCycle) {
+		t.Errorf("Ожидалась ErrTaskCycle для потомка, получено %v", err)
+	}
+
+	// Перенос поддерева учитывает его высоту
+	other := createSubtask(t, tasks, userID, 0, "Другой корень")
+	otherChild := createSubtask(t, tasks, userID, other.ID, "Другая подзадача")
+	if _, err := tasks.UpdateTask(child.ID, userID, TaskUpdate{ParentID: &otherChild.ID}); !errors.Is(err, ErrTaskTooDeep) {
+		t.Errorf("Ожидалась ErrTaskTooDeep при переносе поддерева, получено %v", err)
+	}
+	if _, err := tasks.UpdateTask(child.ID, userID, TaskUpdate{ParentID: &other.ID}); err != nil {
+		t.Errorf("Перенос поддерева на допустимую глубину: %v", err)
+	}
+
+	// Чужая задача не может быть родителем
+	if _, err := tasks.CreateTask(otherID, NewTask{Title: "Чужая", Status: models.TaskStatusPending, Priority: models.PriorityNormal, ParentID: &root.ID}); !errors.Is(err, ErrParentTaskNotFound) {
+		t.Errorf("Ожидалась ErrParentTaskNotFound, получено %v", err)
+	}
+
+	// Удаление родителя удаляет подзадачи
+	if err := tasks.DeleteTask(other.ID, userID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tasks.GetTaskByID(grandchild.ID, userID); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Подзадачи должны удаляться вместе с родителем, получено %v", err)
+	}
+}
+
+func TestSubtasks_ProgressAndCompletion(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "alice")
+	tasks := NewTasksService()
+
+	parent := createSubtask(t, tasks, userID, 0, "Родитель")
+	first := createSubtask(t, tasks, userID, parent.ID, "Первая")
+	createSubtask(t, tasks, userID, parent.ID, "Вторая")
+	nested := createSubtask(t, tasks, userID, first.ID, "Вложенная")
+
+	completed := models.TaskStatusCompleted
+	if _, err := tasks.UpdateTask(nested.ID, userID, TaskUpdate{Status: &completed}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := tasks.GetTaskByID(parent.ID, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Subtasks != (models.TaskProgress{Done: 0, Total: 2}) {
+		t.Errorf("Прогресс подзадач = %+v, ожидалось 0/2", got.Subtasks)
+	}
+
+	list, total, err := tasks.GetTasksByUserID(userID, 1, 10, TaskFilter{ParentID: parent.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || len(list) != 2 {
+		t.Errorf("Ожидались 2 прямые подзадачи, получено %d", total)
+	}
+
+	// Без complete_subtasks завершение блокируется
+	if _, err := tasks.UpdateTask(parent.ID, userID, TaskUpdate{Status: &completed}); !errors.Is(err, ErrOpenSubtasks) {
+		t.Errorf("Ожидалась ErrOpenSubtasks, получено %v", err)
+	}
+
+	got, err = tasks.UpdateTask(parent.ID, userID, TaskUpdate{Status: &completed, CompleteSubtasks: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != models.TaskStatusCompleted || got.Subtasks != (models.TaskProgress{Done: 2, Total: 2}) {
+		t.Errorf("После завершения: статус %s, прогресс %+v", got.Status, got.Subtasks)
+	}
+	sub, err := tasks.GetTaskByID(first.ID, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.Status != models.TaskStatusCompleted || sub.CompletedAt == nil {
+		t.Errorf("Подзадача должна быть завершена: %s", sub.Status)
+	}
+}
+
+func TestChecklist(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "alice")
+	otherID := createTestUser(t, "bob")
+	tasks := NewTasksService()
+	checklist := NewChecklistService()
+
+	task := createSubtask(t, tasks, userID, 0, "С чек-листом")
+
+	var ids []int
+	for _, title := range []string{"Раз", "Два", "Три"} {
+		item, err := checklist.Create(userID, task.ID, title)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, item.ID)
+	}
+
+	if _, err := checklist.Create(otherID, task.ID, "Чужой"); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Ожидалась ErrTaskNotFound, получено %v", err)
+	}
+
+	done := true
+	item, err := checklist.Update(userID, task.ID, ids[1], nil, &done)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !item.Done {
+		t.Error("Пункт должен быть отмечен")
+	}
+
+	got, err := tasks.GetTaskByID(task.ID, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Checklist != (models.TaskProgress{Done: 1, Total: 3}) {
+		t.Errorf("Прогресс чек-листа = %+v, ожидалось 1/3", got.Checklist)
+	}
+
+	// Порядок должен перечислять все пункты ровно один раз
+	if _, err := checklist.Reorder(userID, task.ID, []int{ids[0], ids[0], ids[1]}); !errors.Is(err, ErrChecklistOrder) {
+		t.Errorf("Ожидалась ErrChecklistOrder для повтора, получено %v", err)
+	}
+	if _, err := checklist.Reorder(userID, task.ID, ids[:2]); !errors.Is(err, ErrChecklistOrder) {
+		t.Errorf("Ожидалась ErrChecklistOrder для неполного списка, получено %v", err)
+	}
+
+	items, err := checklist.Reorder(userID, task.ID, []int{ids[2], ids[0], ids[1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items[0].ID != ids[2] || items[1].ID != ids[0] || items[2].ID != ids[1] {
+		t.Errorf("Неверный порядок после Reorder: %+v", items)
+	}
+
+	if err := checklist.Delete(userID, task.ID, ids[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := checklist.Delete(userID, task.ID, ids[0]); !errors.Is(err, ErrChecklistItemNotFound) {
+		t.Errorf("Ожидалась ErrChecklistItemNotFound, получено %v", err)
+	}
+}
+
+func TestSubtasks_CompletionRespectsBlockers(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "alice")
+	tasks := NewTasksService()
+
+	parent := createSubtask(t, tasks, userID, 0, "Родитель")
+	first := createSubtask(t, tasks, userID, parent.ID, "Первая")
+	second := createSubtask(t, tasks, userID, parent.ID, "Вторая")
+	nested := createSubtask(t, tasks, userID, first.ID, "Вложенная")
+	outside := createSubtask(t, tasks, userID, 0, "Внешняя")
+
+	// Блокер среди подзадач завершается вместе с ними и не мешает
+	if _, err := tasks.AddDependency(userID, second.ID, first.ID); err != nil {
+		t.Fatal(err)
+	}
+	// Незавершённая внешняя задача блокирует вложенную подзадачу
+	if _, err := tasks.AddDependency(userID, nested.ID, outside.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	completed := models.TaskStatusCompleted
+	if _, err := tasks.UpdateTask(parent.ID, userID, TaskUpdate{Status: &completed, CompleteSubtasks: true}); !errors.Is(err, ErrTaskBlocked) {
+		t.Errorf("Ожидалась ErrTaskBlocked, получено %v", err)
+	}
+	sub, err := tasks.GetTaskByID(nested.ID, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.Status == models.TaskStatusCompleted {
+		t.Error("Заблокированная подзадача не должна завершаться")
+	}
+
+	if _, err := tasks.UpdateTask(outside.ID, userID, TaskUpdate{Status: &completed}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tasks.UpdateTask(parent.ID, userID, TaskUpdate{Status: &completed, CompleteSubtasks: true}); err != nil {
+		t.Errorf("После завершения блокера: %v", err)
+	}
+}
+
+func TestDeleteTask_RemovesSubtasksOnPooledConnections(t *testing.T) {
+	setupPooledServiceDB(t)
+	userID := createTestUser(t, "alice")
+	tasks := NewTasksService()
+
+	parent := createSubtask(t, tasks, userID, 0, "Родитель")
+	child := createSubtask(t, tasks, userID, parent.ID, "Подзадача")
+	grandchild := createSubtask(t, tasks, userID, child.ID, "Вложенная")
+	if _, err := NewChecklistService().Create(userID, child.ID, "Пункт"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Удаление идёт не через первое соединение пула
+	holdConnection(t)
+	holdConnection(t)
+
+	if err := tasks.DeleteTask(parent.ID, userID); err != nil {
+		t.Fatal(err)
+	}
+
+	var orphans, items int
+	config.DB.QueryRow("SELECT COUNT(*) FROM tasks WHERE id IN (?, ?)", child.ID, grandchild.ID).Scan(&orphans)
+	config.DB.QueryRow("SELECT COUNT(*) FROM task_checklist_items").Scan(&items)
+	if orphans != 0 || items != 0 {
+		t.Errorf("После удаления родителя остались подзадачи: %d, пункты чек-листа: %d", orphans, items)
+	}
+}
+
+func TestSubtasks_SameProjectAsParent(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "alice")
+	tasks := NewTasksService()
+
+	project, err := NewProjectsService().Create(userID, "Проект", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent, err := tasks.CreateTask(userID, NewTask{Title: "В проекте", Status: models.TaskStatusPending, Priority: models.PriorityNormal, ProjectID: &project.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	outside := createSubtask(t, tasks, userID, 0, "Вне проектов")
+
+	// Задачу вне проекта нельзя сделать подзадачей задачи проекта — ни при создании, ни при изменении
+	if _, err := tasks.CreateTask(userID, NewTask{Title: "Подзадача", Status: models.TaskStatusPending, Priority: models.PriorityNormal, ParentID: &parent.ID}); !errors.Is(err, ErrSubtaskProject) {
+		t.Errorf("Ожидалась ErrSubtaskProject, получено %v", err)
+	}
+	if _, err := tasks.UpdateTask(outside.ID, userID, TaskUpdate{ParentID: &parent.ID}); !errors.Is(err, ErrSubtaskProject) {
+		t.Errorf("Ожидалась ErrSubtaskProject, получено %v", err)
+	}
+	if _, err := tasks.UpdateTask(parent.ID, userID, TaskUpdate{ParentID: &outside.ID}); !errors.Is(err, ErrSubtaskProject) {
+		t.Errorf("Ожидалась ErrSubtaskProject, получено %v", err)
+	}
+
+	// Вместе с переносом в проект — можно
+	moved, err := tasks.UpdateTask(outside.ID, userID, TaskUpdate{ProjectID: &project.ID, ParentID: &parent.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Подзадачу нельзя унести из проекта родителя, а сам родитель переносится вместе с подзадачами
+	if _, err := tasks.UpdateTask(moved.ID, userID, TaskUpdate{ClearProject: true}); !errors.Is(err, ErrSubtaskProject) {
+		t.Errorf("Ожидалась ErrSubtaskProject, получено %v", err)
+	}
+	if _, err := tasks.UpdateTask(parent.ID, userID, TaskUpdate{ClearProject: true}); err != nil {
+		t.Fatal(err)
+	}
+	sub, err := tasks.GetTaskByID(moved.ID, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.ProjectID != nil {
+		t.Errorf("Подзадача должна переехать вместе с родителем, project_id = %d", *sub.ProjectID)
+	}
+}
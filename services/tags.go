@@ -0,0 +1,242 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"server_new/config"
+	"server_new/models"
+)
+
+var (
+	// ErrTagNotFound — метка не найдена или принадлежит другому пользователю
+	ErrTagNotFound = errors.New("метка не найдена")
+	// ErrTagExists — у пользователя уже есть метка с таким названием
+	ErrTagExists = errors.New("метка с таким названием уже есть")
+)
+
+// TagsService содержит методы для работы с метками задач
+type TagsService struct {
+	db *sql.DB
+}
+
+// NewTagsService создаёт новый экземпляр сервиса
+func NewTagsService() *TagsService {
+	return &TagsService{db: config.DB}
+}
+
+// ListByUserID возвращает метки пользователя по алфавиту вместе с количеством задач
+func (s *TagsService) ListByUserID(userID int) ([]models.Tag, error) {
+	rows, err := s.db.Query(
+		`SELECT t.id, t.name, t.color, t.created_at,
+		        (SELECT COUNT(*) FROM task_tags tt WHERE tt.tag_id = t.id)
+		 FROM tags t WHERE t.user_id = ?
+		 ORDER BY t.name`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	tags := []models.Tag{}
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.Color, &tag.CreatedAt, &tag.TaskCount); err != nil {
+			return nil, fmt.Errorf("ошибка чтения метки: %v", err)
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации: %v", err)
+	}
+
+	return tags, nil
+}
+
+// GetByID возвращает метку пользователя
+func (s *TagsService) GetByID(userID, tagID int) (*models.Tag, error) {
+	var tag models.Tag
+	err := s.db.QueryRow(
+		`SELECT t.id, t.name, t.color, t.created_at,
+		        (SELECT COUNT(*) FROM task_tags tt WHERE tt.tag_id = t.id)
+		 FROM tags t WHERE t.id = ? AND t.user_id = ?`,
+		tagID, userID,
+	).Scan(&tag.ID, &tag.Name, &tag.Color, &tag.CreatedAt, &tag.TaskCount)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTagNotFound
+		}
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	return &tag, nil
+}
+
+// Create создаёт метку
+func (s *TagsService) Create(userID int, name, color string) (*models.Tag, error) {
+	result, err := s.db.Exec(
+		"INSERT INTO tags (user_id, name, color, created_at) VALUES (?, ?, ?, CURRENT_TIMESTAMP)",
+		userID, name, color,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint") {
+			return nil, ErrTagExists
+		}
+		return nil, fmt.Errorf("ошибка создания метки: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения ID метки: %v", err)
+	}
+
+	return s.GetByID(userID, int(id))
+}
+
+// Update переименовывает метку и/или меняет её цвет (nil — поле не меняется)
+func (s *TagsService) Update(userID, tagID int, name, color *string) (*models.Tag, error) {
+	updates := []string{}
+	params := []interface{}{}
+
+	if name != nil {
+		updates = append(updates, "name = ?")
+		params = append(params, *name)
+	}
+	if color != nil {
+		updates = append(updates, "color = ?")
+		params = append(params, *color)
+	}
+	if len(updates) == 0 {
+		return s.GetByID(userID, tagID)
+	}
+
+	params = append(params, tagID, userID)
+	result, err := s.db.Exec(
+		"UPDATE tags SET "+strings.Join(updates, ", ")+" WHERE id = ? AND user_id = ?",
+		params...,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint") {
+			return nil, ErrTagExists
+		}
+		return nil, fmt.Errorf("ошибка обновления метки: %v", err)
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return nil, ErrTagNotFound
+	}
+
+	return s.GetByID(userID, tagID)
+}
+
+// Delete удаляет метку; с задач она снимается, сами задачи не меняются
+func (s *TagsService) Delete(userID, tagID int) error {
+	result, err := s.db.Exec("DELETE FROM tags WHERE id = ? AND user_id = ?", tagID, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления метки: %v", err)
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return ErrTagNotFound
+	}
+
+	return nil
+}
+
+// setTaskTags заменяет метки пользователя на задаче (метки других участников общего проекта
+// не трогаются). Метки ищутся по названию без учёта регистра, недостающие создаются.
+func setTaskTags(tx *sql.Tx, userID, taskID int, names []string) error {
+	_, err := tx.Exec(
+		"DELETE FROM task_tags WHERE task_id = ? AND tag_id IN (SELECT id FROM tags WHERE user_id = ?)",
+		taskID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка снятия меток: %v", err)
+	}
+
+	for _, name := range uniqueTagNames(names) {
+		_, err := tx.Exec("INSERT OR IGNORE INTO tags (user_id, name, created_at) VALUES (?, ?, CURRENT_TIMESTAMP)", userID, name)
+		if err != nil {
+			return fmt.Errorf("ошибка создания метки: %v", err)
+		}
+
+		_, err = tx.Exec(
+			`INSERT OR IGNORE INTO task_tags (task_id, tag_id)
+			 SELECT ?, id FROM tags WHERE user_id = ? AND name = ?`,
+			taskID, userID, name,
+		)
+		if err != nil {
+			return fmt.Errorf("ошибка установки метки: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// loadTaskTags заполняет названия меток пользователя у задач одним запросом
+func loadTaskTags(q rowsQuerier, userID int, tasks []models.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	index := make(map[int]int, len(tasks))
+	args := make([]interface{}, 0, len(tasks)+1)
+	args = append(args, userID)
+	for i := range tasks {
+		tasks[i].Tags = []string{}
+		index[tasks[i].ID] = i
+		args = append(args, tasks[i].ID)
+	}
+
+	rows, err := q.Query(
+		`SELECT tt.task_id, t.name FROM task_tags tt
+		 JOIN tags t ON t.id = tt.tag_id
+		 WHERE t.user_id = ? AND tt.task_id IN (`+placeholders(len(tasks))+`)
+		 ORDER BY t.name`,
+		args...,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка получения меток: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskID int
+		var name string
+		if err := rows.Scan(&taskID, &name); err != nil {
+			return fmt.Errorf("ошибка чтения метки: %v", err)
+		}
+		if i, ok := index[taskID]; ok {
+			tasks[i].Tags = append(tasks[i].Tags, name)
+		}
+	}
+
+	return rows.Err()
+}
+
+// uniqueTagNames убирает пробелы по краям, пустые названия и повторы (без учёта регистра)
+func uniqueTagNames(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		key := strings.ToLower(name)
+		if name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, name)
+	}
+	return result
+}
+
+// placeholders возвращает "?, ?, ?" для n параметров
+func placeholders(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strings.Repeat("?, ", n-1) + "?"
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"server_new/config"
+	"server_new/models"
+)
+
+func TestTagsService_TaskTagFilters(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "alice")
+	tasks := NewTasksService()
+
+	create := func(title string, tags ...string) *models.Task {
+		t.Helper()
+		task, err := tasks.CreateTask(userID, NewTask{Title: title, Status: models.TaskStatusPending, Priority: models.PriorityNormal, Tags: tags})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return task
+	}
+	both := create("обе метки", "work", "Urgent")
+	create("только work", "WORK")
+	create("без меток")
+
+	if len(both.Tags) != 2 || both.Tags[0] != "Urgent" || both.Tags[1] != "work" {
+		t.Errorf("Метки задачи = %v", both.Tags)
+	}
+
+	// Регистр не важен: "WORK" — та же метка, что и "work"
+	list, err := NewTagsService().ListByUserID(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("Ожидалось 2 метки, получено %d: %+v", len(list), list)
+	}
+
+	_, total, err := tasks.GetTasksByUserID(userID, 1, 10, TaskFilter{AnyTags: []string{"work", "urgent"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 {
+		t.Errorf("any-of: ожидалось 2 задачи, получено %d", total)
+	}
+
+	found, total, err := tasks.GetTasksByUserID(userID, 1, 10, TaskFilter{AllTags: []string{"work", "urgent"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(found) != 1 || found[0].ID != both.ID {
+		t.Errorf("all-of: ожидалась одна задача %d, получено %+v", both.ID, found)
+	}
+
+	// Фильтр по метке сочетается с фильтром по статусу
+	_, total, err = tasks.GetTasksByUserID(userID, 1, 10, TaskFilter{Status: models.TaskStatusCompleted, AnyTags: []string{"work"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 0 {
+		t.Errorf("Выполненных задач с меткой быть не должно, получено %d", total)
+	}
+
+	// Пустой список снимает все метки
+	empty := []string{}
+	updated, err := tasks.UpdateTask(both.ID, userID, TaskUpdate{Tags: &empty})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updated.Tags) != 0 {
+		t.Errorf("Метки должны быть сняты, осталось %v", updated.Tags)
+	}
+}
+
+func TestTagsService_CRUD(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "alice")
+	otherID := createTestUser(t, "bob")
+	service := NewTagsService()
+
+	tag, err := service.Create(userID, "home", "#00ff00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Create(userID, "HOME", ""); !errors.Is(err, ErrTagExists) {
+		t.Errorf("Ожидалась ErrTagExists, получено %v", err)
+	}
+
+	// У другого пользователя может быть метка с тем же названием, а чужая метка недоступна
+	if _, err := service.Create(otherID, "home", ""); err != nil {
+		t.Errorf("Метка другого пользователя не должна конфликтовать: %v", err)
+	}
+	if err := service.Delete(otherID, tag.ID); !errors.Is(err, ErrTagNotFound) {
+		t.Errorf("Ожидалась ErrTagNotFound, получено %v", err)
+	}
+
+	name := "дом"
+	tag, err = service.Update(userID, tag.ID, &name, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag.Name != "дом" || tag.Color != "#00ff00" {
+		t.Errorf("Метка после изменения: %+v", tag)
+	}
+
+	if err := service.Delete(userID, tag.ID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeleteTask_RemovesTaskTags(t *testing.T) {
+	setupPooledServiceDB(t)
+	userID := createTestUser(t, "alice")
+	tasks := NewTasksService()
+
+	task, err := tasks.CreateTask(userID, NewTask{Title: "С метками", Status: models.TaskStatusPending, Priority: models.PriorityNormal, Tags: []string{"work", "urgent"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	holdConnection(t)
+	if err := tasks.DeleteTask(task.ID, userID); err != nil {
+		t.Fatal(err)
+	}
+
+	var links int
+	config.DB.QueryRow("SELECT COUNT(*) FROM task_tags WHERE task_id = ?", task.ID).Scan(&links)
+	if links != 0 {
+		t.Errorf("После удаления задачи осталось связей с метками: %d", links)
+	}
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"server_new/models"
+)
+
+// ErrAssigneeNoAccess — назначаемый пользователь не видит задачу (не автор и не участник её проекта)
+var ErrAssigneeNoAccess = errors.New("у пользователя нет доступа к задаче")
+
+// Assign назначает исполнителя задачи. Назначать может автор или участник проекта с ролью
+// не ниже editor; исполнитель сам должен иметь доступ к задаче.
+func (s *TasksService) Assign(taskID, actorID, assigneeID int) (*models.Task, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := requireTaskRole(tx, actorID, taskID, models.ProjectRoleEditor); err != nil {
+		return nil, err
+	}
+
+	if _, err := taskRole(tx, assigneeID, taskID); err != nil {
+		if errors.Is(err, ErrTaskNotFound) {
+			return nil, ErrAssigneeNoAccess
+		}
+		return nil, err
+	}
+
+	snapshot, err := snapshotTasks(tx, actorID, []int{taskID})
+	if err != nil {
+		return nil, err
+	}
+
+	now := formatDBTime(time.Now())
+	_, err = tx.Exec(
+		"UPDATE tasks SET assignee_id = ?, assigned_by = ?, assigned_at = ?, updated_at = ? WHERE id = ?",
+		assigneeID, actorID, now, now, taskID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка назначения исполнителя: %v", err)
+	}
+
+	if err := snapshot.record(tx); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка сохранения задачи: %v", err)
+	}
+
+	return s.GetTaskByID(taskID, actorID)
+}
+
+// Unassign снимает исполнителя с задачи (те же права, что и для назначения)
+func (s *TasksService) Unassign(taskID, actorID int) (*models.Task, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := requireTaskRole(tx, actorID, taskID, models.ProjectRoleEditor); err != nil {
+		return nil, err
+	}
+
+	snapshot, err := snapshotTasks(tx, actorID, []int{taskID})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(
+		"UPDATE tasks SET assignee_id = NULL, assigned_by = NULL, assigned_at = NULL, updated_at = ? WHERE id = ?",
+		formatDBTime(time.Now()), taskID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка снятия исполнителя: %v", err)
+	}
+
+	if err := snapshot.record(tx); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка сохранения задачи: %v", err)
+	}
+
+	return s.GetTaskByID(taskID, actorID)
+}
+
+// dropInaccessibleAssignee снимает исполнителя, если после переноса задачи он потерял к ней доступ
+func dropInaccessibleAssignee(tx *sql.Tx, taskID, assigneeID int) error {
+	_, err := taskRole(tx, assigneeID, taskID)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, ErrTaskNotFound) {
+		return err
+	}
+
+	_, err = tx.Exec(
+		"UPDATE tasks SET assignee_id = NULL, assigned_by = NULL, assigned_at = NULL WHERE id = ?",
+		taskID,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка снятия исполнителя: %v", err)
+	}
+	return nil
+}
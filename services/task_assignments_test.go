@@ -0,0 +1,86 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"server_new/models"
+)
+
+func TestTaskAssignment(t *testing.T) {
+	setupServiceDB(t)
+	ownerID := createTestUser(t, "owner")
+	memberID := createTestUser(t, "member")
+	strangerID := createTestUser(t, "stranger")
+	projects := NewProjectsService()
+	members := NewProjectMembersService()
+	tasks := NewTasksService()
+
+	project, err := projects.Create(ownerID, "Общий", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := members.Invite(ownerID, project.ID, "member", models.ProjectRoleViewer); err != nil {
+		t.Fatal(err)
+	}
+	if err := members.AcceptInvitation(memberID, project.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	task, err := tasks.CreateTask(ownerID, NewTask{Title: "Общая задача", Status: models.TaskStatusPending, Priority: models.PriorityNormal, ProjectID: &project.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Назначить можно только того, кто видит задачу
+	if _, err := tasks.Assign(task.ID, ownerID, strangerID); !errors.Is(err, ErrAssigneeNoAccess) {
+		t.Errorf("Ожидалась ErrAssigneeNoAccess, получено %v", err)
+	}
+	// viewer не может назначать исполнителей
+	if _, err := tasks.Assign(task.ID, memberID, memberID); !errors.Is(err, ErrProjectForbidden) {
+		t.Errorf("Ожидалась ErrProjectForbidden, получено %v", err)
+	}
+
+	assigned, err := tasks.Assign(task.ID, ownerID, memberID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assigned.AssigneeID == nil || *assigned.AssigneeID != memberID {
+		t.Errorf("Исполнитель = %v, ожидался %d", assigned.AssigneeID, memberID)
+	}
+	if assigned.AssignedBy == nil || *assigned.AssignedBy != ownerID || assigned.AssignedAt == nil {
+		t.Errorf("Должно быть известно, кто и когда назначил: %v, %v", assigned.AssignedBy, assigned.AssignedAt)
+	}
+
+	// «Назначено мне» и фильтр по автору
+	list, total, err := tasks.GetTasksByUserID(memberID, 1, 10, TaskFilter{AssigneeID: memberID, CreatedBy: ownerID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(list) != 1 || list[0].ID != task.ID {
+		t.Errorf("Ожидалась одна назначенная задача, получено %d", total)
+	}
+	if _, total, _ := tasks.GetTasksByUserID(memberID, 1, 10, TaskFilter{AssigneeID: memberID, CreatedBy: memberID}); total != 0 {
+		t.Errorf("Фильтр по автору не сработал: %d", total)
+	}
+
+	// После выноса задачи из проекта участник теряет доступ — исполнитель снимается
+	moved, err := tasks.UpdateTask(task.ID, ownerID, TaskUpdate{ClearProject: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved.AssigneeID != nil || moved.AssignedBy != nil || moved.AssignedAt != nil {
+		t.Errorf("Исполнитель без доступа должен сниматься, получено %v", moved.AssigneeID)
+	}
+
+	if _, err := tasks.Assign(task.ID, ownerID, ownerID); err != nil {
+		t.Fatal(err)
+	}
+	unassigned, err := tasks.Unassign(task.ID, ownerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unassigned.AssigneeID != nil {
+		t.Errorf("Исполнитель должен быть снят, получено %v", unassigned.AssigneeID)
+	}
+}
@@ -0,0 +1,322 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"server_new/models"
+)
+
+// ErrInvalidCursor — курсор повреждён или получен не от этого списка
+var ErrInvalidCursor = errors.New("некорректный курсор")
+
+// taskEventColumns — колонки, которые читает scanTaskEvent (события — e, задачи — t, авторы — u)
+const taskEventColumns = "e.id, e.task_id, COALESCE(t.title, ''), e.actor_id, COALESCE(u.username, ''), e.type, e.changes, e.created_at"
+
+// taskEventsFromSQL — источник строк для taskEventColumns
+const taskEventsFromSQL = " FROM task_events e LEFT JOIN tasks t ON t.id = e.task_id LEFT JOIN users u ON u.id = e.actor_id"
+
+// TaskHistory возвращает историю задачи, новые события первыми. cursor — значение next_cursor
+// с предыдущей страницы ("" — с начала); второй результат — курсор следующей страницы ("" — это последняя).
+func (s *TasksService) TaskHistory(userID, taskID int, cursor string, limit int) ([]models.TaskEvent, string, error) {
+	if _, err := taskRole(s.db, userID, taskID); err != nil {
+		return nil, "", err
+	}
+	return s.listTaskEvents("e.task_id = ?", []interface{}{taskID}, cursor, limit)
+}
+
+// Activity возвращает ленту событий пользователя: его собственные изменения (в том числе
+// в удалённых задачах) и изменения других участников в доступных ему задачах
+func (s *TasksService) Activity(userID int, cursor string, limit int) ([]models.TaskEvent, string, error) {
+	return s.listTaskEvents(
+		"(e.actor_id = ? OR e.task_id IN (SELECT id FROM tasks WHERE "+taskAccessSQL+"))",
+		[]interface{}{userID, userID, userID, userID},
+		cursor, limit,
+	)
+}
+
+// listTaskEvents читает страницу событий по условию where (по убыванию id)
+func (s *TasksService) listTaskEvents(where string, args []interface{}, cursor string, limit int) ([]models.TaskEvent, string, error) {
+	if cursor != "" {
+		afterID, err := decodeEventCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		where += " AND e.id < ?"
+		args = append(args, afterID)
+	}
+
+	// Одна лишняя строка показывает, есть ли следующая страница
+	rows, err := s.db.Query(
+		"SELECT "+taskEventColumns+taskEventsFromSQL+" WHERE "+where+" ORDER BY e.id DESC LIMIT ?",
+		append(args, limit+1)...,
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	events := []models.TaskEvent{}
+	for rows.Next() {
+		event, err := scanTaskEvent(rows)
+		if err != nil {
+			return nil, "", fmt.Errorf("ошибка чтения события: %v", err)
+		}
+		events = append(events, *event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("ошибка при итерации: %v", err)
+	}
+
+	next := ""
+	if len(events) > limit {
+		events = events[:limit]
+		next = encodeEventCursor(events[limit-1].ID)
+	}
+	return events, next, nil
+}
+
+// scanTaskEvent читает событие из строки с колонками taskEventColumns
+func scanTaskEvent(row rowScanner) (*models.TaskEvent, error) {
+	var event models.TaskEvent
+	var actorID sql.NullInt64
+	var changes string
+	err := row.Scan(&event.ID, &event.TaskID, &event.TaskTitle, &actorID, &event.ActorName, &event.Type, &changes, &event.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	event.ActorID = nullIntPtr(actorID)
+	if err := json.Unmarshal([]byte(changes), &event.Changes); err != nil {
+		return nil, fmt.Errorf("некорректные изменения в событии %d: %v", event.ID, err)
+	}
+	return &event, nil
+}
+
+// encodeEventCursor превращает ID последнего события страницы в непрозрачный курсор
+func encodeEventCursor(id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(id)))
+}
+
+// decodeEventCursor разбирает курсор, выданный encodeEventCursor
+func decodeEventCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	id, err := strconv.Atoi(string(raw))
+	if err != nil || id < 1 {
+		return 0, ErrInvalidCursor
+	}
+	return id, nil
+}
+
+// taskSnapshot — состояние задач до изменения, чтобы после него записать в историю разницу
+type taskSnapshot struct {
+	actorID int
+	before  []models.Task
+}
+
+// snapshotTasks запоминает задачи ids (метки — глазами actorID) перед изменением
+func snapshotTasks(tx *sql.Tx, actorID int, ids []int) (*taskSnapshot, error) {
+	tasks, err := readTasks(tx, actorID, ids)
+	if err != nil {
+		return nil, err
+	}
+	return &taskSnapshot{actorID: actorID, before: tasks}, nil
+}
+
+// record пишет в историю, что изменилось у запомненных задач: удалённые — событием deleted,
+// смена статуса — status_changed, остальные поля — updated
+func (s *taskSnapshot) record(tx *sql.Tx) error {
+	ids := make([]int, len(s.before))
+	for i, task := range s.before {
+		ids[i] = task.ID
+	}
+	after, err := readTasks(tx, s.actorID, ids)
+	if err != nil {
+		return err
+	}
+	current := make(map[int]*models.Task, len(after))
+	for i := range after {
+		current[after[i].ID] = &after[i]
+	}
+
+	for i := range s.before {
+		before := &s.before[i]
+		task, ok := current[before.ID]
+		if !ok {
+			if err := recordTaskEvent(tx, before.ID, s.actorID, models.TaskEventDeleted, diffTasks(before, &models.Task{})); err != nil {
+				return err
+			}
+			continue
+		}
+
+		changes := diffTasks(before, task)
+		if status, ok := changes["status"]; ok {
+			delete(changes, "status")
+			statusChange := map[string]models.FieldChange{"status": status}
+			if err := recordTaskEvent(tx, before.ID, s.actorID, models.TaskEventStatusChanged, statusChange); err != nil {
+				return err
+			}
+		}
+		if len(changes) > 0 {
+			if err := recordTaskEvent(tx, before.ID, s.actorID, models.TaskEventUpdated, changes); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// recordTaskCreated пишет в историю создание задачи с её начальными значениями
+func recordTaskCreated(tx *sql.Tx, actorID, taskID int) error {
+	tasks, err := readTasks(tx, actorID, []int{taskID})
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		return ErrTaskNotFound
+	}
+	return recordTaskEvent(tx, taskID, actorID, models.TaskEventCreated, diffTasks(&models.Task{}, &tasks[0]))
+}
+
+// recordTaskEvent добавляет событие в историю задачи
+func recordTaskEvent(e execer, taskID, actorID int, eventType string, changes map[string]models.FieldChange) error {
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации изменений: %v", err)
+	}
+
+	_, err = e.Exec(
+		"INSERT INTO task_events (task_id, actor_id, type, changes, created_at) VALUES (?, ?, ?, ?, ?)",
+		taskID, actorID, eventType, string(data), formatDBTime(time.Now()),
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка записи истории задачи: %v", err)
+	}
+	return nil
+}
+
+// readTasks читает задачи по ID без проверки прав; отсутствующие пропускаются
+func readTasks(tx *sql.Tx, userID int, ids []int) ([]models.Task, error) {
+	tasks := []models.Task{}
+	if len(ids) == 0 {
+		return tasks, nil
+	}
+
+	rows, err := tx.Query("SELECT "+taskColumns+" FROM tasks WHERE id IN ("+placeholders(len(ids))+")", intArgs(ids)...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения задачи: %v", err)
+		}
+		tasks = append(tasks, *task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации: %v", err)
+	}
+	rows.Close()
+
+	if err := loadTaskTags(tx, userID, tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// taskIDs возвращает ID задач, выбранных запросом query (первая колонка)
+func taskIDs(tx *sql.Tx, query string, args ...interface{}) ([]int, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("ошибка чтения ID задачи: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// intArgs превращает ID в параметры запроса
+func intArgs(ids []int) []interface{} {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return args
+}
+
+// diffTasks возвращает поля, которые различаются у before и after
+func diffTasks(before, after *models.Task) map[string]models.FieldChange {
+	old, current := taskFieldValues(before), taskFieldValues(after)
+	changes := map[string]models.FieldChange{}
+	for field, value := range current {
+		if !reflect.DeepEqual(old[field], value) {
+			changes[field] = models.FieldChange{Old: old[field], New: value}
+		}
+	}
+	return changes
+}
+
+// taskFieldValues — поля задачи, изменения которых попадают в историю, в том виде, в каком
+// они пишутся в JSON. Отсутствующее значение (пустая строка, nil, нет меток) — nil.
+func taskFieldValues(task *models.Task) map[string]interface{} {
+	values := map[string]interface{}{
+		"title":       nil,
+		"description": nil,
+		"status":      nil,
+		"priority":    nil,
+		"due_at":      nil,
+		"project_id":  nil,
+		"parent_id":   nil,
+		"assignee_id": nil,
+		"tags":        nil,
+		"recurrence":  nil,
+	}
+
+	for field, value := range map[string]string{
+		"title":       task.Title,
+		"description": task.Description,
+		"status":      task.Status,
+		"priority":    task.Priority,
+	} {
+		if value != "" {
+			values[field] = value
+		}
+	}
+	for field, value := range map[string]*int{
+		"project_id":  task.ProjectID,
+		"parent_id":   task.ParentID,
+		"assignee_id": task.AssigneeID,
+	} {
+		if value != nil {
+			values[field] = *value
+		}
+	}
+	if task.DueAt != nil {
+		values["due_at"] = task.DueAt.UTC().Format(time.RFC3339)
+	}
+	if len(task.Tags) > 0 {
+		values["tags"] = task.Tags
+	}
+	if task.Recurrence != nil {
+		values["recurrence"] = map[string]string{"rrule": task.Recurrence.RRule, "timezone": task.Recurrence.Timezone}
+	}
+	return values
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"server_new/models"
+)
+
+func TestTaskHistory(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "alice")
+	otherID := createTestUser(t, "bob")
+	tasks := NewTasksService()
+
+	task := createSubtask(t, tasks, userID, 0, "Отчёт")
+	child := createSubtask(t, tasks, userID, task.ID, "Черновик")
+
+	title := "Квартальный отчёт"
+	status := models.TaskStatusCompleted
+	if _, err := tasks.UpdateTask(task.ID, userID, TaskUpdate{Title: &title, Status: &status, CompleteSubtasks: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	events, next, err := tasks.TaskHistory(userID, task.ID, "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next != "" || len(events) != 3 {
+		t.Fatalf("Ожидалось 3 события без следующей страницы, получено %d, %q", len(events), next)
+	}
+	// Новые события первыми: updated и status_changed в одной транзакции, затем created
+	if events[2].Type != models.TaskEventCreated || events[2].Changes["title"].New != "Отчёт" {
+		t.Errorf("Первое событие должно быть созданием: %+v", events[2])
+	}
+	if events[1].Type != models.TaskEventStatusChanged || events[1].Changes["status"].Old != models.TaskStatusPending ||
+		events[1].Changes["status"].New != models.TaskStatusCompleted {
+		t.Errorf("Ожидалась смена статуса: %+v", events[1])
+	}
+	if change, ok := events[0].Changes["title"]; events[0].Type != models.TaskEventUpdated || !ok || change.Old != "Отчёт" || change.New != title {
+		t.Errorf("Ожидалась правка названия: %+v", events[0])
+	}
+	if _, ok := events[0].Changes["description"]; ok {
+		t.Errorf("Неизменённые поля не должны попадать в историю: %+v", events[0].Changes)
+	}
+
+	// Подзадача, завершённая вместе с родителем, тоже получает событие
+	childEvents, _, err := tasks.TaskHistory(userID, child.ID, "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(childEvents) != 2 || childEvents[0].Type != models.TaskEventStatusChanged {
+		t.Errorf("Ожидалась смена статуса подзадачи: %+v", childEvents)
+	}
+
+	if _, _, err := tasks.TaskHistory(otherID, task.ID, "", 10); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Ожидалась ErrTaskNotFound, получено %v", err)
+	}
+
+	// Удаление видно в ленте автора и после того, как задачи не стало
+	if err := tasks.DeleteTask(task.ID, userID); err != nil {
+		t.Fatal(err)
+	}
+
+	page, cursor, err := tasks.Activity(userID, "", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 2 || cursor == "" {
+		t.Fatalf("Ожидалась полная страница с курсором, получено %d, %q", len(page), cursor)
+	}
+	for _, event := range page {
+		if event.Type != models.TaskEventDeleted {
+			t.Errorf("Последними должны быть удаления, получено %s", event.Type)
+		}
+	}
+
+	seen := map[int]bool{page[0].ID: true, page[1].ID: true}
+	total := len(page)
+	for cursor != "" {
+		page, cursor, err = tasks.Activity(userID, cursor, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, event := range page {
+			if seen[event.ID] {
+				t.Errorf("Событие %d встретилось дважды", event.ID)
+			}
+			seen[event.ID] = true
+		}
+		total += len(page)
+	}
+	// 2 создания, 2 смены статуса, 1 правка, 2 удаления
+	if total != 7 {
+		t.Errorf("Всего событий в ленте %d, ожидалось 7", total)
+	}
+
+	if other, _, err := tasks.Activity(otherID, "", 10); err != nil || len(other) != 0 {
+		t.Errorf("У чужого пользователя лента должна быть пустой: %d, %v", len(other), err)
+	}
+	if _, _, err := tasks.Activity(userID, "мусор", 10); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Ожидалась ErrInvalidCursor, получено %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"server_new/models"
+	"server_new/utils"
+)
+
+// TaskFilterParams — параметры запроса, из которых ParseTaskFilter собирает фильтр
+var TaskFilterParams = []string{
+	"status", "priority", "project_id", "assignee_id", "created_by", "series_id",
+	"tags_any", "tags_all", "due", "filter", "tz", "sort",
+}
+
+// ParseTaskFilter собирает фильтр списка задач из параметров запроса (GET /tasks, сохранённые виды)
+// и проверяет его. Ошибки — с текстом для ответа клиенту.
+func ParseTaskFilter(values url.Values) (TaskFilter, error) {
+	filter := TaskFilter{
+		Status:   values.Get("status"),
+		Priority: values.Get("priority"),
+		AnyTags:  splitTagNames(values.Get("tags_any")),
+		AllTags:  splitTagNames(values.Get("tags_all")),
+		Due:      values.Get("due"),
+		Query:    values.Get("filter"),
+		Sort:     values.Get("sort"),
+	}
+
+	if filter.Status != "" && !utils.ValidateTaskStatus(filter.Status) {
+		return filter, errors.New("Статус должен быть: pending, in_progress или completed")
+	}
+	if _, ok := models.PriorityRank(filter.Priority); filter.Priority != "" && !ok {
+		return filter, errors.New("Приоритет должен быть: low, normal, high или urgent")
+	}
+
+	for _, param := range []struct {
+		name string
+		dest *int
+	}{
+		{"project_id", &filter.ProjectID},
+		{"assignee_id", &filter.AssigneeID},
+		{"series_id", &filter.SeriesID},
+		{"created_by", &filter.CreatedBy},
+	} {
+		value := values.Get(param.name)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.Atoi(value)
+		if err != nil || id < 1 {
+			return filter, errors.New(param.name + " должен быть числом")
+		}
+		*param.dest = id
+	}
+
+	switch filter.Due {
+	case "", DueOverdue, DueToday, DueThisWeek:
+	default:
+		return filter, errors.New("Фильтр due должен быть: overdue, today или this_week")
+	}
+	if _, err := filter.sortKeys(); err != nil {
+		return filter, errors.New("Неверная сортировка: " + err.Error())
+	}
+	if tz := values.Get("tz"); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return filter, errors.New("Неизвестный часовой пояс")
+		}
+		filter.Location = loc
+	}
+
+	if err := filter.Validate(); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// Validate проверяет фильтр так же, как его проверит выборка задач, не обращаясь к БД
+func (f TaskFilter) Validate() error {
+	if _, _, err := f.where(0, time.Now()); err != nil {
+		return err
+	}
+	_, err := f.orderBy()
+	return err
+}
+
+// splitTagNames разбирает список меток из параметра запроса ("work,home")
+func splitTagNames(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"server_new/config"
+	"server_new/models"
+)
+
+// TaskPageRequest — какую страницу списка задач выбрать
+type TaskPageRequest struct {
+	Page   int    // номер страницы (с 1); с Cursor не используется
+	Limit  int    // размер страницы
+	Cursor string // next_cursor или prev_cursor предыдущей страницы ("" — постраничный режим)
+	Count  bool   // считать общее количество задач (отдельный запрос COUNT(*))
+}
+
+// TaskPage — страница списка задач
+type TaskPage struct {
+	Tasks      []models.Task
+	Total      int    // -1 — количество не запрашивалось
+	NextCursor string // "" — следующей страницы нет
+	PrevCursor string // "" — это первая страница
+}
+
+// sortKey — ключ сортировки списка задач и его направление
+type sortKey struct {
+	sortColumn
+	desc bool
+}
+
+// taskCursor — позиция в списке: значения ключей сортировки у крайней задачи страницы.
+// Backward — курсор на предыдущую страницу (задачи перед этой позицией).
+type taskCursor struct {
+	Sort     string    `json:"s"`
+	Values   []*string `json:"v"`
+	Backward bool      `json:"b,omitempty"`
+}
+
+// ListTasks возвращает страницу доступных пользователю задач. С курсором страница выбирается
+// по ключам сортировки (keyset), а не по смещению: вставка задач между запросами не даёт
+// повторов и пропусков. Курсоры следующей и предыдущей страниц выдаются в обоих режимах.
+func (s *TasksService) ListTasks(userID int, req TaskPageRequest, filter TaskFilter) (*TaskPage, error) {
+	where, args, err := filter.where(userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	where = taskAccessSQL + where
+	args = append([]interface{}{userID, userID, userID}, args...)
+
+	keys, err := filter.sortKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	pageWhere, pageArgs := where, args
+	var cursor *taskCursor
+	offset := 0
+	if req.Cursor != "" {
+		if cursor, err = decodeTaskCursor(req.Cursor, filter.Sort, len(keys)); err != nil {
+			return nil, err
+		}
+		condition, conditionArgs := keysetCondition(keys, cursor.Values, cursor.Backward)
+		pageWhere += " AND " + condition
+		pageArgs = append(append([]interface{}{}, args...), conditionArgs...)
+	} else {
+		offset = (req.Page - 1) * req.Limit
+	}
+	backward := cursor != nil && cursor.Backward
+
+	// Значения ключей читаются как текст: так они без потерь попадают в курсор
+	// и сравниваются с колонками так же, как хранятся в БД
+	keyColumns := make([]string, len(keys))
+	for i, key := range keys {
+		keyColumns[i] = "CAST(" + key.expr + " AS TEXT)"
+	}
+
+	// Одна лишняя строка показывает, есть ли страница дальше
+	rows, err := s.db.Query(
+		"SELECT "+taskColumns+", "+strings.Join(keyColumns, ", ")+" FROM tasks WHERE "+pageWhere+
+			" ORDER BY "+orderByKeys(keys, backward)+" LIMIT ? OFFSET ?",
+		append(pageArgs, req.Limit+1, offset)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	defer rows.Close()
+
+	tasks := []models.Task{}
+	positions := [][]*string{}
+	for rows.Next() {
+		values := make([]sql.NullString, len(keys))
+		dest := make([]interface{}, len(keys))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		task, err := scanTask(extraColumns{row: rows, dest: dest})
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения задачи: %v", err)
+		}
+		tasks = append(tasks, *task)
+		positions = append(positions, cursorValues(values))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации: %v", err)
+	}
+	rows.Close()
+
+	more := len(tasks) > req.Limit
+	if more {
+		tasks, positions = tasks[:req.Limit], positions[:req.Limit]
+	}
+	if backward {
+		for i, j := 0, len(tasks)-1; i < j; i, j = i+1, j-1 {
+			tasks[i], tasks[j] = tasks[j], tasks[i]
+			positions[i], positions[j] = positions[j], positions[i]
+		}
+	}
+
+	if err := loadTaskTags(s.db, userID, tasks); err != nil {
+		return nil, err
+	}
+
+	result := &TaskPage{Tasks: tasks, Total: -1}
+	if len(tasks) > 0 {
+		first, last := positions[0], positions[len(positions)-1]
+		if (backward && more) || (!backward && (cursor != nil || offset > 0)) {
+			result.PrevCursor = encodeTaskCursor(taskCursor{Sort: filter.Sort, Values: first, Backward: true})
+		}
+		if backward || more {
+			result.NextCursor = encodeTaskCursor(taskCursor{Sort: filter.Sort, Values: last})
+		}
+	}
+
+	if req.Count {
+		if err := s.db.QueryRow("SELECT COUNT(*) FROM tasks WHERE "+where, args...).Scan(&result.Total); err != nil {
+			return nil, fmt.Errorf("ошибка получения количества: %v", err)
+		}
+	}
+
+	return result, nil
+}
+
+// orderByKeys собирает ORDER BY по ключам; backward — в обратном порядке (для предыдущей страницы)
+func orderByKeys(keys []sortKey, backward bool) string {
+	terms := make([]string, 0, len(keys)+1)
+	for _, key := range keys {
+		desc := key.desc != backward
+		if key.nullable {
+			nulls := "ASC"
+			if backward {
+				nulls = "DESC"
+			}
+			terms = append(terms, key.expr+" IS NULL "+nulls)
+		}
+		direction := "ASC"
+		if desc {
+			direction = "DESC"
+		}
+		terms = append(terms, key.expr+" "+direction)
+	}
+	return strings.Join(terms, ", ")
+}
+
+// keysetCondition возвращает условие «строка идёт после позиции values» в порядке keys
+// (backward — «перед позицией»): (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ...
+// NULL в nullable колонках всегда в конце списка.
+func keysetCondition(keys []sortKey, values []*string, backward bool) (string, []interface{}) {
+	var or []string
+	var args []interface{}
+	var equal []string
+	var equalArgs []interface{}
+
+	for i, key := range keys {
+		op := ">"
+		if key.desc != backward {
+			op = "<"
+		}
+
+		var beyond string
+		var beyondArgs []interface{}
+		switch {
+		case key.nullable && values[i] == nil && !backward:
+			beyond = "" // после NULL в этой колонке ничего нет
+		case key.nullable && values[i] == nil:
+			beyond = key.expr + " IS NOT NULL"
+		case key.nullable && !backward:
+			beyond = "(" + key.expr + " IS NULL OR " + key.expr + " " + op + " ?)"
+			beyondArgs = []interface{}{*values[i]}
+		default:
+			beyond = key.expr + " " + op + " ?"
+			beyondArgs = []interface{}{derefCursorValue(values[i])}
+		}
+
+		if beyond != "" {
+			or = append(or, "("+strings.Join(append(append([]string{}, equal...), beyond), " AND ")+")")
+			args = append(append(args, equalArgs...), beyondArgs...)
+		}
+
+		if values[i] == nil {
+			equal = append(equal, key.expr+" IS NULL")
+		} else {
+			equal = append(equal, key.expr+" = ?")
+			equalArgs = append(equalArgs, *values[i])
+		}
+	}
+
+	if len(or) == 0 {
+		return "0", nil
+	}
+	return "(" + strings.Join(or, " OR ") + ")", args
+}
+
+// derefCursorValue — значение ключа для сравнения (NULL в не-nullable колонке не встречается)
+func derefCursorValue(value *string) interface{} {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
+
+// cursorValues переводит прочитанные значения ключей в значения курсора (nil — NULL)
+func cursorValues(values []sql.NullString) []*string {
+	result := make([]*string, len(values))
+	for i, value := range values {
+		if value.Valid {
+			v := value.String
+			result[i] = &v
+		}
+	}
+	return result
+}
+
+// encodeTaskCursor превращает позицию в непрозрачный курсор: данные и их HMAC-подпись,
+// чтобы клиент не мог подменить значения ключей
+func encodeTaskCursor(cursor taskCursor) string {
+	payload, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(signTaskCursor(payload))
+}
+
+// decodeTaskCursor проверяет подпись курсора и то, что он выдан для той же сортировки
+func decodeTaskCursor(raw, sort string, keyCount int) (*taskCursor, error) {
+	encodedPayload, encodedSignature, ok := strings.Cut(raw, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, signTaskCursor(payload)) {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor taskCursor
+	if err := json.Unmarshal(payload, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Sort != sort || len(cursor.Values) != keyCount {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+func signTaskCursor(payload []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(config.CursorSecret))
+	mac.Write([]byte("task-cursor:"))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
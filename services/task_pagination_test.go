@@ -0,0 +1,134 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"server_new/models"
+)
+
+func TestTasksService_ListTasksCursor(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "alice")
+	tasks := NewTasksService()
+
+	due := func(days int) *time.Time {
+		at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC).AddDate(0, 0, days)
+		return &at
+	}
+	for _, input := range []NewTask{
+		{Title: "a", Priority: models.PriorityHigh, DueAt: due(2)},
+		{Title: "b", Priority: models.PriorityLow},
+		{Title: "c", Priority: models.PriorityHigh, DueAt: due(1)},
+		{Title: "d", Priority: models.PriorityUrgent},
+		{Title: "e", Priority: models.PriorityNormal, DueAt: due(2)},
+		{Title: "f", Priority: models.PriorityHigh},
+		{Title: "g", Priority: models.PriorityLow, DueAt: due(5)},
+	} {
+		input.Status = models.TaskStatusPending
+		if _, err := tasks.CreateTask(userID, input); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids := func(list []models.Task) []int {
+		result := make([]int, len(list))
+		for i, task := range list {
+			result[i] = task.ID
+		}
+		return result
+	}
+
+	for _, sort := range []string{"", "due_at", "-due_at", "priority", "-priority", "created_at", "-updated_at", "title", "-priority,due_at", "priority,-due_at,title", "-completed_at,-title"} {
+		t.Run("sort="+sort, func(t *testing.T) {
+			filter := TaskFilter{Sort: sort}
+			all, _, err := tasks.GetTasksByUserID(userID, 1, 100, filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := ids(all)
+
+			// Вперёд по next_cursor
+			var forward []int
+			var last *TaskPage
+			cursor := ""
+			for {
+				page, err := tasks.ListTasks(userID, TaskPageRequest{Limit: 3, Cursor: cursor}, filter)
+				if err != nil {
+					t.Fatal(err)
+				}
+				forward = append(forward, ids(page.Tasks)...)
+				last = page
+				if page.NextCursor == "" {
+					break
+				}
+				cursor = page.NextCursor
+			}
+			if !equalInts(forward, want) {
+				t.Fatalf("Вперёд по курсорам: %v, ожидалось %v", forward, want)
+			}
+
+			// Назад по prev_cursor с последней страницы
+			backward := ids(last.Tasks)
+			for cursor = last.PrevCursor; cursor != ""; {
+				page, err := tasks.ListTasks(userID, TaskPageRequest{Limit: 3, Cursor: cursor}, filter)
+				if err != nil {
+					t.Fatal(err)
+				}
+				backward = append(ids(page.Tasks), backward...)
+				cursor = page.PrevCursor
+			}
+			if !equalInts(backward, want) {
+				t.Errorf("Назад по курсорам: %v, ожидалось %v", backward, want)
+			}
+		})
+	}
+
+	// Задача, добавленная между запросами, не сдвигает следующую страницу
+	first, err := tasks.ListTasks(userID, TaskPageRequest{Page: 1, Limit: 3}, TaskFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Total != -1 || first.PrevCursor != "" || first.NextCursor == "" {
+		t.Errorf("Первая страница: total=%d prev=%q next=%q", first.Total, first.PrevCursor, first.NextCursor)
+	}
+	if _, err := tasks.CreateTask(userID, NewTask{Title: "новая", Status: models.TaskStatusPending, Priority: models.PriorityNormal}); err != nil {
+		t.Fatal(err)
+	}
+	second, err := tasks.ListTasks(userID, TaskPageRequest{Limit: 3, Cursor: first.NextCursor, Count: true}, TaskFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Total != 8 || len(second.Tasks) != 3 || second.Tasks[0].ID != first.Tasks[2].ID-1 {
+		t.Errorf("Вторая страница: total=%d, задачи %v после %v", second.Total, ids(second.Tasks), ids(first.Tasks))
+	}
+
+	// Подделанный курсор и курсор от другой сортировки не принимаются
+	tampered := []byte(first.NextCursor)
+	tampered[2] ^= 1
+	for _, tt := range []struct {
+		cursor string
+		sort   string
+	}{
+		{string(tampered), ""},
+		{"garbage", ""},
+		{first.NextCursor, "priority"},
+	} {
+		if _, err := tasks.ListTasks(userID, TaskPageRequest{Limit: 3, Cursor: tt.cursor}, TaskFilter{Sort: tt.sort}); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("Курсор %q (sort=%q): ожидалась ErrInvalidCursor, получено %v", tt.cursor, tt.sort, err)
+		}
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"server_new/models"
+	"server_new/taskquery"
+)
+
+// taskQueryDateColumns — поля дат языка фильтров и их колонки
+var taskQueryDateColumns = map[string]string{
+	"due":       "due_at",
+	"created":   "created_at",
+	"updated":   "updated_at",
+	"completed": "completed_at",
+}
+
+// taskQueryComparisons — операторы языка фильтров в SQL
+var taskQueryComparisons = map[taskquery.Op]string{
+	taskquery.OpEq:  "=",
+	taskquery.OpGt:  ">",
+	taskquery.OpGte: ">=",
+	taskquery.OpLt:  "<",
+	taskquery.OpLte: "<=",
+}
+
+// compileTaskQuery переводит запрос на языке фильтров (пакет taskquery) в условия SQL,
+// начиная с " AND ...". Ошибки в полях и значениях — *taskquery.Error с указанием на условие.
+// Даты без времени понимаются как сутки в часовом поясе loc.
+func compileTaskQuery(input string, userID int, loc *time.Location) (string, []interface{}, error) {
+	query, err := taskquery.Parse(input)
+	if err != nil {
+		return "", nil, err
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	var sb strings.Builder
+	args := []interface{}{}
+	for _, term := range query.Terms {
+		condition, termArgs, err := compileTaskTerm(term, userID, loc)
+		if err != nil {
+			return "", nil, err
+		}
+		if term.Negated {
+			// COALESCE: задача без значения (например, без срока) не подходит под условие,
+			// значит подходит под его отрицание
+			condition = "NOT COALESCE((" + condition + "), 0)"
+		}
+		sb.WriteString(" AND " + condition)
+		args = append(args, termArgs...)
+	}
+	return sb.String(), args, nil
+}
+
+// compileTaskTerm переводит одно условие (без учёта отрицания)
+func compileTaskTerm(term taskquery.Term, userID int, loc *time.Location) (string, []interface{}, error) {
+	if term.Field == "" {
+		pattern := likePattern(term.Value)
+		return `(title LIKE ? ESCAPE '\' OR description LIKE ? ESCAPE '\')`, []interface{}{pattern, pattern}, nil
+	}
+
+	if column, ok := taskQueryDateColumns[term.Field]; ok {
+		return compileDateTerm(term, column, loc)
+	}
+
+	if term.Field == "priority" {
+		rank, ok := models.PriorityRank(strings.ToLower(term.Value))
+		if !ok {
+			return "", nil, term.Errorf("приоритет должен быть: low, normal, high или urgent")
+		}
+		return "priority " + taskQueryComparisons[term.Op] + " ?", []interface{}{rank}, nil
+	}
+
+	if term.Op != taskquery.OpEq {
+		return "", nil, term.Errorf("поле %s сравнивается только через «:»", term.Field)
+	}
+	value := term.Value
+
+	switch term.Field {
+	case "status":
+		value = strings.ToLower(value)
+		switch value {
+		case models.TaskStatusPending, models.TaskStatusInProgress, models.TaskStatusCompleted:
+			return "status = ?", []interface{}{value}, nil
+		}
+		return "", nil, term.Errorf("статус должен быть: pending, in_progress или completed")
+
+	case "project", "assignee", "parent":
+		column := term.Field + "_id"
+		switch strings.ToLower(value) {
+		case "none":
+			return column + " IS NULL", nil, nil
+		case "me":
+			if term.Field == "assignee" {
+				return "assignee_id = ?", []interface{}{userID}, nil
+			}
+		}
+		id, err := strconv.Atoi(value)
+		if err != nil || id < 1 {
+			return "", nil, term.Errorf("значение %s — ID или none", term.Field)
+		}
+		return column + " = ?", []interface{}{id}, nil
+
+	case "tag":
+		if strings.TrimSpace(value) == "" {
+			return "", nil, term.Errorf("не указана метка")
+		}
+		return `id IN (SELECT tt.task_id FROM task_tags tt JOIN tags t ON t.id = tt.tag_id
+			WHERE t.user_id = ? AND t.name = ? COLLATE NOCASE)`, []interface{}{userID, strings.TrimSpace(value)}, nil
+
+	case "title":
+		if value == "" {
+			return "", nil, term.Errorf("пустое значение")
+		}
+		return `title LIKE ? ESCAPE '\'`, []interface{}{likePattern(value)}, nil
+
+	case "is":
+		switch strings.ToLower(value) {
+		case "blocked":
+			return taskBlockedSQL, nil, nil
+		case "overdue":
+			return "due_at < ? AND status != ?", []interface{}{formatDBTime(time.Now()), models.TaskStatusCompleted}, nil
+		case "recurring":
+			return "recurrence_rule IS NOT NULL", nil, nil
+		case "subtask":
+			return "parent_id IS NOT NULL", nil, nil
+		}
+		return "", nil, term.Errorf("is может быть: blocked, overdue, recurring или subtask")
+	}
+
+	return "", nil, term.Errorf("неизвестное поле %s; доступны status, priority, due, created, updated, "+
+		"completed, project, assignee, parent, tag, title, is", term.Field)
+}
+
+// compileDateTerm переводит условие по дате: день (2026-01-31) или момент (RFC 3339), для due
+// и completed также none — значение не задано
+func compileDateTerm(term taskquery.Term, column string, loc *time.Location) (string, []interface{}, error) {
+	if strings.EqualFold(term.Value, "none") {
+		if term.Op != taskquery.OpEq || column == "created_at" || column == "updated_at" {
+			return "", nil, term.Errorf("none допустимо только как %s:none для due и completed", term.Field)
+		}
+		return column + " IS NULL", nil, nil
+	}
+
+	if moment, err := time.Parse(time.RFC3339, term.Value); err == nil {
+		return column + " " + taskQueryComparisons[term.Op] + " ?", []interface{}{formatDBTime(moment)}, nil
+	}
+
+	day, err := time.ParseInLocation("2006-01-02", term.Value, loc)
+	if err != nil {
+		return "", nil, term.Errorf("дата должна быть в формате ГГГГ-ММ-ДД или RFC 3339")
+	}
+	start, end := formatDBTime(day), formatDBTime(day.AddDate(0, 0, 1))
+
+	switch term.Op {
+	case taskquery.OpGt:
+		return column + " >= ?", []interface{}{end}, nil
+	case taskquery.OpGte:
+		return column + " >= ?", []interface{}{start}, nil
+	case taskquery.OpLt:
+		return column + " < ?", []interface{}{start}, nil
+	case taskquery.OpLte:
+		return column + " < ?", []interface{}{end}, nil
+	default:
+		return column + " >= ? AND " + column + " < ?", []interface{}{start, end}, nil
+	}
+}
+
+// likePattern превращает текст в шаблон LIKE «содержит», экранируя % и _
+func likePattern(text string) string {
+	text = strings.ReplaceAll(text, `\`, `\\`)
+	text = strings.ReplaceAll(text, "%", `\%`)
+	text = strings.ReplaceAll(text, "_", `\_`)
+	return "%" + text + "%"
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"server_new/models"
+	"server_new/taskquery"
+)
+
+func TestTaskFilter_Query(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "alice")
+	tasks := NewTasksService()
+
+	create := func(title, priority string, due *time.Time, tags ...string) *models.Task {
+		t.Helper()
+		task, err := tasks.CreateTask(userID, NewTask{Title: title, Status: models.TaskStatusPending, Priority: priority, DueAt: due, Tags: tags})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return task
+	}
+
+	due := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	report := create("Годовой отчёт", models.PriorityUrgent, &due, "work")
+	draft := create("Черновик 100% готов", models.PriorityHigh, nil)
+	later := create("Прочее", models.PriorityLow, nil, "home")
+
+	done := models.TaskStatusCompleted
+	if _, err := tasks.UpdateTask(later.ID, userID, TaskUpdate{Status: &done}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"", []int{later.ID, draft.ID, report.ID}},
+		{"status:pending", []int{draft.ID, report.ID}},
+		{"-status:completed priority>=high", []int{draft.ID, report.ID}},
+		{"priority<high", []int{later.ID}},
+		{"due:2026-03-10", []int{report.ID}},
+		{"due>2026-03-10", []int{}},
+		{"due<=2026-03-10", []int{report.ID}},
+		{"due:none", []int{later.ID, draft.ID}},
+		{"-due<2026-03-11", []int{later.ID, draft.ID}},
+		{`"Годовой отчёт"`, []int{report.ID}},
+		{`"годовой отчёт"`, []int{}}, // LIKE в SQLite не различает регистр только у латиницы
+		{"100%", []int{draft.ID}},
+		{"tag:WORK", []int{report.ID}},
+		{"-tag:work", []int{later.ID, draft.ID}},
+		{"title:Проч is:subtask", []int{}},
+		{"created>2000-01-01 assignee:none", []int{later.ID, draft.ID, report.ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			list, total, err := tasks.GetTasksByUserID(userID, 1, 10, TaskFilter{Query: tt.query})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []int{}
+			for _, task := range list {
+				got = append(got, task.ID)
+			}
+			if total != len(tt.want) || len(got) != len(tt.want) {
+				t.Fatalf("Найдено %v (всего %d), ожидалось %v", got, total, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Найдено %v, ожидалось %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	for _, query := range []string{"colour:red", "status>pending", "due:tomorrow", "created:none", "is:big", `title:""`} {
+		var queryErr *taskquery.Error
+		if err := (TaskFilter{Query: query}).Validate(); !errors.As(err, &queryErr) || queryErr.Token != query {
+			t.Errorf("Validate(%q): ожидалась ошибка в «%s», получено %v", query, query, err)
+		}
+	}
+}
@@ -4,13 +4,100 @@ package services
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"server_new/config"
 	"server_new/models"
 )
 
+var (
+	// ErrTaskNotFound — задача не найдена или принадлежит другому пользователю
+	ErrTaskNotFound = errors.New("задача не найдена")
+	// ErrNoTaskChanges — в запросе на обновление нет ни одного поля
+	ErrNoTaskChanges = errors.New("нет данных для обновления")
+)
+
+// Фильтры по сроку выполнения
+const (
+	DueOverdue  = "overdue"   // срок прошёл, задача не выполнена
+	DueToday    = "today"     // срок — сегодня
+	DueThisWeek = "this_week" // срок — на этой неделе (с понедельника по воскресенье)
+)
+
+// sortColumn — колонка, по которой сортируется список задач
+type sortColumn struct {
+	expr     string
+	nullable bool // задачи с NULL всегда идут в конце, в любом направлении
+}
+
+// taskSortColumns — поля, по которым можно сортировать список задач, и их колонки
+// (для каждой есть индекс, см. миграцию 023)
+var taskSortColumns = map[string]sortColumn{
+	"title":        {expr: "title COLLATE NOCASE"},
+	"priority":     {expr: "priority"},
+	"due_at":       {expr: "due_at", nullable: true},
+	"completed_at": {expr: "completed_at", nullable: true},
+	"created_at":   {expr: "created_at"},
+	"updated_at":   {expr: "updated_at"},
+}
+
+// taskSortFields — поля сортировки для сообщений об ошибке, в порядке документации
+var taskSortFields = []string{"title", "priority", "due_at", "completed_at", "created_at", "updated_at"}
+
+// TaskFilter — параметры выборки списка задач
+type TaskFilter struct {
+	Status     string
+	Priority   string
+	ProjectID  int            // 0 — задачи из всех проектов
+	AssigneeID int            // 0 — с любым исполнителем
+	CreatedBy  int            // 0 — любого автора
+	ParentID   int            // 0 — без фильтра, иначе только прямые подзадачи этой задачи
+	SeriesID   int            // 0 — без фильтра, иначе только повторения этой серии
+	AnyTags    []string       // задача помечена хотя бы одной из меток
+	AllTags    []string       // задача помечена всеми метками
+	Due        string         // DueOverdue, DueToday или DueThisWeek
+	Query      string         // выражение языка фильтров (пакет taskquery)
+	Sort       string         // поля из taskSortColumns через запятую, "-" перед полем — по убыванию
+	Location   *time.Location // часовой пояс для «сегодня» и «эта неделя» (по умолчанию UTC)
+}
+
+// NewTask — данные для создания задачи
+type NewTask struct {
+	Title       string
+	Description string
+	Status      string
+	Priority    string
+	DueAt       *time.Time
+	ProjectID   *int     // nil — задача вне проектов
+	ParentID    *int     // nil — задача верхнего уровня
+	Tags        []string // названия меток; недостающие метки создаются
+	// Recurrence — правило повторения (Start не используется: серия начинается с DueAt)
+	Recurrence *models.TaskRecurrence
+}
+
+// TaskUpdate — изменяемые поля задачи (nil — поле не меняется)
+type TaskUpdate struct {
+	Title           *string
+	Description     *string
+	Status          *string
+	Priority        *string
+	DueAt           *time.Time
+	ClearDueAt      bool                   // убрать срок выполнения
+	ProjectID       *int                   // перенести в другой проект
+	ClearProject    bool                   // вынести из проекта
+	ParentID        *int                   // сделать подзадачей другой задачи
+	ClearParent     bool                   // сделать задачей верхнего уровня
+	Tags            *[]string              // новый набор меток (пустой — снять все)
+	Recurrence      *models.TaskRecurrence // новое правило повторения: серия начинается с текущего срока
+	ClearRecurrence bool                   // задача перестаёт повторяться
+	// CompleteSubtasks — при завершении задачи завершить и её открытые подзадачи;
+	// без него завершение задачи с открытыми подзадачами отклоняется (ErrOpenSubtasks)
+	CompleteSubtasks bool
+}
+
 // TasksService содержит методы для работы с задачами
 type TasksService struct {
 	db *sql.DB
@@ -21,62 +108,272 @@ func NewTasksService() *TasksService {
 	return &TasksService{db: config.DB}
 }
 
-// GetTasksByUserID возвращает задачи пользователя с пагинацией и фильтрацией
-func (s *TasksService) GetTasksByUserID(userID, page, limit int, status string) ([]models.Task, int, error) {
-	offset := (page - 1) * limit
+// taskAccessSQL — задачи, доступные пользователю: созданные им вне проектов и задачи проектов,
+// где он владелец или участник (авторство задачи в проекте доступа не даёт — после исключения
+// из проекта автор теряет и свои задачи). Параметры: userID, userID, userID.
+const taskAccessSQL = "((userid = ? AND project_id IS NULL) OR project_id IN (" + accessibleProjectsSQL + "))"
+
+// taskColumns — колонки, которые читает scanTask
+const taskColumns = "id, title, description, status, userid, project_id, parent_id, assignee_id, assigned_by, assigned_at, " +
+	"priority, due_at, completed_at, created_at, updated_at, series_id, recurrence_rule, recurrence_tz, recurrence_start, " +
+	taskProgressSQL + ", " + taskBlockedSQL
+
+// rowScanner — общее у *sql.Row и *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
 
-	query := "SELECT id, title, description, status, userid FROM tasks WHERE userid = ?"
-	args := []interface{}{userID}
+// scanTask читает задачу из строки с колонками taskColumns
+func scanTask(row rowScanner) (*models.Task, error) {
+	var task models.Task
+	var priority int
+	var projectID, parentID, assigneeID, assignedBy, seriesID sql.NullInt64
+	var assignedAt, dueAt, completedAt, updatedAt, recurrenceStart sql.NullTime
+	var recurrenceRule sql.NullString
+	var recurrenceTZ string
 
-	if status != "" {
-		query += " AND status = ?"
-		args = append(args, status)
+	err := row.Scan(&task.ID, &task.Title, &task.Description, &task.Status, &task.UserID,
+		&projectID, &parentID, &assigneeID, &assignedBy, &assignedAt, &priority, &dueAt, &completedAt, &task.CreatedAt, &updatedAt,
+		&seriesID, &recurrenceRule, &recurrenceTZ, &recurrenceStart,
+		&task.Subtasks.Done, &task.Subtasks.Total, &task.Checklist.Done, &task.Checklist.Total, &task.Blocked)
+	if err != nil {
+		return nil, err
 	}
 
-	query += " ORDER BY id DESC LIMIT ? OFFSET ?"
-	args = append(args, limit, offset)
+	task.ProjectID = nullIntPtr(projectID)
+	task.ParentID = nullIntPtr(parentID)
+	task.AssigneeID = nullIntPtr(assigneeID)
+	task.AssignedBy = nullIntPtr(assignedBy)
+	if assignedAt.Valid {
+		task.AssignedAt = &assignedAt.Time
+	}
+	task.Priority = models.PriorityName(priority)
+	if dueAt.Valid {
+		task.DueAt = &dueAt.Time
+	}
+	if completedAt.Valid {
+		task.CompletedAt = &completedAt.Time
+	}
+	task.UpdatedAt = task.CreatedAt
+	if updatedAt.Valid {
+		task.UpdatedAt = updatedAt.Time
+	}
+	task.SeriesID = nullIntPtr(seriesID)
+	if recurrenceRule.Valid && recurrenceStart.Valid {
+		task.Recurrence = &models.TaskRecurrence{
+			RRule:    recurrenceRule.String,
+			Timezone: recurrenceTZ,
+			Start:    recurrenceStart.Time,
+		}
+	}
+
+	return &task, nil
+}
 
-	rows, err := s.db.Query(query, args...)
+// nullIntPtr превращает NullInt64 в *int (nil для NULL)
+func nullIntPtr(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	id := int(v.Int64)
+	return &id
+}
+
+// GetTasksByUserID возвращает доступные пользователю задачи (свои и из общих проектов)
+// с пагинацией по номеру страницы, фильтрацией и общим количеством
+func (s *TasksService) GetTasksByUserID(userID, page, limit int, filter TaskFilter) ([]models.Task, int, error) {
+	result, err := s.ListTasks(userID, TaskPageRequest{Page: page, Limit: limit, Count: true}, filter)
 	if err != nil {
-		return nil, 0, fmt.Errorf("ошибка запроса к БД: %v", err)
+		return nil, 0, err
 	}
-	defer rows.Close()
+	return result.Tasks, result.Total, nil
+}
+
+// where собирает условия фильтра (начиная с " AND ...") и их параметры.
+// Метки у каждого пользователя свои, поэтому фильтр по меткам учитывает userID.
+func (f TaskFilter) where(userID int, now time.Time) (string, []interface{}, error) {
+	var sb strings.Builder
+	args := []interface{}{}
 
-	var tasks []models.Task
-	for rows.Next() {
-		var task models.Task
-		err := rows.Scan(&task.ID, &task.Title, &task.Description, &task.Status, &task.UserID)
+	if f.Status != "" {
+		sb.WriteString(" AND status = ?")
+		args = append(args, f.Status)
+	}
+
+	if f.Priority != "" {
+		rank, ok := models.PriorityRank(f.Priority)
+		if !ok {
+			return "", nil, fmt.Errorf("неизвестный приоритет %q", f.Priority)
+		}
+		sb.WriteString(" AND priority = ?")
+		args = append(args, rank)
+	}
+
+	if f.ProjectID != 0 {
+		sb.WriteString(" AND project_id = ?")
+		args = append(args, f.ProjectID)
+	}
+
+	if f.AssigneeID != 0 {
+		sb.WriteString(" AND assignee_id = ?")
+		args = append(args, f.AssigneeID)
+	}
+
+	if f.CreatedBy != 0 {
+		sb.WriteString(" AND userid = ?")
+		args = append(args, f.CreatedBy)
+	}
+
+	if f.ParentID != 0 {
+		sb.WriteString(" AND parent_id = ?")
+		args = append(args, f.ParentID)
+	}
+
+	if f.SeriesID != 0 {
+		sb.WriteString(" AND series_id = ?")
+		args = append(args, f.SeriesID)
+	}
+
+	if names := uniqueTagNames(f.AnyTags); len(names) > 0 {
+		sb.WriteString(` AND id IN (SELECT tt.task_id FROM task_tags tt JOIN tags t ON t.id = tt.tag_id
+			WHERE t.user_id = ? AND t.name IN (` + placeholders(len(names)) + `))`)
+		args = append(args, userID)
+		for _, name := range names {
+			args = append(args, name)
+		}
+	}
+
+	if names := uniqueTagNames(f.AllTags); len(names) > 0 {
+		sb.WriteString(` AND id IN (SELECT tt.task_id FROM task_tags tt JOIN tags t ON t.id = tt.tag_id
+			WHERE t.user_id = ? AND t.name IN (` + placeholders(len(names)) + `)
+			GROUP BY tt.task_id HAVING COUNT(DISTINCT t.id) = ?)`)
+		args = append(args, userID)
+		for _, name := range names {
+			args = append(args, name)
+		}
+		args = append(args, len(names))
+	}
+
+	if f.Due != "" {
+		loc := f.Location
+		if loc == nil {
+			loc = time.UTC
+		}
+		local := now.In(loc)
+		startOfDay := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+
+		switch f.Due {
+		case DueOverdue:
+			sb.WriteString(" AND due_at < ? AND status != ?")
+			args = append(args, formatDBTime(now), models.TaskStatusCompleted)
+		case DueToday:
+			sb.WriteString(" AND due_at >= ? AND due_at < ?")
+			args = append(args, formatDBTime(startOfDay), formatDBTime(startOfDay.AddDate(0, 0, 1)))
+		case DueThisWeek:
+			// Неделя начинается с понедельника
+			daysSinceMonday := (int(local.Weekday()) + 6) % 7
+			startOfWeek := startOfDay.AddDate(0, 0, -daysSinceMonday)
+			sb.WriteString(" AND due_at >= ? AND due_at < ?")
+			args = append(args, formatDBTime(startOfWeek), formatDBTime(startOfWeek.AddDate(0, 0, 7)))
+		default:
+			return "", nil, fmt.Errorf("неизвестный фильтр срока %q", f.Due)
+		}
+	}
+
+	if f.Query != "" {
+		condition, queryArgs, err := compileTaskQuery(f.Query, userID, f.Location)
 		if err != nil {
-			continue
+			return "", nil, err
 		}
-		tasks = append(tasks, task)
+		sb.WriteString(condition)
+		args = append(args, queryArgs...)
 	}
 
-	if tasks == nil {
-		tasks = []models.Task{}
+	return sb.String(), args, nil
+}
+
+// sortKeys разбирает Sort ("title,-created_at,priority") в ключи сортировки. Последним ключом
+// всегда идёт id в направлении последнего поля, чтобы порядок был строгим и страницы
+// не пересекались. Без Sort — сначала новые задачи.
+func (f TaskFilter) sortKeys() ([]sortKey, error) {
+	if f.Sort == "" {
+		return []sortKey{{sortColumn: sortColumn{expr: "id"}, desc: true}}, nil
 	}
 
-	// Получаем общее количество
-	var total int
-	countQuery := "SELECT COUNT(*) FROM tasks WHERE userid = ?"
-	if status != "" {
-		countQuery += " AND status = ?"
-		err = s.db.QueryRow(countQuery, userID, status).Scan(&total)
-	} else {
-		err = s.db.QueryRow(countQuery, userID).Scan(&total)
+	fields := strings.Split(f.Sort, ",")
+	keys := make([]sortKey, 0, len(fields)+1)
+	seen := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		desc := strings.HasPrefix(field, "-")
+		field = strings.TrimPrefix(field, "-")
+
+		column, ok := taskSortColumns[field]
+		if !ok {
+			return nil, fmt.Errorf("сортировка по полю %q не поддерживается; доступны %s", field, strings.Join(taskSortFields, ", "))
+		}
+		if seen[field] {
+			return nil, fmt.Errorf("поле %q указано в сортировке дважды", field)
+		}
+		seen[field] = true
+
+		keys = append(keys, sortKey{sortColumn: column, desc: desc})
 	}
+
+	return append(keys, sortKey{sortColumn: sortColumn{expr: "id"}, desc: keys[len(keys)-1].desc}), nil
+}
+
+// orderBy возвращает выражение сортировки по ключам sortKeys
+func (f TaskFilter) orderBy() (string, error) {
+	keys, err := f.sortKeys()
 	if err != nil {
-		return nil, 0, fmt.Errorf("ошибка получения количества: %v", err)
+		return "", err
 	}
-
-	return tasks, total, nil
+	return orderByKeys(keys, false), nil
 }
 
 // CreateTask создаёт новую задачу
-func (s *TasksService) CreateTask(title, description, status string, userID int) (*models.Task, error) {
-	result, err := s.db.Exec(
-		"INSERT INTO tasks (title, description, status, userid) VALUES (?, ?, ?, ?)",
-		title, description, status, userID,
+func (s *TasksService) CreateTask(userID int, input NewTask) (*models.Task, error) {
+	priority, ok := models.PriorityRank(input.Priority)
+	if !ok {
+		return nil, fmt.Errorf("неизвестный приоритет %q", input.Priority)
+	}
+
+	now := formatDBTime(time.Now())
+
+	var dueAt, completedAt interface{}
+	if input.DueAt != nil {
+		dueAt = formatDBTime(*input.DueAt)
+	}
+	if input.Status == models.TaskStatusCompleted {
+		completedAt = now
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	var projectID interface{}
+	if input.ProjectID != nil {
+		if _, err := requireProjectRole(tx, userID, *input.ProjectID, models.ProjectRoleEditor); err != nil {
+			return nil, err
+		}
+		projectID = *input.ProjectID
+	}
+
+	var parentID interface{}
+	if input.ParentID != nil {
+		if err := checkTaskParent(tx, userID, 0, *input.ParentID, input.ProjectID); err != nil {
+			return nil, err
+		}
+		parentID = *input.ParentID
+	}
+
+	result, err := tx.Exec(
+		`INSERT INTO tasks (title, description, status, userid, project_id, parent_id, priority, due_at, completed_at, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		input.Title, input.Description, input.Status, userID, projectID, parentID, priority, dueAt, completedAt, now, now,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка вставки в БД: %v", err)
@@ -87,87 +384,321 @@ func (s *TasksService) CreateTask(title, description, status string, userID int)
 		return nil, fmt.Errorf("ошибка получения ID: %v", err)
 	}
 
-	return &models.Task{
-		ID:          int(id),
-		Title:       title,
-		Description: description,
-		Status:      status,
-		UserID:      userID,
-	}, nil
+	if input.Recurrence != nil {
+		if input.DueAt == nil {
+			return nil, ErrRecurrenceNeedsDue
+		}
+		if err := setRecurrence(tx, int(id), *input.Recurrence, *input.DueAt); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(input.Tags) > 0 {
+		if err := setTaskTags(tx, userID, int(id), input.Tags); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := recordTaskCreated(tx, userID, int(id)); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка сохранения задачи: %v", err)
+	}
+
+	return s.GetTaskByID(int(id), userID)
 }
 
-// GetTaskByID возвращает задачу по ID (только если она принадлежит пользователю)
+// GetTaskByID возвращает задачу по ID, если она доступна пользователю:
+// создана им или лежит в проекте, где он владелец или участник
 func (s *TasksService) GetTaskByID(taskID, userID int) (*models.Task, error) {
-	var task models.Task
-	var createdAt string
-	err := s.db.QueryRow(
-		"SELECT id, title, description, status, userid, created_at FROM tasks WHERE id = ? AND userid = ?",
-		taskID, userID,
-	).Scan(&task.ID, &task.Title, &task.Description, &task.Status, &task.UserID, &createdAt)
-
+	task, err := scanTask(s.db.QueryRow(
+		"SELECT "+taskColumns+" FROM tasks WHERE id = ? AND "+taskAccessSQL,
+		taskID, userID, userID, userID,
+	))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("задача не найдена")
+			return nil, ErrTaskNotFound
 		}
 		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
 	}
 
-	return &task, nil
+	tasks := []models.Task{*task}
+	if err := loadTaskTags(s.db, userID, tasks); err != nil {
+		return nil, err
+	}
+
+	return &tasks[0], nil
 }
 
-// UpdateTask обновляет задачу (только если она принадлежит пользователю)
-func (s *TasksService) UpdateTask(taskID, userID int, title, description, status string) (*models.Task, error) {
-	// Проверяем существование и владельца
-	_, err := s.GetTaskByID(taskID, userID)
+// UpdateTask обновляет задачу. Менять задачу может её автор, а в общем проекте —
+// участники с ролью не ниже editor. При переходе в completed выставляется completed_at,
+// при выходе из него — сбрасывается.
+func (s *TasksService) UpdateTask(taskID, userID int, update TaskUpdate) (*models.Task, error) {
+	// Проверяем существование и права
+	current, err := s.GetTaskByID(taskID, userID)
 	if err != nil {
 		return nil, err
 	}
+	if err := requireTaskRole(s.db, userID, taskID, models.ProjectRoleEditor); err != nil {
+		return nil, err
+	}
 
+	now := formatDBTime(time.Now())
 	updates := []string{}
 	params := []interface{}{}
 
-	if title != "" {
+	if update.Title != nil {
 		updates = append(updates, "title = ?")
-		params = append(params, title)
+		params = append(params, *update.Title)
 	}
-	if description != "" {
+	if update.Description != nil {
 		updates = append(updates, "description = ?")
-		params = append(params, description)
+		params = append(params, *update.Description)
 	}
-	if status != "" {
+	if update.Status != nil {
 		updates = append(updates, "status = ?")
-		params = append(params, status)
+		params = append(params, *update.Status)
+
+		if *update.Status == models.TaskStatusCompleted && current.Status != models.TaskStatusCompleted {
+			updates = append(updates, "completed_at = ?")
+			params = append(params, now)
+		} else if *update.Status != models.TaskStatusCompleted {
+			updates = append(updates, "completed_at = NULL")
+		}
+	}
+	if update.Priority != nil {
+		rank, ok := models.PriorityRank(*update.Priority)
+		if !ok {
+			return nil, fmt.Errorf("неизвестный приоритет %q", *update.Priority)
+		}
+		updates = append(updates, "priority = ?")
+		params = append(params, rank)
+	}
+	if update.ClearDueAt {
+		updates = append(updates, "due_at = NULL")
+	} else if update.DueAt != nil {
+		updates = append(updates, "due_at = ?")
+		params = append(params, formatDBTime(*update.DueAt))
 	}
 
-	if len(updates) == 0 {
-		return nil, fmt.Errorf("нет данных для обновления")
+	if update.ClearProject {
+		updates = append(updates, "project_id = NULL")
+	} else if update.ProjectID != nil {
+		updates = append(updates, "project_id = ?")
+		params = append(params, *update.ProjectID)
 	}
 
-	params = append(params, taskID, userID)
+	if update.ClearParent {
+		updates = append(updates, "parent_id = NULL")
+	} else if update.ParentID != nil {
+		updates = append(updates, "parent_id = ?")
+		params = append(params, *update.ParentID)
+	}
+
+	if len(updates) == 0 && update.Tags == nil && update.Recurrence == nil && !update.ClearRecurrence {
+		return nil, ErrNoTaskChanges
+	}
+
+	// Повторяющейся задаче нужен срок: от него отсчитываются следующие повторения
+	dueAt := current.DueAt
+	if update.ClearDueAt {
+		dueAt = nil
+	} else if update.DueAt != nil {
+		dueAt = update.DueAt
+	}
+	recurring := (current.Recurrence != nil && !update.ClearRecurrence) || update.Recurrence != nil
+	if recurring && dueAt == nil {
+		return nil, ErrRecurrenceNeedsDue
+	}
 
-	sql := fmt.Sprintf("UPDATE tasks SET %s WHERE id = ? AND userid = ?", strings.Join(updates, ", "))
-	_, err = s.db.Exec(sql, params...)
+	// Проект задачи после изменения: подзадачи всегда лежат в проекте родителя
+	projectID := current.ProjectID
+	if update.ClearProject {
+		projectID = nil
+	} else if update.ProjectID != nil {
+		projectID = update.ProjectID
+	}
+	projectChanged := (projectID == nil) != (current.ProjectID == nil) ||
+		(projectID != nil && *projectID != *current.ProjectID)
+
+	updates = append(updates, "updated_at = ?")
+	params = append(params, now, taskID)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Завершение задачи может завершить её подзадачи, а перенос в другой проект — перенести их:
+	// их изменения тоже попадают в историю
+	changed := []int{taskID}
+	completing := update.Status != nil && *update.Status == models.TaskStatusCompleted && current.Status != models.TaskStatusCompleted
+	if completing || projectChanged {
+		descendants, err := taskIDs(tx, descendantsSQL+" SELECT id FROM descendants", taskID)
+		if err != nil {
+			return nil, err
+		}
+		changed = append(changed, descendants...)
+	}
+	snapshot, err := snapshotTasks(tx, userID, changed)
 	if err != nil {
+		return nil, err
+	}
+
+	if update.ProjectID != nil && !update.ClearProject {
+		if _, err := requireProjectRole(tx, userID, *update.ProjectID, models.ProjectRoleEditor); err != nil {
+			return nil, err
+		}
+	}
+	if update.ParentID != nil && !update.ClearParent {
+		if err := checkTaskParent(tx, userID, taskID, *update.ParentID, projectID); err != nil {
+			return nil, err
+		}
+	} else if projectChanged && current.ParentID != nil && !update.ClearParent {
+		// Подзадачу нельзя унести в другой проект без родителя
+		return nil, ErrSubtaskProject
+	}
+	if update.Status != nil && *update.Status != current.Status {
+		if err := checkBlockers(tx, taskID, *update.Status); err != nil {
+			return nil, err
+		}
+	}
+	if completing {
+		if err := completeSubtasks(tx, userID, taskID, update.CompleteSubtasks, now); err != nil {
+			return nil, err
+		}
+	}
+
+	sql := fmt.Sprintf("UPDATE tasks SET %s WHERE id = ?", strings.Join(updates, ", "))
+	if _, err := tx.Exec(sql, params...); err != nil {
 		return nil, fmt.Errorf("ошибка обновления: %v", err)
 	}
 
+	// После переноса в другой проект исполнитель мог потерять доступ к задаче
+	if current.AssigneeID != nil && (update.ProjectID != nil || update.ClearProject) {
+		if err := dropInaccessibleAssignee(tx, taskID, *current.AssigneeID); err != nil {
+			return nil, err
+		}
+	}
+	if projectChanged {
+		if err := moveSubtasks(tx, taskID, projectID, now); err != nil {
+			return nil, err
+		}
+	}
+
+	if update.Tags != nil {
+		if err := setTaskTags(tx, userID, taskID, *update.Tags); err != nil {
+			return nil, err
+		}
+	}
+
+	if update.ClearRecurrence {
+		if _, err := tx.Exec("UPDATE tasks SET recurrence_rule = NULL WHERE id = ?", taskID); err != nil {
+			return nil, fmt.Errorf("ошибка отключения повторения: %v", err)
+		}
+	} else if update.Recurrence != nil {
+		if err := setRecurrence(tx, taskID, *update.Recurrence, *dueAt); err != nil {
+			return nil, err
+		}
+	}
+
+	// Завершённое повторение серии порождает следующее
+	if update.Status != nil && *update.Status == models.TaskStatusCompleted && current.Status != models.TaskStatusCompleted {
+		if err := spawnNextOccurrence(tx, userID, taskID, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := snapshot.record(tx); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка сохранения задачи: %v", err)
+	}
+
 	return s.GetTaskByID(taskID, userID)
 }
 
-// DeleteTask удаляет задачу (только если она принадлежит пользователю)
+// DeleteTask удаляет задачу вместе с подзадачами (автор или участник проекта с ролью не ниже editor)
 func (s *TasksService) DeleteTask(taskID, userID int) error {
-	result, err := s.db.Exec(
-		"DELETE FROM tasks WHERE id = ? AND userid = ?",
-		taskID, userID,
-	)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := requireTaskRole(tx, userID, taskID, models.ProjectRoleEditor); err != nil {
+		return err
+	}
+
+	descendants, err := taskIDs(tx, descendantsSQL+" SELECT id FROM descendants", taskID)
+	if err != nil {
+		return err
+	}
+	snapshot, err := snapshotTasks(tx, userID, append([]int{taskID}, descendants...))
 	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM tasks WHERE id = ?", taskID); err != nil {
 		return fmt.Errorf("ошибка удаления: %v", err)
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return fmt.Errorf("задача не найдена")
+	if err := snapshot.record(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка удаления: %v", err)
 	}
 
 	return nil
 }
+
+// taskRole возвращает права пользователя на задачу: у задачи вне проектов автор — owner;
+// у задачи проекта права определяет только проект (владелец — owner, участник — его роль),
+// авторство не учитывается. Если доступа нет — ErrTaskNotFound.
+func taskRole(q queryRower, userID, taskID int) (string, error) {
+	var authorID int
+	var projectID, projectOwnerID sql.NullInt64
+	var memberRole sql.NullString
+
+	err := q.QueryRow(
+		`SELECT t.userid, t.project_id, p.user_id, m.role FROM tasks t
+		 LEFT JOIN projects p ON p.id = t.project_id
+		 LEFT JOIN project_members m ON m.project_id = t.project_id AND m.user_id = ? AND m.status = 'accepted'
+		 WHERE t.id = ?`,
+		userID, taskID,
+	).Scan(&authorID, &projectID, &projectOwnerID, &memberRole)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", ErrTaskNotFound
+		}
+		return "", fmt.Errorf("ошибка проверки доступа к задаче: %v", err)
+	}
+
+	switch {
+	case !projectID.Valid && authorID == userID, projectOwnerID.Valid && int(projectOwnerID.Int64) == userID:
+		return models.ProjectRoleOwner, nil
+	case memberRole.Valid:
+		return memberRole.String, nil
+	default:
+		return "", ErrTaskNotFound
+	}
+}
+
+// requireTaskRole проверяет, что прав пользователя на задачу хватает для действия
+func requireTaskRole(q queryRower, userID, taskID int, minRole string) error {
+	role, err := taskRole(q, userID, taskID)
+	if err != nil {
+		return err
+	}
+	if !roleAtLeast(role, minRole) {
+		return ErrProjectForbidden
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"server_new/models"
+)
+
+func TestTasksService_CompletedAt(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "alice")
+	service := NewTasksService()
+
+	task, err := service.CreateTask(userID, NewTask{Title: "Задача", Status: models.TaskStatusPending, Priority: models.PriorityHigh})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.CompletedAt != nil {
+		t.Error("У новой невыполненной задачи не должно быть completed_at")
+	}
+	if task.Priority != models.PriorityHigh {
+		t.Errorf("Приоритет = %q, ожидался %q", task.Priority, models.PriorityHigh)
+	}
+
+	completed := models.TaskStatusCompleted
+	task, err = service.UpdateTask(task.ID, userID, TaskUpdate{Status: &completed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.CompletedAt == nil {
+		t.Fatal("completed_at должен выставиться при переходе в completed")
+	}
+	completedAt := *task.CompletedAt
+
+	// Повторная установка того же статуса не сдвигает отметку
+	task, err = service.UpdateTask(task.ID, userID, TaskUpdate{Status: &completed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.CompletedAt == nil || !task.CompletedAt.Equal(completedAt) {
+		t.Errorf("completed_at изменился: %v -> %v", completedAt, task.CompletedAt)
+	}
+
+	pending := models.TaskStatusPending
+	task, err = service.UpdateTask(task.ID, userID, TaskUpdate{Status: &pending})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.CompletedAt != nil {
+		t.Error("completed_at должен сброситься при возврате задачи в работу")
+	}
+
+	// Чужая задача недоступна
+	otherID := createTestUser(t, "bob")
+	if _, err := service.UpdateTask(task.ID, otherID, TaskUpdate{Status: &completed}); err != ErrTaskNotFound {
+		t.Errorf("Ожидалась ErrTaskNotFound, получено %v", err)
+	}
+}
+
+func TestTasksService_DueFiltersAndSort(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "alice")
+	service := NewTasksService()
+
+	now := time.Now()
+	at := func(d time.Duration) *time.Time {
+		v := now.Add(d)
+		return &v
+	}
+
+	create := func(title, status string, dueAt *time.Time) {
+		t.Helper()
+		_, err := service.CreateTask(userID, NewTask{Title: title, Status: status, Priority: models.PriorityNormal, DueAt: dueAt})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	create("просрочена", models.TaskStatusPending, at(-48*time.Hour))
+	create("просрочена, но выполнена", models.TaskStatusCompleted, at(-48*time.Hour))
+	create("через месяц", models.TaskStatusPending, at(30*24*time.Hour))
+	create("без срока", models.TaskStatusPending, nil)
+
+	tasks, total, err := service.GetTasksByUserID(userID, 1, 10, TaskFilter{Due: DueOverdue})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(tasks) != 1 || tasks[0].Title != "просрочена" {
+		t.Errorf("overdue: получено %d задач (total %d): %+v", len(tasks), total, tasks)
+	}
+
+	tasks, _, err = service.GetTasksByUserID(userID, 1, 10, TaskFilter{Sort: "-due_at"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 4 {
+		t.Fatalf("Ожидалось 4 задачи, получено %d", len(tasks))
+	}
+	if tasks[0].Title != "через месяц" || tasks[3].Title != "без срока" {
+		t.Errorf("Задачи без срока должны идти последними и при сортировке по убыванию: %q ... %q", tasks[0].Title, tasks[3].Title)
+	}
+
+	if _, _, err := service.GetTasksByUserID(userID, 1, 10, TaskFilter{Sort: "title; DROP TABLE tasks"}); err == nil {
+		t.Error("Сортировка по неизвестному полю должна возвращать ошибку")
+	}
+}
+
+func TestTasksService_MultiFieldSort(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "alice")
+	service := NewTasksService()
+
+	for _, input := range []NewTask{
+		{Title: "beta", Status: models.TaskStatusPending, Priority: models.PriorityLow},
+		{Title: "Alpha", Status: models.TaskStatusPending, Priority: models.PriorityHigh},
+		{Title: "alpha", Status: models.TaskStatusPending, Priority: models.PriorityLow},
+		{Title: "beta", Status: models.TaskStatusPending, Priority: models.PriorityUrgent},
+		{Title: "alpha", Status: models.TaskStatusCompleted, Priority: models.PriorityUrgent},
+	} {
+		if _, err := service.CreateTask(userID, input); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	describe := func(tasks []models.Task) []string {
+		result := make([]string, len(tasks))
+		for i, task := range tasks {
+			result[i] = task.Title + "/" + task.Priority
+		}
+		return result
+	}
+
+	tests := []struct {
+		sort string
+		want []string
+	}{
+		// Название без учёта регистра, при равенстве — важные первыми, затем по id
+		{"title,-priority", []string{"Alpha/high", "alpha/low", "beta/urgent", "beta/low"}},
+		{"-priority,title", []string{"beta/urgent", "Alpha/high", "alpha/low", "beta/low"}},
+		// Равные ключи разрешаются по id в направлении последнего поля
+		{"title,-created_at", []string{"alpha/low", "Alpha/high", "beta/urgent", "beta/low"}},
+		{"title,created_at", []string{"Alpha/high", "alpha/low", "beta/low", "beta/urgent"}},
+	}
+	for _, tt := range tests {
+		tasks, total, err := service.GetTasksByUserID(userID, 1, 10, TaskFilter{Status: models.TaskStatusPending, Sort: tt.sort})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := describe(tasks); total != 4 || strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("sort=%s: %v (total %d), ожидалось %v", tt.sort, got, total, tt.want)
+		}
+	}
+
+	for _, sort := range []string{"title,colour", "title,-title", "title,", "status"} {
+		if _, _, err := service.GetTasksByUserID(userID, 1, 10, TaskFilter{Sort: sort}); err == nil {
+			t.Errorf("sort=%q: ожидалась ошибка", sort)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"server_new/config"
+	"server_new/utils"
+)
+
+// Издатель, который увидит пользователь в приложении-аутентификаторе
+const totpIssuer = "TaskServer"
+
+// Количество кодов восстановления, выдаваемых при включении 2FA
+const recoveryCodesCount = 10
+
+var (
+	// ErrTwoFactorAlreadyEnabled — 2FA уже включена
+	ErrTwoFactorAlreadyEnabled = errors.New("двухфакторная аутентификация уже включена")
+	// ErrTwoFactorNotEnabled — 2FA не включена
+	ErrTwoFactorNotEnabled = errors.New("двухфакторная аутентификация не включена")
+	// ErrTwoFactorSetupNotStarted — подтверждение без предварительного /me/2fa/setup
+	ErrTwoFactorSetupNotStarted = errors.New("сначала начни настройку двухфакторной аутентификации")
+	// ErrInvalidTwoFactorCode — код неверный или уже использован
+	ErrInvalidTwoFactorCode = errors.New("неверный код подтверждения")
+	// ErrInvalidPassword — текущий пароль неверен
+	ErrInvalidPassword = errors.New("текущий пароль неверен")
+)
+
+// TwoFactorStatus — состояние 2FA пользователя
+type TwoFactorStatus struct {
+	Enabled           bool `json:"enabled"`
+	RecoveryCodesLeft int  `json:"recoveryCodesLeft"`
+}
+
+// TwoFactorSetup — данные для добавления аккаунта в приложение-аутентификатор
+type TwoFactorSetup struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauthUri"`
+}
+
+// TwoFactorService управляет TOTP двухфакторной аутентификацией
+type TwoFactorService struct {
+	db *sql.DB
+}
+
+// NewTwoFactorService создаёт новый экземпляр сервиса
+func NewTwoFactorService() *TwoFactorService {
+	return &TwoFactorService{db: config.DB}
+}
+
+// IsEnabled проверяет, включена ли 2FA у пользователя
+func (s *TwoFactorService) IsEnabled(userID int) (bool, error) {
+	var enabled bool
+	err := s.db.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM user_totp WHERE user_id = ? AND enabled_at IS NOT NULL)",
+		userID,
+	).Scan(&enabled)
+	if err != nil {
+		return false, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	return enabled, nil
+}
+
+// Status возвращает состояние 2FA и количество оставшихся кодов восстановления
+func (s *TwoFactorService) Status(userID int) (*TwoFactorStatus, error) {
+	enabled, err := s.IsEnabled(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &TwoFactorStatus{Enabled: enabled}
+	if enabled {
+		err = s.db.QueryRow(
+			"SELECT COUNT(*) FROM recovery_codes WHERE user_id = ? AND used_at IS NULL",
+			userID,
+		).Scan(&status.RecoveryCodesLeft)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+		}
+	}
+	return status, nil
+}
+
+// BeginSetup создаёт новый секрет. 2FA включится только после подтверждения кодом.
+func (s *TwoFactorService) BeginSetup(userID int) (*TwoFactorSetup, error) {
+	enabled, err := s.IsEnabled(userID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	var email string
+	if err := s.db.QueryRow("SELECT email FROM users WHERE id = ?", userID).Scan(&email); err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка генерации секрета: %v", err)
+	}
+
+	_, err = s.db.Exec(
+		`INSERT INTO user_totp (user_id, secret) VALUES (?, ?)
+		 ON CONFLICT(user_id) DO UPDATE SET secret = excluded.secret, enabled_at = NULL, last_used_step = 0`,
+		userID, secret,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка сохранения секрета: %v", err)
+	}
+
+	return &TwoFactorSetup{
+		Secret:     secret,
+		OTPAuthURI: utils.TOTPURI(totpIssuer, email, secret),
+	}, nil
+}
+
+// Confirm включает 2FA после проверки первого кода и возвращает коды восстановления.
+// Коды показываются только один раз, в базе хранятся их хэши.
+func (s *TwoFactorService) Confirm(userID int, code string) ([]string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	var secret string
+	var enabledAt sql.NullTime
+	err = tx.QueryRow("SELECT secret, enabled_at FROM user_totp WHERE user_id = ?", userID).Scan(&secret, &enabledAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrTwoFactorSetupNotStarted
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	if enabledAt.Valid {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	step, ok := utils.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	_, err = tx.Exec(
+		"UPDATE user_totp SET enabled_at = ?, last_used_step = ? WHERE user_id = ?",
+		formatDBTime(time.Now()), step, userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка включения 2FA: %v", err)
+	}
+
+	codes, err := replaceRecoveryCodes(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("ошибка сохранения: %v", err)
+	}
+	return codes, nil
+}
+
+// Disable выключает 2FA. Требует текущий пароль пользователя.
+func (s *TwoFactorService) Disable(userID int, password string) error {
+	var hash string
+	if err := s.db.QueryRow("SELECT password FROM users WHERE id = ?", userID).Scan(&hash); err != nil {
+		return fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+	if !utils.CheckPassword(password, hash) {
+		return ErrInvalidPassword
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %v", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM user_totp WHERE user_id = ? AND enabled_at IS NOT NULL", userID)
+	if err != nil {
+		return fmt.Errorf("ошибка выключения 2FA: %v", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrTwoFactorNotEnabled
+	}
+
+	if _, err := tx.Exec("DELETE FROM recovery_codes WHERE user_id = ?", userID); err != nil {
+		return fmt.Errorf("ошибка удаления кодов восстановления: %v", err)
+	}
+
+	return tx.Commit()
+}
+
+// Verify проверяет код из приложения или одноразовый код восстановления при входе.
+// Один и тот же TOTP код нельзя использовать дважды.
+func (s *TwoFactorService) Verify(userID int, code string) error {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return ErrInvalidTwoFactorCode
+	}
+
+	var secret string
+	var lastStep int64
+	err := s.db.QueryRow(
+		"SELECT secret, last_used_step FROM user_totp WHERE user_id = ? AND enabled_at IS NOT NULL",
+		userID,
+	).Scan(&secret, &lastStep)
+	if err == sql.ErrNoRows {
+		return ErrTwoFactorNotEnabled
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка запроса к БД: %v", err)
+	}
+
+	if step, ok := utils.ValidateTOTP(secret, code, time.Now()); ok {
+		// Сдвигаем last_used_step только вперёд: повтор того же кода не пройдёт
+		result, err := s.db.Exec(
+			"UPDATE user_totp SET last_used_step = ? WHERE user_id = ? AND last_used_step < ?",
+			step, userID, step,
+		)
+		if err != nil {
+			return fmt.Errorf("ошибка обновления 2FA: %v", err)
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	// Не TOTP — пробуем как код восстановления
+	result, err := s.db.Exec(
+		"UPDATE recovery_codes SET used_at = ? WHERE user_id = ? AND code_hash = ? AND used_at IS NULL",
+		formatDBTime(time.Now()), userID, utils.HashToken(normalizeRecoveryCode(code)),
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка проверки кода восстановления: %v", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+
+	utils.LogInfo("Вход по коду восстановления", "userID", userID)
+	return nil
+}
+
+// replaceRecoveryCodes удаляет старые коды восстановления и создаёт новые
+func replaceRecoveryCodes(tx *sql.Tx, userID int) ([]string, error) {
+	if _, err := tx.Exec("DELETE FROM recovery_codes WHERE user_id = ?", userID); err != nil {
+		return nil, fmt.Errorf("ошибка удаления кодов восстановления: %v", err)
+	}
+
+	codes := make([]string, 0, recoveryCodesCount)
+	for i := 0; i < recoveryCodesCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, fmt.Errorf("ошибка генерации кода восстановления: %v", err)
+		}
+		_, err = tx.Exec(
+			"INSERT INTO recovery_codes (user_id, code_hash) VALUES (?, ?)",
+			userID, utils.HashToken(normalizeRecoveryCode(code)),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сохранения кода восстановления: %v", err)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// recoveryAlphabet — символы кода восстановления (без похожих 0/o, 1/l)
+const recoveryAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// generateRecoveryCode создаёт код вида "abcde-fghjk"
+func generateRecoveryCode() (string, error) {
+	// Отбрасываем байты из «хвоста», чтобы все символы были равновероятны
+	limit := byte(256 - 256%len(recoveryAlphabet))
+
+	code := make([]byte, 0, 10)
+	buf := make([]byte, 16)
+	for len(code) < 10 {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, v := range buf {
+			if v < limit && len(code) < 10 {
+				code = append(code, recoveryAlphabet[int(v)%len(recoveryAlphabet)])
+			}
+		}
+	}
+	return string(code[:5]) + "-" + string(code[5:]), nil
+}
+
+// normalizeRecoveryCode убирает дефисы и пробелы и приводит код к нижнему регистру
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"server_new/utils"
+)
+
+func TestTwoFactorService_Flow(t *testing.T) {
+	setupServiceDB(t)
+	userID := createTestUser(t, "dave")
+	service := NewTwoFactorService()
+
+	setup, err := service.BeginSetup(userID)
+	if err != nil {
+		t.Fatal("BeginSetup():", err)
+	}
+
+	if _, err := service.Confirm(userID, "000000"); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Errorf("Confirm(неверный код) error = %v, want %v", err, ErrInvalidTwoFactorCode)
+	}
+
+	code, _ := utils.TOTPCode(setup.Secret, time.Now())
+	recoveryCodes, err := service.Confirm(userID, code)
+	if err != nil {
+		t.Fatal("Confirm():", err)
+	}
+	if len(recoveryCodes) != recoveryCodesCount {
+		t.Errorf("Confirm() вернул %d кодов восстановления, want %d", len(recoveryCodes), recoveryCodesCount)
+	}
+
+	// Код, которым подтверждали включение, повторно не принимается
+	if err := service.Verify(userID, code); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Errorf("Verify(повтор кода) error = %v, want %v", err, ErrInvalidTwoFactorCode)
+	}
+
+	// Код восстановления одноразовый
+	if err := service.Verify(userID, recoveryCodes[0]); err != nil {
+		t.Errorf("Verify(код восстановления) error = %v", err)
+	}
+	if err := service.Verify(userID, recoveryCodes[0]); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Errorf("Verify(повтор кода восстановления) error = %v, want %v", err, ErrInvalidTwoFactorCode)
+	}
+
+	status, err := service.Status(userID)
+	if err != nil || !status.Enabled || status.RecoveryCodesLeft != recoveryCodesCount-1 {
+		t.Errorf("Status() = %+v, %v", status, err)
+	}
+}
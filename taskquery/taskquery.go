@@ -0,0 +1,300 @@
+// Package taskquery разбирает язык фильтров списка задач, например
+//
+//	status:pending priority>=high created>2026-01-01 -status:completed "годовой отчёт"
+//
+// Запрос — условия через пробел, все должны выполняться. Условие — это либо поле с оператором
+// и значением (status:pending, due<=2026-05-01, title:"два слова"), либо текст: слово или фраза
+// в двойных кавычках. Минус перед условием отрицает его. Пакет проверяет только синтаксис:
+// какие поля и значения допустимы, решает тот, кто переводит запрос в SQL.
+package taskquery
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Ограничения на размер запроса
+const (
+	MaxQueryLength = 500 // символов
+	MaxTerms       = 20
+)
+
+// Op — оператор сравнения поля со значением
+type Op string
+
+// Поддерживаемые операторы
+const (
+	OpEq  Op = ":"
+	OpGt  Op = ">"
+	OpGte Op = ">="
+	OpLt  Op = "<"
+	OpLte Op = "<="
+)
+
+// Query — разобранный запрос: условия, которые должны выполняться одновременно
+type Query struct {
+	Terms []Term
+}
+
+// Term — одно условие запроса
+type Term struct {
+	Negated bool   // условие с минусом: задача не должна ему соответствовать
+	Field   string // имя поля в нижнем регистре; "" — поиск текста в задаче
+	Op      Op     // для текста — OpEq
+	Value   string // значение без кавычек
+	Pos     int    // позиция начала условия в запросе (в символах, с 1)
+	Raw     string // условие как оно записано в запросе
+}
+
+// Error — ошибка в запросе с указанием на условие, в котором она найдена
+type Error struct {
+	Pos   int    // позиция в запросе (в символах, с 1)
+	Token string // фрагмент запроса, к которому относится ошибка
+	Msg   string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("ошибка в фильтре в позиции %d («%s»): %s", e.Pos, e.Token, e.Msg)
+}
+
+// Errorf возвращает ошибку, указывающую на условие t
+func (t Term) Errorf(format string, args ...interface{}) *Error {
+	return &Error{Pos: t.Pos, Token: t.Raw, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Parse разбирает запрос. Пустой запрос — Query без условий.
+func Parse(input string) (*Query, error) {
+	runes := []rune(input)
+	if len(runes) > MaxQueryLength {
+		return nil, &Error{Pos: MaxQueryLength + 1, Token: string(runes[MaxQueryLength:min(len(runes), MaxQueryLength+10)]),
+			Msg: fmt.Sprintf("запрос длиннее %d символов", MaxQueryLength)}
+	}
+
+	p := &parser{runes: runes}
+	query := &Query{Terms: []Term{}}
+	for {
+		p.skipSpaces()
+		if p.done() {
+			return query, nil
+		}
+		term, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		if len(query.Terms) == MaxTerms {
+			return nil, term.Errorf("условий больше %d", MaxTerms)
+		}
+		query.Terms = append(query.Terms, term)
+	}
+}
+
+// String возвращает запрос в каноническом виде: Parse(q.String()) даёт те же условия
+func (q *Query) String() string {
+	parts := make([]string, len(q.Terms))
+	for i, term := range q.Terms {
+		parts[i] = term.String()
+	}
+	return strings.Join(parts, " ")
+}
+
+// String возвращает условие в каноническом виде
+func (t Term) String() string {
+	var sb strings.Builder
+	if t.Negated {
+		sb.WriteByte('-')
+	}
+	if t.Field == "" {
+		sb.WriteString(quote(t.Value))
+		return sb.String()
+	}
+	sb.WriteString(t.Field)
+	sb.WriteString(string(t.Op))
+	if needsQuotes(t.Value) {
+		sb.WriteString(quote(t.Value))
+	} else {
+		sb.WriteString(t.Value)
+	}
+	return sb.String()
+}
+
+// parser — состояние разбора: руны запроса и текущая позиция
+type parser struct {
+	runes []rune
+	pos   int
+}
+
+func (p *parser) done() bool { return p.pos >= len(p.runes) }
+
+func (p *parser) peek(offset int) rune {
+	if p.pos+offset >= len(p.runes) {
+		return 0
+	}
+	return p.runes[p.pos+offset]
+}
+
+func (p *parser) skipSpaces() {
+	for !p.done() && unicode.IsSpace(p.runes[p.pos]) {
+		p.pos++
+	}
+}
+
+// rawFrom — текст запроса от start до текущей позиции
+func (p *parser) rawFrom(start int) string {
+	return string(p.runes[start:p.pos])
+}
+
+// errorAt — ошибка во фрагменте от start до конца текущего слова
+func (p *parser) errorAt(start int, msg string) *Error {
+	end := p.pos
+	for end < len(p.runes) && !unicode.IsSpace(p.runes[end]) {
+		end++
+	}
+	if end == start && end < len(p.runes) {
+		end++
+	}
+	return &Error{Pos: start + 1, Token: string(p.runes[start:end]), Msg: msg}
+}
+
+// term разбирает одно условие, начиная с непробельного символа
+func (p *parser) term() (Term, error) {
+	start := p.pos
+	term := Term{Op: OpEq, Pos: start + 1}
+
+	if p.peek(0) == '-' {
+		term.Negated = true
+		p.pos++
+		if p.done() || unicode.IsSpace(p.peek(0)) {
+			return term, p.errorAt(start, "после «-» должно идти условие")
+		}
+	}
+
+	// Фраза в кавычках
+	if p.peek(0) == '"' {
+		value, err := p.quoted()
+		if err != nil {
+			return term, err
+		}
+		if err := p.expectEnd(start); err != nil {
+			return term, err
+		}
+		term.Value = value
+		term.Raw = p.rawFrom(start)
+		if strings.TrimSpace(value) == "" {
+			return term, p.errorAt(start, "пустая фраза")
+		}
+		return term, nil
+	}
+
+	// Поле с оператором или просто слово
+	nameStart := p.pos
+	for !p.done() && isFieldChar(p.peek(0)) {
+		p.pos++
+	}
+	if p.pos > nameStart {
+		if op, width := p.operator(); width > 0 {
+			term.Field = strings.ToLower(string(p.runes[nameStart:p.pos]))
+			term.Op = op
+			p.pos += width
+
+			switch {
+			case p.done() || unicode.IsSpace(p.peek(0)):
+				term.Raw = p.rawFrom(start)
+				return term, p.errorAt(start, "не указано значение")
+			case p.peek(0) == '"':
+				value, err := p.quoted()
+				if err != nil {
+					return term, err
+				}
+				if err := p.expectEnd(start); err != nil {
+					return term, err
+				}
+				term.Value = value
+			default:
+				term.Value = p.word()
+			}
+			term.Raw = p.rawFrom(start)
+			return term, nil
+		}
+	}
+
+	p.pos = nameStart
+	term.Value = p.word()
+	term.Raw = p.rawFrom(start)
+	return term, nil
+}
+
+// operator распознаёт оператор в текущей позиции и возвращает его длину (0 — оператора нет)
+func (p *parser) operator() (Op, int) {
+	switch p.peek(0) {
+	case ':':
+		return OpEq, 1
+	case '>':
+		if p.peek(1) == '=' {
+			return OpGte, 2
+		}
+		return OpGt, 1
+	case '<':
+		if p.peek(1) == '=' {
+			return OpLte, 2
+		}
+		return OpLt, 1
+	}
+	return "", 0
+}
+
+// word читает символы до пробела
+func (p *parser) word() string {
+	start := p.pos
+	for !p.done() && !unicode.IsSpace(p.peek(0)) {
+		p.pos++
+	}
+	return string(p.runes[start:p.pos])
+}
+
+// quoted читает строку в двойных кавычках; внутри допускаются \" и \\
+func (p *parser) quoted() (string, error) {
+	start := p.pos
+	p.pos++ // открывающая кавычка
+
+	var sb strings.Builder
+	for !p.done() {
+		r := p.peek(0)
+		switch {
+		case r == '\\' && (p.peek(1) == '"' || p.peek(1) == '\\'):
+			sb.WriteRune(p.peek(1))
+			p.pos += 2
+		case r == '"':
+			p.pos++
+			return sb.String(), nil
+		default:
+			sb.WriteRune(r)
+			p.pos++
+		}
+	}
+	return "", &Error{Pos: start + 1, Token: string(p.runes[start:]), Msg: "не закрыта кавычка"}
+}
+
+// expectEnd проверяет, что после закрывающей кавычки условие заканчивается
+func (p *parser) expectEnd(start int) error {
+	if p.done() || unicode.IsSpace(p.peek(0)) {
+		return nil
+	}
+	return p.errorAt(start, "после закрывающей кавычки нужен пробел")
+}
+
+// isFieldChar — символ, допустимый в имени поля
+func isFieldChar(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// needsQuotes сообщает, нужно ли брать значение поля в кавычки, чтобы оно разобралось обратно
+func needsQuotes(value string) bool {
+	return value == "" || strings.HasPrefix(value, `"`) || strings.IndexFunc(value, unicode.IsSpace) >= 0
+}
+
+// quote заключает значение в двойные кавычки, экранируя кавычки и обратную косую черту
+func quote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+}
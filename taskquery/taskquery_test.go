@@ -0,0 +1,145 @@
+package taskquery
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// terms убирает из условий позиции и исходный текст, чтобы сравнивать только смысл
+func terms(q *Query) []Term {
+	result := make([]Term, len(q.Terms))
+	for i, term := range q.Terms {
+		term.Pos, term.Raw = 0, ""
+		result[i] = term
+	}
+	return result
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []Term
+	}{
+		{"пустой", "  ", []Term{}},
+		{"поле", "status:pending", []Term{{Field: "status", Op: OpEq, Value: "pending"}}},
+		{"сравнения", "priority>=high due<2026-05-01 created>2026-01-01 updated<=2026-02-01", []Term{
+			{Field: "priority", Op: OpGte, Value: "high"},
+			{Field: "due", Op: OpLt, Value: "2026-05-01"},
+			{Field: "created", Op: OpGt, Value: "2026-01-01"},
+			{Field: "updated", Op: OpLte, Value: "2026-02-01"},
+		}},
+		{"отрицание", "-status:completed", []Term{{Negated: true, Field: "status", Op: OpEq, Value: "completed"}}},
+		{"регистр поля", "Status:Pending", []Term{{Field: "status", Op: OpEq, Value: "Pending"}}},
+		{"слово и фраза", `отчёт "годовой план"`, []Term{
+			{Op: OpEq, Value: "отчёт"},
+			{Op: OpEq, Value: "годовой план"},
+		}},
+		{"значение в кавычках", `title:"два слова" -"без \"этого\""`, []Term{
+			{Field: "title", Op: OpEq, Value: "два слова"},
+			{Negated: true, Op: OpEq, Value: `без "этого"`},
+		}},
+		{"слово со знаками", "c++ -x", []Term{{Op: OpEq, Value: "c++"}, {Negated: true, Op: OpEq, Value: "x"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.input, err)
+			}
+			if got := terms(q); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Positions(t *testing.T) {
+	q, err := Parse(`отчёт  -status:done "a b"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		pos int
+		raw string
+	}{{1, "отчёт"}, {8, "-status:done"}, {21, `"a b"`}}
+	for i, w := range want {
+		if q.Terms[i].Pos != w.pos || q.Terms[i].Raw != w.raw {
+			t.Errorf("Условие %d: позиция %d «%s», ожидалось %d «%s»", i, q.Terms[i].Pos, q.Terms[i].Raw, w.pos, w.raw)
+		}
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		pos   int
+		token string
+	}{
+		{"минус без условия", "status:pending - x", 16, "-"},
+		{"нет значения", "a priority>= b", 3, "priority>="},
+		{"незакрытая кавычка", `a title:"abc`, 9, `"abc`},
+		{"нет пробела после кавычки", `"abc"def`, 1, `"abc"def`},
+		{"пустая фраза", `x ""`, 3, `""`},
+		{"слишком много условий", strings.Repeat("a ", MaxTerms) + "b", 2*MaxTerms + 1, "b"},
+		{"слишком длинный", strings.Repeat("а", MaxQueryLength+1), MaxQueryLength + 1, "а"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			var qerr *Error
+			if !errors.As(err, &qerr) {
+				t.Fatalf("Parse(%q): ожидалась *Error, получено %v", tt.input, err)
+			}
+			if qerr.Pos != tt.pos || qerr.Token != tt.token {
+				t.Errorf("Parse(%q): позиция %d «%s», ожидалось %d «%s» (%v)", tt.input, qerr.Pos, qerr.Token, tt.pos, tt.token, err)
+			}
+		})
+	}
+}
+
+// FuzzParse проверяет, что разбор не паникует, ошибки указывают внутрь запроса,
+// а канонический вид запроса разбирается в те же условия
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		`status:pending priority>=high created>2026-01-01 -status:completed "exact phrase"`,
+		`title:"a \"b\" c\\" -"x" c++ --y`,
+		`due<= "unclosed`,
+		`a:"" b:"c"d - `,
+		"\"\xff\" é:ü",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		q, err := Parse(input)
+		if err != nil {
+			var qerr *Error
+			if !errors.As(err, &qerr) {
+				t.Fatalf("Parse(%q): ошибка не *Error: %v", input, err)
+			}
+			if n := utf8.RuneCountInString(input); qerr.Pos < 1 || qerr.Pos > n+1 {
+				t.Fatalf("Parse(%q): позиция %d вне запроса длиной %d", input, qerr.Pos, n)
+			}
+			return
+		}
+
+		canonical := q.String()
+		if utf8.RuneCountInString(canonical) > MaxQueryLength {
+			return // кавычки и экранирование могли удлинить запрос сверх лимита
+		}
+		again, err := Parse(canonical)
+		if err != nil {
+			t.Fatalf("Parse(%q) канонического вида %q: %v", input, canonical, err)
+		}
+		if !reflect.DeepEqual(terms(q), terms(again)) {
+			t.Fatalf("Канонический вид %q разобран иначе:\n%+v\n%+v", canonical, terms(q), terms(again))
+		}
+	})
+}
@@ -2,6 +2,8 @@
 package utils
 
 import (
+	"database/sql"
+	"errors"
 	"time"
 
 	"server_new/config"
@@ -10,22 +12,39 @@ import (
 	"golang.org/x/crypto/bcrypt"   // для хеширования паролей
 )
 
-// GenerateToken создаёт JWT токен для пользователя
-func GenerateToken(userID int) (string, error) {
+// defaultAccessTokenTTL используется, если конфигурация ещё не загружена
+const defaultAccessTokenTTL = 15 * time.Minute
+
+// ErrSessionRevoked — сессия, к которой привязан токен, завершена
+var ErrSessionRevoked = errors.New("сессия завершена")
+
+// purposeTwoFactor — назначение промежуточного токена, выдаваемого после пароля при включённой 2FA
+const purposeTwoFactor = "2fa"
+
+// twoFactorTokenTTL — сколько времени есть на ввод кода 2FA после пароля
+const twoFactorTokenTTL = 5 * time.Minute
+
+// GenerateToken создаёт короткоживущий JWT (access) токен для пользователя.
+// sessionID записывается в jti, чтобы токен можно было отозвать вместе с сессией.
+func GenerateToken(userID int, sessionID, role string) (string, error) {
+	ttl := config.AccessTokenTTL
+	if ttl <= 0 {
+		ttl = defaultAccessTokenTTL
+	}
+
 	// Создаём claims (данные в токене)
 	claims := Claims{
 		UserID: userID,
+		Role:   role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(7 * 24 * time.Hour)), // токен действует 7 дней
+			ID:        sessionID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	// Создаём токен с алгоритмом HS256
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	// Подписываем токен секретным ключом
-	tokenString, err := token.SignedString([]byte(JWT_SECRET))
+	// Подписываем токен текущим ключом (HS256, EdDSA или RS256 — зависит от конфигурации)
+	tokenString, err := currentKeyManager().Sign(claims)
 	if err != nil {
 		return "", err
 	}
@@ -35,42 +54,94 @@ func GenerateToken(userID int) (string, error) {
 
 // ValidateToken проверяет токен и возвращает UserID
 func ValidateToken(tokenString string) (int, error) {
+	claims, err := ParseToken(tokenString)
+	if err != nil {
+		return 0, err
+	}
+	return claims.UserID, nil
+}
+
+// ParseToken проверяет подпись и срок действия токена, а также то,
+// что его сессия не завершена. Возвращает все claims токена.
+func ParseToken(tokenString string) (*Claims, error) {
 	// Парсим токен
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		// Проверяем алгоритм подписи
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.ErrSignatureInvalid
-		}
-		return []byte(JWT_SECRET), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, currentKeyManager().keyFunc)
 
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	// Извлекаем claims
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims.UserID, nil
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, jwt.ErrSignatureInvalid
+	}
+
+	// Промежуточные токены (например, «ожидает 2FA») не дают доступа к API
+	if claims.Purpose != "" {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+
+	// Токен действует, только пока жива его сессия
+	if err := checkSession(claims.ID); err != nil {
+		return nil, err
 	}
 
-	return 0, jwt.ErrSignatureInvalid
+	return claims, nil
 }
 
-// getJWTSecret получает секрет из конфигурации или использует значение по умолчанию
-func getJWTSecret() string {
-	if config.JWTSecret != "" {
-		return config.JWTSecret
+// GenerateTwoFactorToken создаёт короткоживущий токен «ожидает 2FA».
+// Он подтверждает, что пароль введён верно, но сам по себе доступа не даёт.
+func GenerateTwoFactorToken(userID int) (string, error) {
+	claims := Claims{
+		UserID:  userID,
+		Purpose: purposeTwoFactor,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(twoFactorTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	return currentKeyManager().Sign(claims)
+}
+
+// ParseTwoFactorToken проверяет токен «ожидает 2FA» и возвращает UserID
+func ParseTwoFactorToken(tokenString string) (int, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, currentKeyManager().keyFunc)
+	if err != nil {
+		return 0, err
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid || claims.Purpose != purposeTwoFactor {
+		return 0, jwt.ErrTokenInvalidClaims
 	}
-	return "dev-secret-change-me" // только для разработки!
+	return claims.UserID, nil
 }
 
-// JWT_SECRET — секретный ключ для подписи токенов
-var JWT_SECRET = getJWTSecret()
+// checkSession проверяет, что сессия существует и не отозвана
+func checkSession(sessionID string) error {
+	if sessionID == "" || config.DB == nil {
+		return ErrSessionRevoked
+	}
+
+	var revoked bool
+	err := config.DB.QueryRow(
+		"SELECT revoked_at IS NOT NULL FROM sessions WHERE id = ?",
+		sessionID,
+	).Scan(&revoked)
+	if err == sql.ErrNoRows || revoked {
+		return ErrSessionRevoked
+	}
+	return err
+}
 
 // Claims — структура для данных в JWT токене
 type Claims struct {
-	UserID               int `json:"userId"`
-	jwt.RegisteredClaims     // встроенная структура для стандартных полей
+	UserID               int    `json:"userId"`
+	Role                 string `json:"role,omitempty"`
+	Purpose              string `json:"purpose,omitempty"` // пусто для обычного access токена
+	jwt.RegisteredClaims        // встроенная структура для стандартных полей
 }
 
 // HashPassword создаёт хэш пароля
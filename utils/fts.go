@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// maxSearchQueryLength — максимальная длина поискового запроса в символах
+const maxSearchQueryLength = 200
+
+// BuildFTSQuery превращает пользовательский запрос в безопасное выражение FTS5 MATCH.
+// Слова ищутся по префиксу ("отч" найдёт «отчёт»), текст в двойных кавычках — точной фразой,
+// все части должны встретиться в задаче. Спецсимволы FTS5 в запросе не действуют.
+func BuildFTSQuery(query string) (string, error) {
+	query = strings.TrimSpace(query)
+	if len([]rune(query)) > maxSearchQueryLength {
+		return "", fmt.Errorf("запрос длиннее %d символов", maxSearchQueryLength)
+	}
+
+	var parts []string
+	for query != "" {
+		if strings.HasPrefix(query, `"`) {
+			// Фраза до закрывающей кавычки (незакрытая — до конца запроса)
+			phrase, rest, _ := strings.Cut(query[1:], `"`)
+			if hasWordChars(phrase) {
+				parts = append(parts, quoteFTS(phrase))
+			}
+			query = strings.TrimSpace(rest)
+			continue
+		}
+
+		end := strings.IndexFunc(query, func(r rune) bool { return unicode.IsSpace(r) || r == '"' })
+		if end < 0 {
+			end = len(query)
+		}
+		word := strings.TrimSuffix(query[:end], "*")
+		if hasWordChars(word) {
+			parts = append(parts, quoteFTS(word)+"*")
+		}
+		query = strings.TrimSpace(query[end:])
+	}
+
+	if len(parts) == 0 {
+		return "", fmt.Errorf("пустой поисковый запрос")
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// quoteFTS заключает текст в кавычки FTS5, удваивая кавычки внутри
+func quoteFTS(text string) string {
+	return `"` + strings.ReplaceAll(text, `"`, `""`) + `"`
+}
+
+// hasWordChars сообщает, есть ли в тексте буквы или цифры (иначе токенизатор ничего не найдёт)
+func hasWordChars(text string) bool {
+	return strings.IndexFunc(text, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsNumber(r) }) >= 0
+}
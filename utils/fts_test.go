@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildFTSQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    string
+		wantErr bool
+	}{
+		{"слово по префиксу", "отч", `"отч"*`, false},
+		{"несколько слов", "квартальный report", `"квартальный"* "report"*`, false},
+		{"фраза", `"годовой отчёт" срочно`, `"годовой отчёт" "срочно"*`, false},
+		{"незакрытая фраза", `план "на неделю`, `"план"* "на неделю"`, false},
+		{"звёздочка в конце", "doc*", `"doc"*`, false},
+		{"операторы FTS5 как текст", "NEAR(a b) OR -x", `"NEAR(a"* "b)"* "OR"* "-x"*`, false},
+		{"только знаки", `*** "" -`, "", true},
+		{"пустой", "   ", "", true},
+		{"слишком длинный", strings.Repeat("а", 201), "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BuildFTSQuery(tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BuildFTSQuery(%q) error = %v, wantErr %v", tt.query, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("BuildFTSQuery(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
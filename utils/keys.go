@@ -0,0 +1,295 @@
+// ключи для подписи и проверки JWT: HMAC секрет или асимметричные ключи (EdDSA, RS256) с ротацией
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+
+	"server_new/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// hmacKeyID — kid токенов, подписанных общим секретом JWT_SECRET
+const hmacKeyID = "hs256"
+
+// minRSAKeyBits — минимальный допустимый размер RSA ключа
+const minRSAKeyBits = 2048
+
+// JWK — открытый ключ в формате JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"` // Ed25519
+	X   string `json:"x,omitempty"`   // Ed25519
+	N   string `json:"n,omitempty"`   // RSA
+	E   string `json:"e,omitempty"`   // RSA
+}
+
+// jwtKey — один ключ: алгоритм, закрытая часть для подписи (если есть) и открытая для проверки
+type jwtKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private interface{} // nil у ключей, которые остались только для проверки
+	public  interface{} // для HMAC — сам секрет
+}
+
+// KeyManager хранит ключ, которым подписываются новые токены, и все ключи,
+// которыми ещё можно проверять ранее выданные токены (по kid из заголовка)
+type KeyManager struct {
+	signing      *jwtKey
+	verification map[string]*jwtKey
+}
+
+var (
+	keyManagerMu sync.RWMutex
+	keyManager   *KeyManager
+)
+
+// NewHMACKeyManager создаёт менеджер с единственным HMAC секретом (HS256)
+func NewHMACKeyManager(secret string) *KeyManager {
+	key := &jwtKey{
+		kid:     hmacKeyID,
+		method:  jwt.SigningMethodHS256,
+		private: []byte(secret),
+		public:  []byte(secret),
+	}
+	return &KeyManager{
+		signing:      key,
+		verification: map[string]*jwtKey{key.kid: key},
+	}
+}
+
+// NewKeyManager создаёт менеджер из PEM: закрытого ключа для подписи и, необязательно,
+// ключей прошлых поколений (открытых или закрытых), которыми подписаны ещё действующие токены
+func NewKeyManager(signingPEM []byte, verificationPEMs ...[]byte) (*KeyManager, error) {
+	signing, err := parsePEMKey(signingPEM)
+	if err != nil {
+		return nil, fmt.Errorf("ключ подписи: %v", err)
+	}
+	if signing.private == nil {
+		return nil, errors.New("ключ подписи: нужен закрытый ключ")
+	}
+
+	m := &KeyManager{
+		signing:      signing,
+		verification: map[string]*jwtKey{signing.kid: signing},
+	}
+
+	for i, data := range verificationPEMs {
+		key, err := parsePEMKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("ключ проверки №%d: %v", i+1, err)
+		}
+		if _, exists := m.verification[key.kid]; !exists {
+			m.verification[key.kid] = key
+		}
+	}
+
+	return m, nil
+}
+
+// LoadKeys загружает ключи согласно конфигурации и делает их текущими.
+// Без JWT_SIGNING_KEY_FILE используется HMAC секрет JWT_SECRET.
+func LoadKeys() error {
+	if config.JWTSigningKeyFile == "" {
+		SetKeyManager(NewHMACKeyManager(config.JWTSecret))
+		return nil
+	}
+
+	signingPEM, err := os.ReadFile(config.JWTSigningKeyFile)
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать ключ подписи: %v", err)
+	}
+
+	var verificationPEMs [][]byte
+	for _, path := range config.JWTVerificationKeyFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("не удалось прочитать ключ проверки %s: %v", path, err)
+		}
+		verificationPEMs = append(verificationPEMs, data)
+	}
+
+	m, err := NewKeyManager(signingPEM, verificationPEMs...)
+	if err != nil {
+		return err
+	}
+	SetKeyManager(m)
+	return nil
+}
+
+// SetKeyManager делает менеджер текущим
+func SetKeyManager(m *KeyManager) {
+	keyManagerMu.Lock()
+	defer keyManagerMu.Unlock()
+	keyManager = m
+}
+
+// currentKeyManager возвращает текущий менеджер. Пока ключи не загружены
+// (например, в тестах), используется HMAC с секретом из конфигурации.
+func currentKeyManager() *KeyManager {
+	keyManagerMu.RLock()
+	m := keyManager
+	keyManagerMu.RUnlock()
+
+	if m != nil {
+		return m
+	}
+	secret := config.JWTSecret
+	if secret == "" {
+		secret = config.DefaultJWTSecret
+	}
+	return NewHMACKeyManager(secret)
+}
+
+// Sign подписывает claims текущим ключом и указывает его kid в заголовке
+func (m *KeyManager) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(m.signing.method, claims)
+	token.Header["kid"] = m.signing.kid
+	return token.SignedString(m.signing.private)
+}
+
+// keyFunc находит ключ проверки по kid и следит, чтобы алгоритм токена
+// совпадал с алгоритмом ключа (иначе возможна подмена RS256 на HS256)
+func (m *KeyManager) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	key, ok := m.verification[kid]
+	if !ok && kid == "" && m.signing.kid == hmacKeyID {
+		// Токены, выданные до появления kid, подписаны тем же HMAC секретом
+		key, ok = m.signing, true
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: неизвестный kid %q", jwt.ErrTokenUnverifiable, kid)
+	}
+
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, jwt.ErrSignatureInvalid
+	}
+	return key.public, nil
+}
+
+// JWKS возвращает открытые ключи для публикации. HMAC секрет не публикуется никогда.
+func (m *KeyManager) JWKS() []JWK {
+	keys := []JWK{}
+
+	// Текущий ключ подписи — первым, остальные — в порядке kid для стабильного ответа
+	if jwk, ok := toJWK(m.signing); ok {
+		keys = append(keys, jwk)
+	}
+	kids := make([]string, 0, len(m.verification))
+	for kid := range m.verification {
+		if kid != m.signing.kid {
+			kids = append(kids, kid)
+		}
+	}
+	sort.Strings(kids)
+	for _, kid := range kids {
+		if jwk, ok := toJWK(m.verification[kid]); ok {
+			keys = append(keys, jwk)
+		}
+	}
+
+	return keys
+}
+
+// PublicJWKS возвращает открытые ключи текущего менеджера
+func PublicJWKS() []JWK {
+	return currentKeyManager().JWKS()
+}
+
+// parsePEMKey разбирает PEM с ключом Ed25519 или RSA (закрытым или открытым)
+func parsePEMKey(data []byte) (*jwtKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("файл не содержит PEM блок")
+	}
+
+	var private, public interface{}
+	var err error
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		public, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		public, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("неподдерживаемый тип PEM блока %q", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось разобрать ключ: %v", err)
+	}
+
+	switch k := private.(type) {
+	case nil:
+	case ed25519.PrivateKey:
+		public = k.Public()
+	case *rsa.PrivateKey:
+		public = &k.PublicKey
+	default:
+		return nil, fmt.Errorf("неподдерживаемый тип ключа %T (нужен Ed25519 или RSA)", private)
+	}
+
+	key := &jwtKey{private: private, public: public}
+	switch pub := public.(type) {
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA ключ слишком короткий: %d бит, нужно не меньше %d", pub.N.BitLen(), minRSAKeyBits)
+		}
+		key.method = jwt.SigningMethodRS256
+	default:
+		return nil, fmt.Errorf("неподдерживаемый тип ключа %T (нужен Ed25519 или RSA)", public)
+	}
+
+	jwk, _ := toJWK(key)
+	key.kid = jwkThumbprint(jwk)
+	return key, nil
+}
+
+// toJWK описывает открытую часть ключа в формате JWK (для HMAC — false)
+func toJWK(key *jwtKey) (JWK, bool) {
+	b64 := base64.RawURLEncoding.EncodeToString
+
+	switch pub := key.public.(type) {
+	case ed25519.PublicKey:
+		return JWK{Kty: "OKP", Kid: key.kid, Use: "sig", Alg: key.method.Alg(), Crv: "Ed25519", X: b64(pub)}, true
+	case *rsa.PublicKey:
+		e := big.NewInt(int64(pub.E)).Bytes()
+		return JWK{Kty: "RSA", Kid: key.kid, Use: "sig", Alg: key.method.Alg(), N: b64(pub.N.Bytes()), E: b64(e)}, true
+	default:
+		return JWK{}, false
+	}
+}
+
+// jwkThumbprint вычисляет отпечаток ключа по RFC 7638 — он и служит kid,
+// поэтому kid не меняется, когда ключ переносят из подписи в список проверки
+func jwkThumbprint(jwk JWK) string {
+	var canonical string
+	switch jwk.Kty {
+	case "OKP":
+		canonical = fmt.Sprintf(`{"crv":%q,"kty":"OKP","x":%q}`, jwk.Crv, jwk.X)
+	case "RSA":
+		canonical = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, jwk.E, jwk.N)
+	}
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func ed25519PEM(t *testing.T) []byte {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func testClaims() *Claims {
+	return &Claims{
+		UserID: 42,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}
+}
+
+func parseWith(m *KeyManager, token string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(token, claims, m.keyFunc)
+	return claims, err
+}
+
+func TestKeyManager_EdDSARotation(t *testing.T) {
+	oldPEM := ed25519PEM(t)
+	newPEM := ed25519PEM(t)
+
+	oldManager, err := NewKeyManager(oldPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldToken, err := oldManager.Sign(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Новый ключ подписывает, старый остаётся только для проверки
+	rotated, err := NewKeyManager(newPEM, oldPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := parseWith(rotated, oldToken)
+	if err != nil {
+		t.Fatalf("Токен, подписанный старым ключом, должен приниматься: %v", err)
+	}
+	if claims.UserID != 42 {
+		t.Errorf("UserID = %d, ожидалось 42", claims.UserID)
+	}
+
+	newToken, err := rotated.Sign(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseWith(oldManager, newToken); err == nil {
+		t.Error("Старый менеджер не знает нового ключа и должен отклонить токен")
+	}
+
+	jwks := rotated.JWKS()
+	if len(jwks) != 2 {
+		t.Fatalf("В JWKS ожидалось 2 ключа, получено %d", len(jwks))
+	}
+	if jwks[0].Kid != rotated.signing.kid || jwks[0].Alg != "EdDSA" || jwks[0].Crv != "Ed25519" {
+		t.Errorf("Первым должен идти текущий ключ подписи: %+v", jwks[0])
+	}
+	if jwks[1].Kid != oldManager.signing.kid {
+		t.Error("kid ключа не должен меняться при переносе в список проверки")
+	}
+}
+
+func TestKeyManager_RS256(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)})
+
+	m, err := NewKeyManager(privatePEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := m.Sign(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseWith(m, token); err != nil {
+		t.Fatalf("Токен RS256 должен приниматься: %v", err)
+	}
+
+	// Подмена алгоритма: HS256, подписанный открытым ключом как секретом, с тем же kid
+	publicDER, _ := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+	forged.Header["kid"] = m.signing.kid
+	forgedToken, err := forged.SignedString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseWith(m, forgedToken); err == nil {
+		t.Error("Токен с подменённым алгоритмом должен отклоняться")
+	}
+
+	if jwks := m.JWKS(); len(jwks) != 1 || jwks[0].Kty != "RSA" || jwks[0].E != "AQAB" {
+		t.Errorf("Неверный JWK для RSA: %+v", jwks)
+	}
+}
+
+func TestKeyManager_HMAC(t *testing.T) {
+	m := NewHMACKeyManager("secret")
+
+	token, err := m.Sign(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseWith(m, token); err != nil {
+		t.Fatal(err)
+	}
+
+	// Токены, выданные до появления kid, продолжают приниматься
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims()).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseWith(m, legacy); err != nil {
+		t.Errorf("Токен без kid должен приниматься: %v", err)
+	}
+
+	if _, err := parseWith(NewHMACKeyManager("other"), token); !errors.Is(err, jwt.ErrSignatureInvalid) {
+		t.Errorf("Ожидалась ошибка подписи, получено %v", err)
+	}
+
+	if len(m.JWKS()) != 0 {
+		t.Error("HMAC секрет не должен публиковаться в JWKS")
+	}
+}
+
+func TestNewKeyManager_RejectsPublicSigningKey(t *testing.T) {
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKIXPublicKey(public)
+	if _, err := NewKeyManager(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})); err == nil {
+		t.Error("Открытым ключом нельзя подписывать")
+	}
+}
@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// mentionPattern — "@имя" в начале текста или после символа, который не может быть частью имени
+// или адреса почты (чтобы "user@example.com" не считался упоминанием)
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@.])@([\p{L}\p{N}_]{3,50})`)
+
+// ParseMentions возвращает имена пользователей, упомянутых в тексте через @, без повторов
+// (без учёта регистра) в порядке появления
+func ParseMentions(text string) []string {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		key := strings.ToLower(match[1])
+		if !seen[key] {
+			seen[key] = true
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
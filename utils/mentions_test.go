@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"без упоминаний", "Просто текст", []string{}},
+		{"в начале", "@alice посмотри", []string{"alice"}},
+		{"несколько", "@alice и @bob_2, гляньте", []string{"alice", "bob_2"}},
+		{"кириллица", "Спроси у @иван_петров.", []string{"иван_петров"}},
+		{"повтор без учёта регистра", "@Alice @alice", []string{"Alice"}},
+		{"адрес почты", "пиши на alice@example.com", []string{}},
+		{"слишком короткое", "@ab", []string{}},
+		{"в скобках", "(@carol)", []string{"carol"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseMentions(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseMentions(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
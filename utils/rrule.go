@@ -0,0 +1,295 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Поддерживаемые частоты повторения RRULE (RFC 5545)
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+)
+
+// maxRRulePeriods — сколько периодов (дней, недель, месяцев) перебирается в поиске повторений,
+// чтобы правило без подходящих дат не зациклило сервер
+const maxRRulePeriods = 100000
+
+// rruleWeekdays — дни недели в нотации RFC 5545
+var rruleWeekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// RRule — правило повторения из подмножества RFC 5545: ежедневно, еженедельно по дням недели,
+// ежемесячно по числам месяца, с ограничением COUNT или UNTIL. Неделя начинается с понедельника.
+type RRule struct {
+	Freq       string
+	Interval   int            // каждые Interval периодов (по умолчанию 1)
+	ByDay      []time.Weekday // для WEEKLY: дни недели (пусто — день недели первого повторения)
+	ByMonthDay []int          // для MONTHLY: числа месяца, отрицательные — с конца (-1 — последнее)
+	Count      int            // всего повторений, считая первое (0 — без ограничения)
+	Until      time.Time      // последнее допустимое время (нулевое — без ограничения)
+	untilDate  bool           // UNTIL задан датой без времени — включительно до конца этого дня
+}
+
+// ParseRRule разбирает правило вида "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10" (префикс "RRULE:" допускается)
+func ParseRRule(value string) (*RRule, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(strings.ToUpper(value)), "RRULE:")
+	if value == "" {
+		return nil, fmt.Errorf("пустое правило повторения")
+	}
+
+	rule := &RRule{Interval: 1}
+	seen := map[string]bool{}
+
+	for _, part := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok || val == "" {
+			return nil, fmt.Errorf("некорректная часть правила %q", part)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("параметр %s указан дважды", key)
+		}
+		seen[key] = true
+
+		switch key {
+		case "FREQ":
+			switch val {
+			case FreqDaily, FreqWeekly, FreqMonthly:
+				rule.Freq = val
+			default:
+				return nil, fmt.Errorf("FREQ должен быть DAILY, WEEKLY или MONTHLY")
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 || n > 1000 {
+				return nil, fmt.Errorf("INTERVAL должен быть числом от 1 до 1000")
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("COUNT должен быть положительным числом")
+			}
+			rule.Count = n
+		case "UNTIL":
+			if t, err := time.Parse("20060102T150405Z", val); err == nil {
+				rule.Until = t
+			} else if t, err := time.Parse("20060102", val); err == nil {
+				rule.Until, rule.untilDate = t, true
+			} else {
+				return nil, fmt.Errorf("UNTIL должен быть в формате ГГГГММДД или ГГГГММДДTЧЧММССZ")
+			}
+		case "BYDAY":
+			for _, day := range strings.Split(val, ",") {
+				weekday, ok := rruleWeekdays[day]
+				if !ok {
+					return nil, fmt.Errorf("неизвестный день недели %q в BYDAY", day)
+				}
+				rule.ByDay = append(rule.ByDay, weekday)
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(val, ",") {
+				n, err := strconv.Atoi(day)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, fmt.Errorf("BYMONTHDAY должен быть числом от 1 до 31 или от -31 до -1")
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, n)
+			}
+		default:
+			return nil, fmt.Errorf("параметр %s не поддерживается", key)
+		}
+	}
+
+	switch {
+	case rule.Freq == "":
+		return nil, fmt.Errorf("не указан FREQ")
+	case rule.Count > 0 && !rule.Until.IsZero():
+		return nil, fmt.Errorf("COUNT и UNTIL нельзя указывать вместе")
+	case len(rule.ByDay) > 0 && rule.Freq != FreqWeekly:
+		return nil, fmt.Errorf("BYDAY поддерживается только с FREQ=WEEKLY")
+	case len(rule.ByMonthDay) > 0 && rule.Freq != FreqMonthly:
+		return nil, fmt.Errorf("BYMONTHDAY поддерживается только с FREQ=MONTHLY")
+	}
+
+	return rule, nil
+}
+
+// String возвращает правило в каноническом виде для хранения
+func (r *RRule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, weekday := range sortedWeekdays(r.ByDay) {
+			for name, d := range rruleWeekdays {
+				if d == weekday {
+					days = append(days, name)
+				}
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, day := range r.ByMonthDay {
+			days[i] = strconv.Itoa(day)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		if r.untilDate {
+			parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+		} else {
+			parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next возвращает первое повторение строго после after для серии, начавшейся в dtstart.
+// false — повторений больше нет (исчерпан COUNT или наступил UNTIL).
+func (r *RRule) Next(dtstart, after time.Time) (time.Time, bool) {
+	next := r.Occurrences(dtstart, after, 1)
+	if len(next) == 0 {
+		return time.Time{}, false
+	}
+	return next[0], true
+}
+
+// Occurrences возвращает до n повторений строго после after для серии, начавшейся в dtstart.
+// Дни недели и числа месяца считаются в часовом поясе dtstart, время суток берётся из dtstart.
+func (r *RRule) Occurrences(dtstart, after time.Time, n int) []time.Time {
+	result := []time.Time{}
+	if n <= 0 {
+		return result
+	}
+
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	until := r.Until
+	if r.untilDate {
+		// Дата без времени — включительно до конца дня в часовом поясе серии
+		until = time.Date(until.Year(), until.Month(), until.Day()+1, 0, 0, 0, 0, dtstart.Location()).Add(-time.Nanosecond)
+	}
+
+	count := 0
+	for period := 0; period < maxRRulePeriods; period++ {
+		for _, candidate := range r.period(dtstart, period*interval) {
+			if candidate.Before(dtstart) {
+				continue
+			}
+			if !until.IsZero() && candidate.After(until) {
+				return result
+			}
+			count++
+			if r.Count > 0 && count > r.Count {
+				return result
+			}
+			if candidate.After(after) {
+				result = append(result, candidate)
+				if len(result) == n {
+					return result
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// period возвращает по возрастанию даты-кандидаты периода с номером offset (в днях, неделях или месяцах от dtstart)
+func (r *RRule) period(dtstart time.Time, offset int) []time.Time {
+	y, m, d := dtstart.Date()
+	hh, mm, ss := dtstart.Clock()
+	loc := dtstart.Location()
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, hh, mm, ss, 0, loc)
+	}
+
+	switch r.Freq {
+	case FreqDaily:
+		return []time.Time{at(y, m, d+offset)}
+
+	case FreqWeekly:
+		days := r.ByDay
+		if len(days) == 0 {
+			days = []time.Weekday{dtstart.Weekday()}
+		}
+		// Понедельник недели, в которую попадает dtstart, плюс offset недель
+		monday := d - weekdayIndex(dtstart.Weekday()) + 7*offset
+		candidates := make([]time.Time, 0, len(days))
+		for _, weekday := range sortedWeekdays(days) {
+			candidates = append(candidates, at(y, m, monday+weekdayIndex(weekday)))
+		}
+		return candidates
+
+	case FreqMonthly:
+		first := time.Date(y, m+time.Month(offset), 1, 0, 0, 0, 0, loc)
+		daysInMonth := time.Date(first.Year(), first.Month()+1, 0, 0, 0, 0, 0, loc).Day()
+
+		monthDays := r.ByMonthDay
+		if len(monthDays) == 0 {
+			monthDays = []int{d}
+		}
+		seen := map[int]bool{}
+		resolved := make([]int, 0, len(monthDays))
+		for _, day := range monthDays {
+			if day < 0 {
+				day = daysInMonth + day + 1
+			}
+			// Несуществующие даты (31 апреля, 30 февраля) пропускаются, как требует RFC 5545
+			if day < 1 || day > daysInMonth || seen[day] {
+				continue
+			}
+			seen[day] = true
+			resolved = append(resolved, day)
+		}
+		sort.Ints(resolved)
+
+		candidates := make([]time.Time, 0, len(resolved))
+		for _, day := range resolved {
+			candidates = append(candidates, at(first.Year(), first.Month(), day))
+		}
+		return candidates
+	}
+
+	return nil
+}
+
+// weekdayIndex — номер дня недели, начиная с понедельника (0) до воскресенья (6)
+func weekdayIndex(weekday time.Weekday) int {
+	return (int(weekday) + 6) % 7
+}
+
+// sortedWeekdays возвращает дни недели без повторов в порядке с понедельника
+func sortedWeekdays(days []time.Weekday) []time.Weekday {
+	seen := map[time.Weekday]bool{}
+	result := make([]time.Weekday, 0, len(days))
+	for _, day := range days {
+		if !seen[day] {
+			seen[day] = true
+			result = append(result, day)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return weekdayIndex(result[i]) < weekdayIndex(result[j]) })
+	return result
+}
@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    string // каноническая форма, пусто — ожидается ошибка
+		wantErr bool
+	}{
+		{"ежедневно", "FREQ=DAILY", "FREQ=DAILY", false},
+		{"с префиксом и в нижнем регистре", "rrule:freq=daily;interval=2", "FREQ=DAILY;INTERVAL=2", false},
+		{"по дням недели", "FREQ=WEEKLY;BYDAY=FR,MO,WE", "FREQ=WEEKLY;BYDAY=MO,WE,FR", false},
+		{"по числам месяца", "FREQ=MONTHLY;BYMONTHDAY=1,-1;COUNT=6", "FREQ=MONTHLY;BYMONTHDAY=1,-1;COUNT=6", false},
+		{"UNTIL датой", "FREQ=DAILY;UNTIL=20240131", "FREQ=DAILY;UNTIL=20240131", false},
+		{"UNTIL с временем", "FREQ=DAILY;UNTIL=20240131T120000Z", "FREQ=DAILY;UNTIL=20240131T120000Z", false},
+		{"пустое", "", "", true},
+		{"без FREQ", "INTERVAL=2", "", true},
+		{"YEARLY не поддерживается", "FREQ=YEARLY", "", true},
+		{"COUNT и UNTIL вместе", "FREQ=DAILY;COUNT=3;UNTIL=20240131", "", true},
+		{"BYDAY не с WEEKLY", "FREQ=DAILY;BYDAY=MO", "", true},
+		{"неизвестный день", "FREQ=WEEKLY;BYDAY=XX", "", true},
+		{"нулевой BYMONTHDAY", "FREQ=MONTHLY;BYMONTHDAY=0", "", true},
+		{"нулевой интервал", "FREQ=DAILY;INTERVAL=0", "", true},
+		{"повтор параметра", "FREQ=DAILY;FREQ=WEEKLY", "", true},
+		{"неизвестный параметр", "FREQ=DAILY;BYHOUR=9", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRRule(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRRule(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if err == nil && rule.String() != tt.want {
+				t.Errorf("String() = %q, want %q", rule.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRRuleOccurrences(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Skip("нет базы часовых поясов")
+	}
+
+	date := func(s string) time.Time {
+		tm, err := time.ParseInLocation("2006-01-02 15:04", s, moscow)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+
+	tests := []struct {
+		name    string
+		rule    string
+		dtstart string
+		after   string
+		n       int
+		want    []string
+	}{
+		{
+			"каждые два дня", "FREQ=DAILY;INTERVAL=2", "2024-01-30 09:00", "2024-01-30 09:00", 3,
+			[]string{"2024-02-01 09:00", "2024-02-03 09:00", "2024-02-05 09:00"},
+		},
+		{
+			// 2024-01-03 — среда: понедельник этой недели уже прошёл
+			"по понедельникам и пятницам", "FREQ=WEEKLY;BYDAY=MO,FR", "2024-01-03 08:00", "2024-01-03 08:00", 4,
+			[]string{"2024-01-05 08:00", "2024-01-08 08:00", "2024-01-12 08:00", "2024-01-15 08:00"},
+		},
+		{
+			"раз в две недели", "FREQ=WEEKLY;INTERVAL=2", "2024-01-01 10:00", "2024-01-01 10:00", 2,
+			[]string{"2024-01-15 10:00", "2024-01-29 10:00"},
+		},
+		{
+			// 31-го числа бывает не каждый месяц
+			"31-го числа", "FREQ=MONTHLY;BYMONTHDAY=31", "2024-01-31 12:00", "2024-01-31 12:00", 2,
+			[]string{"2024-03-31 12:00", "2024-05-31 12:00"},
+		},
+		{
+			"последний день месяца", "FREQ=MONTHLY;BYMONTHDAY=-1", "2024-01-31 12:00", "2024-01-31 12:00", 2,
+			[]string{"2024-02-29 12:00", "2024-03-31 12:00"},
+		},
+		{
+			"COUNT считается от начала серии", "FREQ=DAILY;COUNT=3", "2024-01-01 09:00", "2024-01-01 09:00", 5,
+			[]string{"2024-01-02 09:00", "2024-01-03 09:00"},
+		},
+		{
+			"UNTIL датой включительно", "FREQ=DAILY;UNTIL=20240103", "2024-01-01 23:00", "2024-01-01 23:00", 5,
+			[]string{"2024-01-02 23:00", "2024-01-03 23:00"},
+		},
+		{
+			"серия закончилась", "FREQ=DAILY;COUNT=2", "2024-01-01 09:00", "2024-01-02 09:00", 1,
+			[]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRRule(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := rule.Occurrences(date(tt.dtstart), date(tt.after), tt.n)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Occurrences() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(date(tt.want[i])) {
+					t.Errorf("Occurrences()[%d] = %v, want %s", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Параметры TOTP (RFC 6238) — значения по умолчанию, которые понимают все приложения-аутентификаторы
+const (
+	totpPeriod = 30 // длительность шага в секундах
+	totpDigits = 6  // количество цифр в коде
+	totpSkew   = 1  // допустимое расхождение часов в шагах (±30 секунд)
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret создаёт случайный секрет (160 бит) в base32
+func GenerateTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// TOTPURI формирует otpauth:// ссылку для QR-кода в приложении-аутентификаторе
+func TOTPURI(issuer, account, secret string) string {
+	label := url.PathEscape(issuer + ":" + account)
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(totpDigits))
+	params.Set("period", fmt.Sprint(totpPeriod))
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// TOTPCode вычисляет код для момента времени t
+func TOTPCode(secret string, t time.Time) (string, error) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, uint64(t.Unix()/totpPeriod), totpDigits), nil
+}
+
+// ValidateTOTP проверяет код с учётом расхождения часов.
+// Возвращает номер шага, которому соответствует код, чтобы вызывающий
+// мог запретить повторное использование того же кода.
+func ValidateTOTP(secret, code string, t time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return 0, false
+	}
+
+	current := t.Unix() / totpPeriod
+	for delta := int64(-totpSkew); delta <= totpSkew; delta++ {
+		step := current + delta
+		expected := hotp(key, uint64(step), totpDigits)
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// decodeTOTPSecret декодирует base32 секрет (регистр и пробелы не важны)
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	secret = strings.TrimRight(secret, "=")
+	return totpEncoding.DecodeString(secret)
+}
+
+// hotp вычисляет HOTP код (RFC 4226) для счётчика
+func hotp(key []byte, counter uint64, digits int) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// Динамическое усечение
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
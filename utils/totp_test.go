@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+func TestHOTP_RFC6238Vectors(t *testing.T) {
+	// Тестовые значения из RFC 6238 (приложение B, SHA1)
+	key := []byte("12345678901234567890")
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+	}
+
+	for _, tt := range tests {
+		if got := hotp(key, uint64(tt.unix/30), 8); got != tt.want {
+			t.Errorf("hotp(T=%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(59, 0)
+
+	tests := []struct {
+		name string
+		code string
+		at   time.Time
+		want bool
+	}{
+		{"текущий код", "287082", now, true},
+		{"код с предыдущего шага", "287082", now.Add(30 * time.Second), true},
+		{"слишком старый код", "287082", now.Add(90 * time.Second), false},
+		{"неверный код", "000000", now, false},
+		{"неверная длина", "28708", now, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := ValidateTOTP(secret, tt.code, tt.at); got != tt.want {
+				t.Errorf("ValidateTOTP() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,21 +1,25 @@
 package utils
 
 import (
+	"net/mail"
 	"strings"
 	"unicode"
 )
 
-// ValidateEmail проверяет, что email имеет правильный формат
+// ValidateEmail проверяет, что email имеет правильный формат:
+// один адрес без имени и угловых скобок, домен с точкой, без пробелов
 func ValidateEmail(email string) bool {
 	email = strings.TrimSpace(strings.ToLower(email))
-	if !strings.Contains(email, "@") {
+	if len(email) > 254 || strings.ContainsAny(email, " \t\r\n") {
 		return false
 	}
-	parts := strings.Split(email, "@")
-	if len(parts) != 2 {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
 		return false
 	}
-	if len(parts[0]) == 0 || len(parts[1]) == 0 {
+	at := strings.LastIndex(email, "@")
+	domain := email[at+1:]
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
 		return false
 	}
 	return true
@@ -63,6 +67,35 @@ func ValidateTaskTitle(title string) (bool, string) {
 	return true, ""
 }
 
+// ValidateTagName проверяет название метки. Запятая запрещена:
+// через неё метки перечисляются в фильтрах списка задач.
+func ValidateTagName(name string) (bool, string) {
+	name = strings.TrimSpace(name)
+	if len(name) == 0 {
+		return false, "Название метки не может быть пустым"
+	}
+	if len([]rune(name)) > 50 {
+		return false, "Название метки слишком длинное"
+	}
+	if strings.Contains(name, ",") {
+		return false, "Название метки не может содержать запятую"
+	}
+	return true, ""
+}
+
+// ValidateColor проверяет цвет в формате #rrggbb
+func ValidateColor(color string) bool {
+	if len(color) != 7 || color[0] != '#' {
+		return false
+	}
+	for _, c := range color[1:] {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
+
 // ValidateTaskStatus проверяет статус задачи
 func ValidateTaskStatus(status string) bool {
 	validStatuses := []string{"pending", "in_progress", "completed"}
@@ -16,6 +16,11 @@ func TestValidateEmail(t *testing.T) {
 		{"пустой email", "", false},
 		{"только домен", "@example.com", false},
 		{"только имя", "test@", false},
+		{"домен без точки", "test@localhost", false},
+		{"два @", "test@@example.com", false},
+		{"пробел внутри", "te st@example.com", false},
+		{"с именем отправителя", "Test <test@example.com>", false},
+		{"поддомен и плюс", "test+tag@mail.example.com", true},
 	}
 
 	for _, tt := range tests {
@@ -74,3 +79,33 @@ func TestValidateTaskTitle(t *testing.T) {
 	}
 }
 
+
+func TestValidateTagName(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  string
+		want bool
+	}{
+		{"пустое название", "  ", false},
+		{"валидное название", "работа", true},
+		{"с запятой", "a,b", false},
+		{"слишком длинное", strings.Repeat("я", 51), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := ValidateTagName(tt.tag)
+			if got != tt.want {
+				t.Errorf("ValidateTagName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateColor(t *testing.T) {
+	for color, want := range map[string]bool{"#a1B2c3": true, "a1b2c3": false, "#abc": false, "#ggg000": false} {
+		if got := ValidateColor(color); got != want {
+			t.Errorf("ValidateColor(%q) = %v, want %v", color, got, want)
+		}
+	}
+}